@@ -0,0 +1,8 @@
+package common
+
+// DisplayProvider converts between positions in the window and on the virtual surface, which is scaled into the window
+// according to the scale mode.
+type DisplayProvider interface {
+	ScreenToSurface(x, y float64) (surfaceX, surfaceY float64)
+	SurfaceToScreen(x, y float64) (screenX, screenY float64)
+}
@@ -0,0 +1,17 @@
+package common
+
+import (
+	"github.com/OpenDiablo2/AbyssEngine/input"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+)
+
+// MouseProvider gives nodes the state of the mouse for the current frame. See input.Mouse for the capture rules.
+type MouseProvider interface {
+	MousePositionProvider
+	Events() []input.MouseEvent
+	IsButtonDown(button renderer.MouseButton) bool
+	Capture(owner interface{}) bool
+	Captured() interface{}
+	HasCapture(owner interface{}) bool
+	ReleaseCapture(owner interface{})
+}
@@ -1,18 +1,15 @@
 package common
 
-import rl "github.com/gen2brain/raylib-go/raylib"
+import "github.com/OpenDiablo2/AbyssEngine/renderer"
 
 type PalTex struct {
-	Texture rl.Texture2D
+	Texture renderer.Texture
 	Data    []byte
 	Init    bool
 }
 
 //TODO: Yeah yeah, move this out
 var (
-	PaletteShader          rl.Shader
-	PaletteShaderLoc       int32
-	PaletteShaderOffsetLoc int32
 	PaletteTexture         map[string]*PalTex
 	PaletteTextShiftOffset int
 	PaletteTransformsCount int
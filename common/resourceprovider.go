@@ -0,0 +1,23 @@
+package common
+
+const (
+	ResourceTypeBytes     = "bytes"
+	ResourceTypeCOF       = "cof"
+	ResourceTypeDC6       = "dc6"
+	ResourceTypeDCC       = "dcc"
+	ResourceTypeDS1       = "ds1"
+	ResourceTypeDT1       = "dt1"
+	ResourceTypeFontTable = "tbl"
+	ResourceTypePalette   = "pl2"
+)
+
+// ResourceHandle is a reference to a shared, decoded resource. The value must not be modified, and Release must be
+// called once it is no longer used.
+type ResourceHandle interface {
+	Value() interface{}
+	Release()
+}
+
+type ResourceProvider interface {
+	Acquire(path string, resourceType string) (ResourceHandle, error)
+}
@@ -1,6 +1,58 @@
 package engine
 
+const (
+	defaultVirtualWidth  = 800
+	defaultVirtualHeight = 600
+)
+
 type Configuration struct {
 	RootPath     string   `json:"-"`
 	MpqLoadOrder []string `json:"mpqLoadOrder"`
+	ZipLoadOrder []string `json:"zipLoadOrder"`
+
+	// ModPath is the directory that mods (directories or zip files) are mounted from, relative to the root path
+	ModPath string `json:"modPath"`
+
+	// DevMode enables developer features, such as reloading scripts and assets when they change on disk
+	DevMode bool `json:"devMode"`
+
+	// DebugOverlay shows the debug overlay from the start. In dev mode, it is toggled with F3.
+	DebugOverlay bool `json:"debugOverlay"`
+
+	// DisableBatching draws every sprite and glyph with a draw call of its own, instead of batching them. It is meant
+	// for comparing the number of draw calls with and without batching.
+	DisableBatching bool `json:"disableBatching"`
+
+	// ResourceCacheBudget is the number of bytes of decoded resources kept in memory after they are no longer in use
+	ResourceCacheBudget int64 `json:"resourceCacheBudget"`
+
+	// VirtualWidth and VirtualHeight are the size of the surface that the game is drawn on, before it is scaled
+	VirtualWidth  int `json:"virtualWidth"`
+	VirtualHeight int `json:"virtualHeight"`
+
+	// WindowWidth and WindowHeight are the initial size of the window
+	WindowWidth  int  `json:"windowWidth"`
+	WindowHeight int  `json:"windowHeight"`
+	Fullscreen   bool `json:"fullscreen"`
+
+	// ScaleMode is how the virtual surface is fitted to the window: letterbox, integer or stretch
+	ScaleMode string `json:"scaleMode"`
+
+	// KeyBindingsPath is the file the player's key bindings are saved to. It defaults to keybindings.json in the
+	// user's configuration directory.
+	KeyBindingsPath string `json:"keyBindingsPath"`
+}
+
+// DefaultConfiguration returns the configuration used for the settings that config.json leaves out.
+func DefaultConfiguration(rootPath string) Configuration {
+	result := Configuration{
+		RootPath:      rootPath,
+		VirtualWidth:  defaultVirtualWidth,
+		VirtualHeight: defaultVirtualHeight,
+		WindowWidth:   defaultVirtualWidth,
+		WindowHeight:  defaultVirtualHeight,
+		ScaleMode:     ScaleModeLetterbox.ToString(),
+	}
+
+	return result
 }
@@ -0,0 +1,269 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+	"runtime"
+	"strings"
+
+	"github.com/OpenDiablo2/AbyssEngine/loader/resourcecache"
+	"github.com/OpenDiablo2/AbyssEngine/node"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer/atlas"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	debugOverlayLeft       = 5
+	debugOverlayTop        = 5
+	debugOverlayWidth      = 420
+	debugOverlayLineHeight = 16
+	debugOverlayIndent     = "  "
+	// debugOverlayIdLength is how much of the node ids is shown, which is enough to tell them apart
+	debugOverlayIdLength = 8
+)
+
+var (
+	colorDebugBackground = color.NRGBA{A: 180}
+	colorDebugBounds     = color.NRGBA{R: 255, G: 220, B: 0, A: 255}
+)
+
+// debugInfo is the state of the engine shown on the debug overlay, and dumped as JSON for bug reports.
+type debugInfo struct {
+	FPS       int                 `json:"fps"`
+	Memory    debugMemoryInfo     `json:"memory"`
+	Renderer  renderer.Stats      `json:"renderer"`
+	Atlas     atlas.Stats         `json:"atlas"`
+	Resources resourcecache.Stats `json:"resources"`
+	Lua       debugLuaInfo        `json:"lua"`
+	Scenes    []string            `json:"scenes"`
+	Nodes     int                 `json:"nodes"`
+	// Hovered is the id of the node under the mouse that takes mouse input, if there is one
+	Hovered string         `json:"hovered,omitempty"`
+	Tree    node.DebugInfo `json:"tree"`
+}
+
+type debugMemoryInfo struct {
+	Alloc         uint64  `json:"alloc"`
+	Sys           uint64  `json:"sys"`
+	NumGC         uint32  `json:"numGC"`
+	GCCPUFraction float64 `json:"gcCPUFraction"`
+}
+
+// debugLuaInfo counts the objects the scripts keep alive in the engine.
+type debugLuaInfo struct {
+	Threads  int `json:"threads"`
+	Tweens   int `json:"tweens"`
+	Handlers int `json:"handlers"`
+}
+
+func (e *Engine) debugInfo() debugInfo {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	result := debugInfo{
+		FPS: e.renderer.FPS(),
+		Memory: debugMemoryInfo{
+			Alloc:         memStats.Alloc,
+			Sys:           memStats.Sys,
+			NumGC:         memStats.NumGC,
+			GCCPUFraction: memStats.GCCPUFraction,
+		},
+		Renderer:  e.renderer.Stats(),
+		Atlas:     e.atlas.Stats(),
+		Resources: e.resources.Stats(),
+		Lua: debugLuaInfo{
+			Tweens:   e.tweens.Count(),
+			Handlers: len(e.keyHandlers) + len(e.textHandlers) + len(e.mouseHandlers) + len(e.reloadHandlers),
+		},
+		Scenes: make([]string, 0),
+		Tree:   e.rootNode.DebugInfo(),
+	}
+
+	if e.scheduler != nil {
+		result.Lua.Threads = e.scheduler.ThreadCount()
+	}
+
+	for _, handlers := range e.actionHandlers {
+		result.Lua.Handlers += len(handlers)
+	}
+
+	for _, s := range e.scenes.Stack() {
+		result.Scenes = append(result.Scenes, s.Name)
+	}
+
+	result.Nodes = result.Tree.Count()
+
+	if hovered := e.mouseDispatch.Hovered(); hovered != nil {
+		result.Hovered = hovered.Id.String()
+	}
+
+	return result
+}
+
+// debugInfoJSON returns the debug info as indented JSON.
+func (e *Engine) debugInfoJSON() (string, error) {
+	result, err := json.MarshalIndent(e.debugInfo(), "", "  ")
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// updateDebugOverlay toggles the debug overlay with F3 in dev mode, and copies the debug info to the clipboard with
+// F4 while the overlay is shown.
+func (e *Engine) updateDebugOverlay() {
+	if e.config.DevMode && e.renderer.IsKeyPressed(renderer.KeyF3) {
+		e.debugOverlay = !e.debugOverlay
+	}
+
+	if !e.debugOverlay || !e.renderer.IsKeyPressed(renderer.KeyF4) {
+		return
+	}
+
+	text, err := e.debugInfoJSON()
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to dump the debug info")
+		return
+	}
+
+	e.renderer.SetClipboardText(text)
+	log.Info().Msg("copied the debug info to the clipboard")
+}
+
+// drawDebugOverlay draws the engine stats and the node tree over the left side of the screen, and outlines the
+// hovered node.
+func (e *Engine) drawDebugOverlay() {
+	info := e.debugInfo()
+	_, screenHeight := e.renderer.ScreenSize()
+
+	if bounds, ok := e.hoveredBounds(); ok {
+		e.renderer.DrawRectangleLines(bounds.X, bounds.Y, bounds.Width, bounds.Height, colorDebugBounds)
+	}
+
+	e.renderer.DrawRectangle(0, 0, debugOverlayWidth, screenHeight, colorDebugBackground)
+
+	y := debugOverlayTop
+	drawLine := func(text string, tint color.Color) bool {
+		if y > screenHeight-debugOverlayLineHeight {
+			return false
+		}
+
+		e.renderer.DrawText(text, debugOverlayLeft, y, tint)
+		y += debugOverlayLineHeight
+
+		return true
+	}
+
+	drawLine(fmt.Sprintf("FPS: %d", info.FPS), colorWhite)
+	drawLine(fmt.Sprintf("GC: %d (%%%d)", int(info.Memory.NumGC), int(info.Memory.GCCPUFraction*100)), colorWhite)
+	drawLine(fmt.Sprintf("Alloc: %0.2fMB (%0.2fMB)", megabytes(int64(info.Memory.Alloc)),
+		megabytes(int64(info.Memory.Sys))), colorWhite)
+	drawLine(fmt.Sprintf("Cache: %d hits, %d misses (%0.2fMB)", info.Resources.Hits, info.Resources.Misses,
+		megabytes(info.Resources.Bytes)), colorWhite)
+	drawLine(fmt.Sprintf("Textures: %d (%0.2fMB)", info.Renderer.Textures, megabytes(info.Renderer.TextureBytes)),
+		colorWhite)
+	drawLine(fmt.Sprintf("Draw calls: %d (%d quads, batching %t)", info.Renderer.DrawCalls, info.Renderer.Quads,
+		info.Renderer.Batching), colorWhite)
+	drawLine(fmt.Sprintf("Atlas: %d pages, %d regions", info.Atlas.Pages, info.Atlas.Regions), colorWhite)
+	drawLine(fmt.Sprintf("Lua: %d threads, %d tweens, %d handlers", info.Lua.Threads, info.Lua.Tweens,
+		info.Lua.Handlers), colorWhite)
+	drawLine(fmt.Sprintf("Scenes: %s", strings.Join(info.Scenes, " > ")), colorWhite)
+	drawLine(fmt.Sprintf("Nodes: %d", info.Nodes), colorWhite)
+	drawLine("[F3] Hide    [F4] Copy as JSON", colorBeige)
+	y += debugOverlayLineHeight / 2
+
+	var drawTree func(tree *node.DebugInfo, depth int) bool
+
+	drawTree = func(tree *node.DebugInfo, depth int) bool {
+		tint := color.Color(colorWhite)
+
+		switch {
+		case tree.Id == info.Hovered:
+			tint = colorDebugBounds
+		case !tree.Active || !tree.Visible:
+			tint = colorGray
+		}
+
+		if !drawLine(strings.Repeat(debugOverlayIndent, depth)+debugNodeLine(tree), tint) {
+			return false
+		}
+
+		for idx := range tree.Children {
+			if !drawTree(&tree.Children[idx], depth+1) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	drawTree(&info.Tree, 0)
+}
+
+// debugNodeLine describes a node on one line of the debug overlay, e.g. `sprite "ok" 3KrCuPZz (10,20) hidden`.
+func debugNodeLine(tree *node.DebugInfo) string {
+	result := &strings.Builder{}
+
+	result.WriteString(tree.Type)
+
+	if len(tree.Name) > 0 {
+		result.WriteString(fmt.Sprintf(" %q", tree.Name))
+	}
+
+	result.WriteString(fmt.Sprintf(" %s (%d,%d)", tree.Id[:debugOverlayIdLength], tree.WorldX, tree.WorldY))
+
+	if len(tree.Tags) > 0 {
+		result.WriteString(" #" + strings.Join(tree.Tags, " #"))
+	}
+
+	if !tree.Visible {
+		result.WriteString(" hidden")
+	}
+
+	if !tree.Active {
+		result.WriteString(" inactive")
+	}
+
+	return result.String()
+}
+
+// hoveredBounds returns where the hovered node is drawn on the screen.
+func (e *Engine) hoveredBounds() (node.Bounds, bool) {
+	hovered := e.mouseDispatch.Hovered()
+
+	if hovered == nil {
+		return node.Bounds{}, false
+	}
+
+	bounds, ok := hovered.WorldBounds()
+
+	if !ok {
+		return node.Bounds{}, false
+	}
+
+	rectX, rectY, rectWidth, rectHeight := e.surfaceRect()
+	surfaceWidth, surfaceHeight := e.renderer.SurfaceSize()
+	scaleX := float64(rectWidth) / float64(surfaceWidth)
+	scaleY := float64(rectHeight) / float64(surfaceHeight)
+	left := math.Floor(float64(rectX) + float64(bounds.X)*scaleX)
+	top := math.Floor(float64(rectY) + float64(bounds.Y)*scaleY)
+
+	result := node.Bounds{
+		X:      int(left),
+		Y:      int(top),
+		Width:  int(math.Ceil(float64(rectX)+float64(bounds.X+bounds.Width)*scaleX) - left),
+		Height: int(math.Ceil(float64(rectY)+float64(bounds.Y+bounds.Height)*scaleY) - top),
+	}
+
+	return result, true
+}
+
+func megabytes(bytes int64) float32 {
+	return float32(bytes) / 1024 / 1024
+}
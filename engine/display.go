@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"math"
+
+	"github.com/rs/zerolog/log"
+)
+
+// applyDisplaySettings sizes the virtual surface and the window from the configuration.
+func (e *Engine) applyDisplaySettings() {
+	if e.config.VirtualWidth <= 0 || e.config.VirtualHeight <= 0 {
+		e.config.VirtualWidth = defaultVirtualWidth
+		e.config.VirtualHeight = defaultVirtualHeight
+	}
+
+	scaleMode, err := StringToScaleMode(e.config.ScaleMode)
+
+	if err != nil {
+		log.Error().Err(err).Msgf("invalid scale mode %s, using %s", e.config.ScaleMode, scaleMode.ToString())
+	}
+
+	e.setScaleMode(scaleMode)
+	e.renderer.SetSurfaceSize(e.config.VirtualWidth, e.config.VirtualHeight)
+
+	if e.config.Fullscreen {
+		e.renderer.SetFullscreen(true)
+	}
+}
+
+func (e *Engine) setScaleMode(scaleMode ScaleMode) {
+	e.scaleMode = scaleMode
+	e.config.ScaleMode = scaleMode.ToString()
+}
+
+// surfaceRect returns where the virtual surface is drawn on the screen, according to the scale mode.
+func (e *Engine) surfaceRect() (x, y, width, height float32) {
+	screenWidth, screenHeight := e.renderer.ScreenSize()
+	surfaceWidth, surfaceHeight := e.renderer.SurfaceSize()
+
+	if e.scaleMode == ScaleModeStretch {
+		return 0, 0, float32(screenWidth), float32(screenHeight)
+	}
+
+	scale := math.Min(float64(screenWidth)/float64(surfaceWidth), float64(screenHeight)/float64(surfaceHeight))
+
+	// a window smaller than the surface can't be scaled by a whole number, so it falls back to letterboxing
+	if e.scaleMode == ScaleModeInteger && scale >= 1 {
+		scale = math.Floor(scale)
+	}
+
+	width = float32(float64(surfaceWidth) * scale)
+	height = float32(float64(surfaceHeight) * scale)
+	x = (float32(screenWidth) - width) * 0.5
+	y = (float32(screenHeight) - height) * 0.5
+
+	if e.scaleMode == ScaleModeInteger {
+		// keep the surface on whole pixels so that it is not filtered
+		x = float32(math.Floor(float64(x)))
+		y = float32(math.Floor(float64(y)))
+	}
+
+	return x, y, width, height
+}
+
+// screenToSurface converts a position on the screen to a whole pixel on the virtual surface.
+func (e *Engine) screenToSurface(screenX, screenY int) (X, Y int) {
+	x, y := e.ScreenToSurface(float64(screenX), float64(screenY))
+
+	return int(math.Floor(x)), int(math.Floor(y))
+}
+
+// ScreenToSurface converts a position on the screen to a position on the virtual surface.
+func (e *Engine) ScreenToSurface(x, y float64) (surfaceX, surfaceY float64) {
+	rectX, rectY, rectWidth, rectHeight := e.surfaceRect()
+	surfaceWidth, surfaceHeight := e.renderer.SurfaceSize()
+
+	if rectWidth <= 0 || rectHeight <= 0 {
+		return 0, 0
+	}
+
+	surfaceX = (x - float64(rectX)) * (float64(surfaceWidth) / float64(rectWidth))
+	surfaceY = (y - float64(rectY)) * (float64(surfaceHeight) / float64(rectHeight))
+
+	return surfaceX, surfaceY
+}
+
+// SurfaceToScreen converts a position on the virtual surface to a position on the screen.
+func (e *Engine) SurfaceToScreen(x, y float64) (screenX, screenY float64) {
+	rectX, rectY, rectWidth, rectHeight := e.surfaceRect()
+	surfaceWidth, surfaceHeight := e.renderer.SurfaceSize()
+
+	if surfaceWidth <= 0 || surfaceHeight <= 0 {
+		return 0, 0
+	}
+
+	screenX = float64(rectX) + x*(float64(rectWidth)/float64(surfaceWidth))
+	screenY = float64(rectY) + y*(float64(rectHeight)/float64(surfaceHeight))
+
+	return screenX, screenY
+}
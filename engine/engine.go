@@ -1,41 +1,77 @@
 package engine
 
 import (
-	"fmt"
-	"math"
-	"runtime"
+	"image/color"
+	"path"
+	"sync"
 
 	lua "github.com/yuin/gopher-lua"
 
-	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/input"
 	"github.com/OpenDiablo2/AbyssEngine/loader"
 	"github.com/OpenDiablo2/AbyssEngine/loader/filesystemloader"
+	"github.com/OpenDiablo2/AbyssEngine/loader/resourcecache"
+	"github.com/OpenDiablo2/AbyssEngine/loader/ziploader"
 	"github.com/OpenDiablo2/AbyssEngine/media"
 	"github.com/OpenDiablo2/AbyssEngine/node"
+	"github.com/OpenDiablo2/AbyssEngine/node/camera"
 	"github.com/OpenDiablo2/AbyssEngine/node/sprite"
-	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer/atlas"
+	"github.com/OpenDiablo2/AbyssEngine/scene"
+	"github.com/OpenDiablo2/AbyssEngine/scheduler"
+	"github.com/OpenDiablo2/AbyssEngine/tween"
+	"github.com/OpenDiablo2/AbyssEngine/watcher"
 	"github.com/rs/zerolog/log"
 )
 
 // Engine represents the main game engine
 type Engine struct {
-	config        Configuration
-	loader        *loader.Loader
-	renderSurface rl.RenderTexture2D
-	systemFont    rl.Font
-	bootLogo      rl.Texture2D
-	bootLoadText  string
-	shutdown      bool
-	engineMode    EngineMode
-	cursorSprite  *sprite.Sprite
-	rootNode      *node.Node
-	cursorX       int
-	cursorY       int
-	luaState      *lua.LState
+	config         Configuration
+	loader         *loader.Loader
+	resources      *resourcecache.ResourceCache
+	renderer       renderer.Renderer
+	atlas          *atlas.Atlas
+	bootLogo       renderer.Texture
+	bootLoadText   string
+	shutdown       bool
+	engineMode     EngineMode
+	cursorSprite   *sprite.Sprite
+	rootNode       *node.Node
+	luaState       *lua.LState
+	scheduler      *scheduler.Scheduler
+	dispatchMutex  sync.Mutex
+	dispatched     []func()
+	reloadPaths    map[string]bool
+	watcher        *watcher.Watcher
+	reloadFunc     *lua.LFunction
+	reloadHandlers []*lua.LFunction
+	palettePaths   map[string]string
+	lastError      *engineError
+	scaleMode      ScaleMode
+	input          *input.Input
+	mouse          *input.Mouse
+	mouseDispatch  *node.MouseDispatcher
+	tweens         *tween.Manager
+	scenes         *scene.Manager
+	actions        *input.ActionMap
+	actionHandlers map[string][]*lua.LFunction
+	keyHandlers    []*lua.LFunction
+	textHandlers   []*lua.LFunction
+	mouseHandlers  []*lua.LFunction
+	debugOverlay   bool
+	camera         *camera.Camera
 }
 
+var (
+	colorWhite = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	colorGray  = color.RGBA{R: 130, G: 130, B: 130, A: 255}
+	colorBeige = color.RGBA{R: 211, G: 176, B: 131, A: 255}
+	colorRed   = color.RGBA{R: 224, G: 72, B: 64, A: 255}
+)
+
 func (e *Engine) GetMousePosition() (X, Y int) {
-	return e.cursorX, e.cursorY
+	return e.mouse.GetMousePosition()
 }
 
 func (e *Engine) GetLanguageCode() string {
@@ -48,138 +84,217 @@ func (e *Engine) GetLanguageFontCode() string {
 	return "latin"
 }
 
-// New creates a new instance of the engine
-func New(config Configuration) *Engine {
-	rl.SetConfigFlags(rl.FlagWindowResizable | rl.FlagVsyncHint)
-	rl.InitWindow(800, 600, "Abyss Engine")
-	rl.SetTargetFPS(60)
-	rl.HideCursor()
-
+// New creates a new instance of the engine that draws with the specified renderer
+func New(config Configuration, renderProvider renderer.Renderer) *Engine {
 	result := &Engine{
-		shutdown:      false,
-		config:        config,
-		engineMode:    EngineModeBoot,
-		renderSurface: rl.LoadRenderTexture(800, 600),
-		systemFont:    rl.LoadFontFromMemory(".ttf", media.FontDiabloHeavy, int32(len(media.FontDiabloHeavy)), 18, nil, 0),
-		rootNode:      node.New(),
+		shutdown:     false,
+		config:       config,
+		renderer:     renderProvider,
+		atlas:        atlas.New(renderProvider, atlas.DefaultPageSize),
+		engineMode:   EngineModeBoot,
+		rootNode:     node.New(),
+		reloadPaths:  make(map[string]bool),
+		palettePaths: make(map[string]string),
+		debugOverlay: config.DebugOverlay,
 	}
 
-	result.loader = loader.New(result)
-	result.loader.AddProvider(filesystemloader.New(config.RootPath))
+	result.tweens = tween.NewManager(result.runLuaCallback)
+	result.scenes = scene.NewManager(result.rootNode, result.tweens, result.runLuaCallback, func() int {
+		width, _ := result.renderer.SurfaceSize()
+		return width
+	})
+
+	renderProvider.SetBatching(!config.DisableBatching)
+	result.applyDisplaySettings()
+	result.initInput()
+	result.initLoader()
 
-	logo := rl.LoadImageFromMemory(".png", media.BootLogo, int32(len(media.BootLogo)))
-	result.bootLogo = rl.LoadTextureFromImage(logo)
-	rl.UnloadImage(logo)
+	bootLogo, err := renderProvider.LoadTexture(".png", media.BootLogo)
 
-	rl.GenTextureMipmaps(&result.systemFont.Texture)
-	rl.SetTextureFilter(result.systemFont.Texture, rl.FilterAnisotropic16x)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load the boot logo")
+	}
+
+	result.bootLogo = bootLogo
 
-	common.PaletteShader = rl.LoadShaderFromMemory(media.StandardVertexShader, media.PaletteFragmentShader)
-	common.PaletteShaderLoc = rl.GetShaderLocation(common.PaletteShader, "palette")
-	common.PaletteShaderOffsetLoc = rl.GetShaderLocation(common.PaletteShader, "paletteOffset")
 	return result
 }
 
+// initLoader creates the loader and resource cache, and mounts the root path, the configured zip files and the mods.
+func (e *Engine) initLoader() {
+	e.closeLoader()
+
+	e.loader = loader.New(e)
+	e.resources = resourcecache.New(e.loader, e.config.ResourceCacheBudget)
+	e.loader.AddProvider(filesystemloader.New(e.config.RootPath))
+
+	for _, zipPath := range e.config.ZipLoadOrder {
+		if !path.IsAbs(zipPath) {
+			zipPath = path.Join(e.config.RootPath, zipPath)
+		}
+
+		provider, err := ziploader.New(zipPath)
+
+		if err != nil {
+			log.Error().Err(err).Msgf("failed to mount %s", zipPath)
+			continue
+		}
+
+		e.loader.AddProvider(provider)
+	}
+
+	e.mountMods()
+}
+
 // Destroy finalizes the instance of the engine
 func (e *Engine) Destroy() {
-	rl.UnloadTexture(e.bootLogo)
-	rl.UnloadFont(e.systemFont)
+	if e.bootLogo != nil {
+		e.renderer.UnloadTexture(e.bootLogo)
+	}
+
+	e.atlas.Destroy()
+	e.closeLoader()
+}
+
+// closeLoader closes the archives mounted by the loader, if there is one.
+func (e *Engine) closeLoader() {
+	if e.loader == nil {
+		return
+	}
+
+	if err := e.loader.Close(); err != nil {
+		log.Error().Err(err).Msg("failed to close the loader")
+	}
+
+	e.loader = nil
 }
 
 // Run runs the engine
 func (e *Engine) Run() {
 	e.bootstrapScripts()
 
-	for !rl.WindowShouldClose() {
+	if e.config.DevMode {
+		e.startHotReload()
+	}
+
+	for !e.renderer.ShouldClose() {
 		if e.shutdown {
 			break
 		}
 
-		rl.BeginTextureMode(e.renderSurface)
-		rl.ClearBackground(rl.Black)
+		e.runDispatched()
+		e.updateInput(e.renderer.FrameTime())
+
+		if e.engineMode != EngineModeError {
+			e.updateScripts(e.renderer.FrameTime())
+		}
+
+		e.renderer.BeginSurface()
 
 		switch e.engineMode {
 		case EngineModeBoot:
 			e.showBootSplash()
 		case EngineModeGame:
 			e.showGame()
+		case EngineModeError:
+			e.showErrorScreen()
 		}
 
-		rl.EndTextureMode()
+		e.renderer.EndSurface()
 		e.drawMainSurface()
 
-		if e.engineMode == EngineModeGame {
-			e.updateGame(float64(rl.GetFrameTime()))
+		switch e.engineMode {
+		case EngineModeGame:
+			e.updateGame(e.renderer.FrameTime())
+		case EngineModeError:
+			e.updateErrorScreen()
 		}
 	}
 
-	e.luaState.Close()
-	rl.CloseWindow()
+	e.stopHotReload()
+
+	if e.luaState != nil {
+		e.luaState.Close()
+	}
+
+	e.renderer.Close()
+}
+
+// runLuaCallback runs a Lua function as a new script thread, if the scripts are running.
+func (e *Engine) runLuaCallback(fn *lua.LFunction, args ...lua.LValue) {
+	if e.scheduler == nil {
+		return
+	}
+
+	e.scheduler.Spawn(fn, args...)
+}
+
+// updateScripts resumes any script threads that are due to run
+func (e *Engine) updateScripts(elapsed float64) {
+	if e.scheduler == nil {
+		return
+	}
+
+	if err := e.scheduler.Update(elapsed); err != nil {
+		e.panic(err)
+	}
 }
 
+// showGame draws the node tree one render layer at a time.
 func (e *Engine) showGame() {
-	e.rootNode.Render()
-	if e.cursorSprite != nil {
-		e.cursorSprite.Render()
+	for _, layer := range node.RenderLayers {
+		e.rootNode.RenderLayer(layer)
 	}
 }
 
+// updateGame updates the node tree, and then the view of the active camera, so that it follows where its target is
+// drawn this frame.
 func (e *Engine) updateGame(elapsed float64) {
-	e.rootNode.Update(elapsed)
+	if e.cursorSprite != nil && e.cursorSprite.ShouldRemove {
+		e.cursorSprite = nil
+	}
+
 	if e.cursorSprite != nil {
-		scale := float32(math.Min(float64(rl.GetScreenWidth())/800.0, float64(rl.GetScreenHeight())/600.0))
-		xOrigin := (float32(rl.GetScreenWidth()) - (800.0 * scale)) * 0.5
-		yOrigin := (float32(rl.GetScreenHeight()) - (600.0 * scale)) * 0.5
+		mouseX, mouseY := e.mouse.GetMousePosition()
+		e.cursorSprite.X, e.cursorSprite.Y = float64(mouseX), float64(mouseY)
+	}
 
-		e.cursorX = int((float32(rl.GetMouseX()) - xOrigin) * (1.0 / scale))
-		e.cursorSprite.X = e.cursorX
+	e.tweens.Update(elapsed, e.rootNode)
+	e.rootNode.Update(elapsed)
 
-		e.cursorY = int((float32(rl.GetMouseY()) - yOrigin) * (1.0 / scale))
-		e.cursorSprite.Y = e.cursorY
+	if e.camera != nil && e.camera.ShouldRemove {
+		e.camera = nil
+	}
 
-		e.cursorSprite.Update(elapsed)
+	if e.camera != nil && e.camera.Active {
+		e.camera.UpdateView(elapsed)
 	}
 }
 
 func (e *Engine) showBootSplash() {
-	rl.DrawTexture(e.bootLogo, int32(rl.GetScreenWidth()/3)-(e.bootLogo.Width/2),
-		int32(rl.GetScreenHeight()/2)-(e.bootLogo.Height/2), rl.White)
+	screenWidth, screenHeight := e.renderer.SurfaceSize()
+
+	if e.bootLogo != nil {
+		e.renderer.DrawTexture(e.bootLogo, (screenWidth/3)-(e.bootLogo.Width()/2),
+			(screenHeight/2)-(e.bootLogo.Height()/2))
+	}
 
-	textX := float32(rl.GetScreenWidth()) / 2
-	textY := float32(rl.GetScreenHeight()/2) - 20
+	textX := screenWidth / 2
+	textY := (screenHeight / 2) - 20
 
-	rl.DrawTextEx(e.systemFont, "Abyss Engine", rl.Vector2{X: textX, Y: textY}, 18, 0, rl.White)
-	rl.DrawTextEx(e.systemFont, "Local Build", rl.Vector2{X: textX, Y: textY + 16}, 18, 0, rl.Gray)
-	rl.DrawTextEx(e.systemFont, e.bootLoadText,
-		rl.Vector2{X: float32(rl.GetScreenWidth() / 4), Y: float32(rl.GetScreenWidth()/4) * 2.5}, 18, 0, rl.Beige)
+	e.renderer.DrawText("Abyss Engine", textX, textY, colorWhite)
+	e.renderer.DrawText("Local Build", textX, textY+16, colorGray)
+	e.renderer.DrawText(e.bootLoadText, screenWidth/4, int(float32(screenWidth/4)*2.5), colorBeige)
 }
 
 func (e *Engine) drawMainSurface() {
-	rl.BeginDrawing()
-	rl.ClearBackground(rl.Black)
-	scale := float32(math.Min(float64(rl.GetScreenWidth())/800.0, float64(rl.GetScreenHeight())/600.0))
-
-	rl.DrawTexturePro(e.renderSurface.Texture,
-		rl.Rectangle{Width: float32(e.renderSurface.Texture.Width), Height: float32(-e.renderSurface.Texture.Height)},
-		rl.Rectangle{
-			X:      (float32(rl.GetScreenWidth()) - (800.0 * scale)) * 0.5,
-			Y:      (float32(rl.GetScreenHeight()) - (600.0 * scale)) * 0.5,
-			Width:  800.0 * scale,
-			Height: 600.0 * scale},
-		rl.Vector2{}, 0.0, rl.White)
-
-	var memStats runtime.MemStats
-	runtime.ReadMemStats(&memStats)
-
-	rl.DrawTextEx(e.systemFont, fmt.Sprintf("FPS: %d", int(rl.GetFPS())), rl.Vector2{X: 5, Y: 5}, 18, 0, rl.White)
-	rl.DrawTextEx(e.systemFont, fmt.Sprintf("GC: %d (%%%d)", int(memStats.NumGC), int(memStats.GCCPUFraction*100)), rl.Vector2{X: 5, Y: 21}, 18, 0, rl.White)
-	rl.DrawTextEx(e.systemFont, fmt.Sprintf("Alloc: %0.2fMB (%0.2fMB)", float32(memStats.Alloc)/1024/1024, float32(memStats.Sys)/1024/1024), rl.Vector2{X: 5, Y: 37}, 18, 0, rl.White)
-
-	rl.EndDrawing()
-}
+	e.renderer.BeginScreen()
+
+	e.renderer.DrawSurface(e.surfaceRect())
+
+	if e.debugOverlay {
+		e.drawDebugOverlay()
+	}
 
-func (e *Engine) panic(msg string) {
-	// TODO: This should be a UI screen
-	log.Fatal().Msg(msg)
-	rl.CloseWindow()
+	e.renderer.EndScreen()
 }
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer/headlessrenderer"
+)
+
+// runScript runs a bootstrap script on the headless renderer for at most a number of frames, in dev mode so that the
+// hot reload watcher runs alongside the main loop.
+func runScript(t *testing.T, script string, frames int) *Engine {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "bootstrap.lua"), []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfiguration(dir)
+	config.DevMode = true
+
+	result := New(config, headlessrenderer.New(config.WindowWidth, config.WindowHeight, frames))
+	result.Run()
+	result.Destroy()
+
+	return result
+}
+
+func TestScriptedScene(t *testing.T) {
+	e := runScript(t, `
+local abyss = require("abyss")
+local scene = abyss.createScene("main")
+abyss.pushScene(scene)
+
+local camera = abyss.createCamera()
+camera:node():name("camera")
+scene:node():appendChild(camera:node())
+abyss.exitBootMode()
+
+local frames = 0
+local counter = abyss.spawn(function()
+	while true do
+		frames = frames + 1
+		abyss.waitFrames(1)
+	end
+end)
+
+abyss.tween(camera:node(), 100, {x = 40}):play()
+abyss.wait(250)
+counter:cancel()
+
+local x = camera:node():position()
+if x ~= 40 then error("the camera is at " .. x) end
+if frames < 10 then error("the counter ran " .. frames .. " times") end
+
+abyss.waitFrames(1)
+abyss.shutdown()
+`, 600)
+
+	if e.lastError != nil {
+		t.Fatalf("script error at %s: %s\n%s", e.lastError.Location, e.lastError.Message,
+			strings.Join(e.lastError.Traceback, "\n"))
+	}
+
+	if !e.shutdown {
+		t.Fatal("the script did not finish")
+	}
+
+	if e.rootNode.Find("main/camera") == nil {
+		t.Error("the camera is not in the scene")
+	}
+}
+
+func TestScriptErrorShowsErrorScreen(t *testing.T) {
+	e := runScript(t, `
+local abyss = require("abyss")
+abyss.exitBootMode()
+abyss.waitFrames(1)
+error("boom")
+`, 300)
+
+	if e.engineMode != EngineModeError || e.lastError == nil {
+		t.Fatal("the error screen is not shown")
+	}
+
+	if !strings.Contains(e.lastError.Message, "boom") || e.lastError.Location != "/bootstrap.lua:5" {
+		t.Errorf("error is %q at %s", e.lastError.Message, e.lastError.Location)
+	}
+}
@@ -0,0 +1,222 @@
+package engine
+
+import (
+	"errors"
+	"image/color"
+	"strings"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/node"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/AbyssEngine/scheduler"
+	"github.com/rs/zerolog/log"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	// errorScreenTop leaves room for the stats drawn in the top left corner of the screen
+	errorScreenTop        = 80
+	errorScreenLeft       = 20
+	errorScreenLineHeight = 16
+	// errorScreenLineLength is the number of characters after which long lines are wrapped
+	errorScreenLineLength = 84
+)
+
+// engineError is the error shown on the error screen.
+type engineError struct {
+	Message   string
+	Location  string
+	Traceback []string
+}
+
+func newEngineError(err error) *engineError {
+	var scriptErr *scheduler.ScriptError
+
+	if errors.As(err, &scriptErr) {
+		return &engineError{
+			Message:   scriptErr.Message,
+			Location:  scriptErr.Location,
+			Traceback: scriptErr.Traceback,
+		}
+	}
+
+	var apiErr *lua.ApiError
+
+	if !errors.As(err, &apiErr) {
+		return &engineError{Message: err.Error()}
+	}
+
+	result := &engineError{
+		Message:   apiErr.Object.String(),
+		Traceback: make([]string, 0),
+	}
+
+	for _, line := range strings.Split(apiErr.StackTrace, "\n") {
+		line = strings.TrimSpace(line)
+
+		if len(line) == 0 || line == "stack traceback:" {
+			continue
+		}
+
+		if len(result.Location) == 0 && !strings.HasPrefix(line, "[G]") {
+			result.Location = strings.SplitN(line, ": in ", 2)[0]
+		}
+
+		result.Traceback = append(result.Traceback, line)
+	}
+
+	return result
+}
+
+// String returns the error as plain text, in the form it is copied to the clipboard.
+func (e *engineError) String() string {
+	result := &strings.Builder{}
+
+	result.WriteString(e.Message)
+
+	if len(e.Location) > 0 {
+		result.WriteString("\nat " + e.Location)
+	}
+
+	if len(e.Traceback) > 0 {
+		result.WriteString("\n\nstack traceback:\n\t")
+		result.WriteString(strings.Join(e.Traceback, "\n\t"))
+	}
+
+	return result.String()
+}
+
+// panic stops the scripts and shows the error screen.
+func (e *Engine) panic(err error) {
+	log.Error().Msg(err.Error())
+
+	e.lastError = newEngineError(err)
+	e.engineMode = EngineModeError
+}
+
+func (e *Engine) showErrorScreen() {
+	if e.lastError == nil {
+		return
+	}
+
+	_, surfaceHeight := e.renderer.SurfaceSize()
+	footerY := surfaceHeight - (errorScreenLineHeight * 3)
+	y := errorScreenTop
+
+	drawLines := func(lines []string, tint color.Color) {
+		for _, line := range lines {
+			if y >= footerY-errorScreenLineHeight {
+				return
+			}
+
+			e.renderer.DrawText(line, errorScreenLeft, y, tint)
+			y += errorScreenLineHeight
+		}
+	}
+
+	drawLines([]string{"Script Error"}, colorRed)
+	y += errorScreenLineHeight / 2
+
+	if len(e.lastError.Location) > 0 {
+		drawLines([]string{"at " + e.lastError.Location}, colorBeige)
+	}
+
+	y += errorScreenLineHeight / 2
+	drawLines(wrapText(e.lastError.Message, errorScreenLineLength), colorWhite)
+
+	if len(e.lastError.Traceback) > 0 {
+		y += errorScreenLineHeight
+		drawLines([]string{"Traceback:"}, colorGray)
+
+		for _, line := range e.lastError.Traceback {
+			drawLines(wrapText("  "+line, errorScreenLineLength), colorGray)
+		}
+	}
+
+	e.renderer.DrawText("[C] Copy to clipboard    [R] Retry    [Q] Quit", errorScreenLeft, footerY, colorBeige)
+}
+
+func (e *Engine) updateErrorScreen() {
+	switch {
+	case e.renderer.IsKeyPressed(renderer.KeyC):
+		if e.lastError != nil {
+			e.renderer.SetClipboardText(e.lastError.String())
+			log.Info().Msg("copied the error to the clipboard")
+		}
+	case e.renderer.IsKeyPressed(renderer.KeyR):
+		e.retry()
+	case e.renderer.IsKeyPressed(renderer.KeyQ), e.renderer.IsKeyPressed(renderer.KeyEscape):
+		log.Info().Msg("engine shutting down from the error screen")
+		e.shutdown = true
+	}
+}
+
+// retry throws away the script state, everything the scripts created and the providers they added, and runs the
+// bootstrap script again.
+func (e *Engine) retry() {
+	log.Info().Msg("restarting the scripts")
+
+	e.stopHotReload()
+	e.resetScripts()
+	e.bootstrapScripts()
+
+	if e.engineMode != EngineModeError && e.config.DevMode {
+		e.startHotReload()
+	}
+}
+
+func (e *Engine) resetScripts() {
+	e.tweens.CancelAll()
+	e.rootNode.DestroyTree()
+	e.rootNode = node.New()
+	e.scenes.Reset(e.rootNode)
+	e.camera = nil
+	e.cursorSprite = nil
+
+	for name, tex := range common.PaletteTexture {
+		if tex.Init {
+			e.renderer.UnloadTexture(tex.Texture)
+		}
+
+		delete(common.PaletteTexture, name)
+	}
+
+	e.palettePaths = make(map[string]string)
+	e.reloadFunc = nil
+	e.reloadHandlers = nil
+	e.resetInputHandlers()
+
+	if e.luaState != nil {
+		e.luaState.Close()
+		e.luaState = nil
+	}
+
+	e.scheduler = nil
+	e.initLoader()
+
+	e.bootLoadText = ""
+	e.lastError = nil
+	e.engineMode = EngineModeBoot
+}
+
+// wrapText splits text into lines of at most lineLength characters, breaking at spaces where possible.
+func wrapText(text string, lineLength int) []string {
+	result := make([]string, 0)
+
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\t", "  "), "\n") {
+		for len(paragraph) > lineLength {
+			split := strings.LastIndex(paragraph[:lineLength], " ")
+
+			if split <= 0 {
+				split = lineLength
+			}
+
+			result = append(result, paragraph[:split])
+			paragraph = strings.TrimLeft(paragraph[split:], " ")
+		}
+
+		result = append(result, paragraph)
+	}
+
+	return result
+}
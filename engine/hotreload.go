@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/loader"
+	"github.com/OpenDiablo2/AbyssEngine/media"
+	"github.com/OpenDiablo2/AbyssEngine/watcher"
+	"github.com/rs/zerolog/log"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// hotReloadInterval is how often the root path is checked for changed files in dev mode
+const hotReloadInterval = 500 * time.Millisecond
+
+// dispatch queues a function to run on the main loop at the start of the next frame. It is safe to call from any
+// goroutine, and never blocks.
+func (e *Engine) dispatch(fn func()) {
+	e.dispatchMutex.Lock()
+	defer e.dispatchMutex.Unlock()
+
+	e.dispatched = append(e.dispatched, fn)
+}
+
+// runDispatched runs every function queued with dispatch.
+func (e *Engine) runDispatched() {
+	e.dispatchMutex.Lock()
+	queued := e.dispatched
+	e.dispatched = nil
+	e.dispatchMutex.Unlock()
+
+	for _, fn := range queued {
+		fn()
+	}
+}
+
+// queueReload adds changed files to those reloaded at the start of the next frame. It is safe to call from any
+// goroutine. A file that changes several times before then is reloaded once.
+func (e *Engine) queueReload(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	e.dispatchMutex.Lock()
+	defer e.dispatchMutex.Unlock()
+
+	if len(e.reloadPaths) == 0 {
+		e.dispatched = append(e.dispatched, e.runQueuedReload)
+	}
+
+	for _, path := range paths {
+		e.reloadPaths[path] = true
+	}
+}
+
+// runQueuedReload reloads the files queued with queueReload.
+func (e *Engine) runQueuedReload() {
+	e.dispatchMutex.Lock()
+	paths := make([]string, 0, len(e.reloadPaths))
+
+	for path := range e.reloadPaths {
+		paths = append(paths, path)
+		delete(e.reloadPaths, path)
+	}
+
+	e.dispatchMutex.Unlock()
+
+	sort.Strings(paths)
+	e.reloadFiles(paths)
+}
+
+func (e *Engine) startHotReload() {
+	reloadFunc, err := e.luaState.LoadString(media.ReloadScript)
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load the reload script")
+		return
+	}
+
+	e.luaState.Push(reloadFunc)
+
+	if err := e.luaState.PCall(0, 1, nil); err != nil {
+		log.Error().Err(err).Msg("failed to load the reload script")
+		return
+	}
+
+	fn, ok := e.luaState.Get(-1).(*lua.LFunction)
+	e.luaState.Pop(1)
+
+	if !ok {
+		log.Error().Msg("the reload script did not return a function")
+		return
+	}
+
+	e.reloadFunc = fn
+
+	e.watcher = watcher.New(e.config.RootPath, hotReloadInterval, e.queueReload)
+
+	e.watcher.Start()
+
+	log.Info().Msgf("watching %s for changes", e.config.RootPath)
+}
+
+func (e *Engine) stopHotReload() {
+	if e.watcher == nil {
+		return
+	}
+
+	e.watcher.Stop()
+	e.watcher = nil
+}
+
+// reloadFiles drops the cached copies of the changed files, reloads the palettes, sprites and labels that use them,
+// and re-runs the Lua modules that were loaded from them.
+// If the engine is showing the error screen, the scripts are restarted instead.
+func (e *Engine) reloadFiles(paths []string) {
+	if e.engineMode == EngineModeError {
+		log.Info().Msgf("%s changed", strings.Join(paths, ", "))
+		e.retry()
+
+		return
+	}
+
+	for _, path := range paths {
+		log.Info().Msgf("reloading %s", path)
+
+		e.resources.Invalidate(path)
+
+		for name, palettePath := range e.palettePaths {
+			if strings.EqualFold(loader.NormalizePath(palettePath), path) {
+				e.reloadPalette(name, palettePath)
+			}
+		}
+
+		e.rootNode.Reload(path)
+
+		moduleName, isModule := e.luaModuleName(path)
+
+		if !isModule && len(e.reloadHandlers) == 0 {
+			continue
+		}
+
+		handlers := e.luaState.NewTable()
+
+		for _, handler := range e.reloadHandlers {
+			handlers.Append(handler)
+		}
+
+		var module lua.LValue = lua.LNil
+
+		if isModule {
+			module = lua.LString(moduleName)
+		}
+
+		e.scheduler.Spawn(e.reloadFunc, module, lua.LString(path), handlers)
+	}
+}
+
+func (e *Engine) reloadPalette(name, path string) {
+	if tex, ok := common.PaletteTexture[name]; ok && tex.Init {
+		e.renderer.UnloadTexture(tex.Texture)
+	}
+
+	if err := e.loadPalette(name, path); err != nil {
+		log.Error().Err(err).Msgf("failed to reload palette %s", name)
+	}
+}
+
+// luaModuleName returns the name that the module loaded from the given path was required with.
+func (e *Engine) luaModuleName(path string) (string, bool) {
+	if !strings.HasSuffix(strings.ToLower(path), ".lua") {
+		return "", false
+	}
+
+	loaded, ok := e.luaState.GetField(e.luaState.GetGlobal("package"), "loaded").(*lua.LTable)
+
+	if !ok {
+		return "", false
+	}
+
+	result := ""
+
+	loaded.ForEach(func(key, _ lua.LValue) {
+		name, ok := key.(lua.LString)
+
+		if ok && strings.EqualFold(loader.NormalizePath(string(name))+".lua", path) {
+			result = string(name)
+		}
+	})
+
+	return result, len(result) > 0
+}
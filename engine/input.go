@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/OpenDiablo2/AbyssEngine/input"
+	"github.com/OpenDiablo2/AbyssEngine/node"
+	"github.com/rs/zerolog/log"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	keyBindingsFileName = "keybindings.json"
+	userConfigDirName   = "AbyssEngine"
+)
+
+// initInput creates the input subsystem, and loads the player's key bindings.
+func (e *Engine) initInput() {
+	e.input = input.New(e.renderer)
+	e.mouse = input.NewMouse(e.renderer)
+	e.mouseDispatch = node.NewMouseDispatcher(e.mouse)
+	e.actions = input.NewActionMap()
+	e.actionHandlers = make(map[string][]*lua.LFunction)
+
+	if len(e.config.KeyBindingsPath) == 0 {
+		e.config.KeyBindingsPath = defaultKeyBindingsPath(e.config.RootPath)
+	}
+
+	if err := e.actions.Load(e.config.KeyBindingsPath); err != nil {
+		log.Error().Err(err).Msg("failed to load the key bindings")
+	}
+}
+
+// defaultKeyBindingsPath returns the path of the key bindings file in the user's configuration directory, or in the
+// root path if the user has none.
+func defaultKeyBindingsPath(rootPath string) string {
+	configDir, err := os.UserConfigDir()
+
+	if err != nil {
+		return filepath.Join(rootPath, keyBindingsFileName)
+	}
+
+	return filepath.Join(configDir, userConfigDirName, keyBindingsFileName)
+}
+
+func (e *Engine) saveKeyBindings() {
+	if err := e.actions.Save(e.config.KeyBindingsPath); err != nil {
+		log.Error().Err(err).Msg("failed to save the key bindings")
+	}
+}
+
+// updateInput polls the keyboard and mouse, sends the mouse events through the node tree, and runs the key, action,
+// text input and mouse handlers of the scripts for each event. The mouse handlers only get the events that no node
+// stopped, so that clicks on the interface do not reach the game behind it.
+func (e *Engine) updateInput(elapsed float64) {
+	e.input.Update(elapsed)
+
+	// nodes hit test against the mouse position, so it is mapped onto the surface in every scale mode
+	mouseX, mouseY := e.screenToSurface(e.renderer.MousePosition())
+	e.mouse.Update(elapsed, mouseX, mouseY, e.input.Modifiers())
+	e.updateDebugOverlay()
+
+	if e.scheduler == nil || e.engineMode == EngineModeError {
+		return
+	}
+
+	mouseEvents := e.mouse.Events()
+
+	if e.engineMode == EngineModeGame {
+		mouseEvents = e.mouseDispatch.Dispatch(e.rootNode)
+	}
+
+	for _, event := range e.input.Events() {
+		for _, handler := range e.keyHandlers {
+			e.scheduler.Spawn(handler, lua.LString(event.Key.ToString()), lua.LString(event.Type.ToString()),
+				event.Modifiers.ToLua(e.luaState))
+		}
+
+		for _, action := range e.actions.Match(event) {
+			for _, handler := range e.actionHandlers[action] {
+				e.scheduler.Spawn(handler, lua.LString(event.Type.ToString()))
+			}
+		}
+	}
+
+	if text := e.input.Text(); len(text) > 0 {
+		for _, handler := range e.textHandlers {
+			e.scheduler.Spawn(handler, lua.LString(text))
+		}
+	}
+
+	for _, event := range mouseEvents {
+		for _, handler := range e.mouseHandlers {
+			e.scheduler.Spawn(handler, e.mouseEventToLua(event))
+		}
+	}
+}
+
+// mouseEventToLua returns the Lua table of a mouse event. With an active camera, it has the point of the world under
+// the mouse in its worldX and worldY fields.
+func (e *Engine) mouseEventToLua(event input.MouseEvent) *lua.LTable {
+	result := event.ToLua(e.luaState)
+
+	if e.camera != nil {
+		worldX, worldY := e.camera.SurfaceToWorld(float64(event.X), float64(event.Y))
+		result.RawSetString("worldX", lua.LNumber(worldX))
+		result.RawSetString("worldY", lua.LNumber(worldY))
+	}
+
+	return result
+}
+
+func (e *Engine) resetInputHandlers() {
+	e.keyHandlers = nil
+	e.textHandlers = nil
+	e.mouseHandlers = nil
+	e.mouseDispatch.Reset()
+	e.actionHandlers = make(map[string][]*lua.LFunction)
+}
+
+// luaToBindings converts the string arguments of a Lua function, starting at the given index, to bindings.
+func luaToBindings(l *lua.LState, start int) ([]input.Binding, bool) {
+	result := make([]input.Binding, 0, l.GetTop()-start+1)
+
+	for idx := start; idx <= l.GetTop(); idx++ {
+		binding, err := input.StringToBinding(l.CheckString(idx))
+
+		if err != nil {
+			l.ArgError(idx, err.Error())
+			return nil, false
+		}
+
+		result = append(result, binding)
+	}
+
+	return result, true
+}
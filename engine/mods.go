@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/OpenDiablo2/AbyssEngine/loader"
+	"github.com/OpenDiablo2/AbyssEngine/loader/filesystemloader"
+	"github.com/OpenDiablo2/AbyssEngine/loader/ziploader"
+	"github.com/rs/zerolog/log"
+)
+
+const defaultModPath = "mods"
+
+// mountMods mounts every mod found in the mod directory and logs the files that more than one source supplies.
+func (e *Engine) mountMods() {
+	modPath := e.config.ModPath
+
+	if len(modPath) == 0 {
+		modPath = defaultModPath
+	}
+
+	if !path.IsAbs(modPath) {
+		modPath = path.Join(e.config.RootPath, modPath)
+	}
+
+	entries, err := ioutil.ReadDir(modPath)
+
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Error().Err(err).Msgf("failed to read mod directory %s", modPath)
+		}
+
+		return
+	}
+
+	mods := make([]*loader.Mod, 0)
+
+	for _, entry := range entries {
+		mod, err := openMod(path.Join(modPath, entry.Name()), entry)
+
+		if err != nil {
+			log.Error().Err(err).Msgf("failed to open mod %s", entry.Name())
+			continue
+		}
+
+		if mod != nil {
+			mods = append(mods, mod)
+		}
+	}
+
+	for _, err := range e.loader.MountMods(mods) {
+		log.Error().Err(err).Msg("failed to mount mod")
+	}
+
+	for _, mod := range e.loader.Mods() {
+		log.Info().Msgf("mounted mod %s", mod.String())
+	}
+
+	conflicts, err := e.loader.Conflicts()
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to check for mod conflicts")
+		return
+	}
+
+	if len(conflicts) > 0 {
+		log.Warn().Msgf("%d files are supplied by more than one source", len(conflicts))
+	}
+
+	for _, conflict := range conflicts {
+		log.Debug().Msgf("%s: %s", conflict.Path, strings.Join(conflict.Sources, ", "))
+	}
+}
+
+// openMod opens a mod directory or zip file, or returns nil if the entry is not a mod.
+func openMod(modPath string, entry os.FileInfo) (*loader.Mod, error) {
+	var provider loader.LoaderProvider
+	name := entry.Name()
+
+	if entry.IsDir() {
+		provider = filesystemloader.New(modPath)
+	} else {
+		ext := strings.ToLower(path.Ext(name))
+
+		if ext != ".zip" && ext != ".pk3" {
+			return nil, nil
+		}
+
+		zipProvider, err := ziploader.New(modPath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		provider = zipProvider
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+
+	manifest, err := loader.ReadManifest(provider, name)
+
+	if err != nil {
+		if closer, ok := provider.(io.Closer); ok {
+			_ = closer.Close()
+		}
+
+		return nil, err
+	}
+
+	result := &loader.Mod{
+		Manifest: *manifest,
+		Path:     modPath,
+		Provider: provider,
+	}
+
+	return result, nil
+}
@@ -2,7 +2,6 @@ package engine
 
 import (
 	"image/color"
-	"io/ioutil"
 
 	"github.com/OpenDiablo2/AbyssEngine/common"
 	pl2 "github.com/OpenDiablo2/pl2/pkg"
@@ -13,23 +12,15 @@ func (e *Engine) loadPalette(name string, path string) error {
 		common.PaletteTexture = make(map[string]*common.PalTex)
 	}
 
-	paletteStream, err := e.loader.Load(path)
+	paletteResource, err := e.resources.Acquire(path, common.ResourceTypePalette)
 
 	if err != nil {
 		return err
 	}
 
-	paletteBytes, err := ioutil.ReadAll(paletteStream)
+	defer paletteResource.Release()
 
-	if err != nil {
-		return err
-	}
-
-	pal, err := pl2.FromBytes(paletteBytes)
-
-	if err != nil {
-		return err
-	}
+	pal := paletteResource.Value().(*pl2.PL2)
 
 	colors := make([]uint8, 0)
 	colors = append(colors, palToSlice(pal.BasePalette)...)
@@ -74,6 +65,7 @@ func (e *Engine) loadPalette(name string, path string) error {
 	tex.Init = false
 
 	common.PaletteTexture[name] = tex
+	e.palettePaths[name] = path
 
 	return nil
 }
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+)
+
+// ScaleMode decides how the virtual render surface is fitted to the window.
+type ScaleMode int
+
+const (
+	// ScaleModeLetterbox scales the surface as large as it fits while keeping its aspect ratio, with black bars
+	ScaleModeLetterbox ScaleMode = iota
+	// ScaleModeInteger scales the surface by the largest whole number that fits, so every pixel is the same size
+	ScaleModeInteger
+	// ScaleModeStretch scales the surface to fill the whole window, ignoring its aspect ratio
+	ScaleModeStretch
+)
+
+func (s ScaleMode) ToString() string {
+	switch s {
+	case ScaleModeLetterbox:
+		return "letterbox"
+	case ScaleModeInteger:
+		return "integer"
+	case ScaleModeStretch:
+		return "stretch"
+	}
+
+	return "letterbox"
+}
+
+func StringToScaleMode(s string) (ScaleMode, error) {
+	switch strings.ToLower(s) {
+	case "letterbox":
+		return ScaleModeLetterbox, nil
+	case "integer":
+		return ScaleModeInteger, nil
+	case "stretch":
+		return ScaleModeStretch, nil
+	}
+
+	return ScaleModeLetterbox, errors.New("unknown scale mode")
+}
@@ -1,32 +1,50 @@
 package engine
 
 import (
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"path"
 	"reflect"
 	"strings"
-	"time"
 
 	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/loader/cascloader"
 	"github.com/OpenDiablo2/AbyssEngine/loader/filesystemloader"
 	"github.com/OpenDiablo2/AbyssEngine/loader/mpqloader"
+	"github.com/OpenDiablo2/AbyssEngine/loader/ziploader"
+	"github.com/OpenDiablo2/AbyssEngine/media"
 	"github.com/OpenDiablo2/AbyssEngine/node"
 	"github.com/OpenDiablo2/AbyssEngine/node/button"
 	"github.com/OpenDiablo2/AbyssEngine/node/button/buttonlayout"
+	"github.com/OpenDiablo2/AbyssEngine/node/camera"
+	"github.com/OpenDiablo2/AbyssEngine/node/composite"
 	"github.com/OpenDiablo2/AbyssEngine/node/label"
+	"github.com/OpenDiablo2/AbyssEngine/node/maprenderer"
 	"github.com/OpenDiablo2/AbyssEngine/node/sprite"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/AbyssEngine/scene"
+	"github.com/OpenDiablo2/AbyssEngine/scheduler"
+	"github.com/OpenDiablo2/AbyssEngine/tween"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	lua "github.com/yuin/gopher-lua"
 )
 
+// bootDelay is how long (in seconds) the boot splash is shown before the bootstrap script starts
+const bootDelay = 1.0
+
 var luaTypes = []common.LuaTypeExport{
 	node.LuaTypeExport,
 	sprite.LuaTypeExport,
 	label.LuaTypeExport,
 	button.LuaTypeExport,
 	buttonlayout.LuaTypeExport,
+	scheduler.LuaTypeExport,
+	tween.LuaTypeExport,
+	scene.LuaTypeExport,
+	composite.LuaTypeExport,
+	maprenderer.LuaTypeExport,
+	camera.LuaTypeExport,
 }
 
 func registerType(l *lua.LState, luaTypeExport common.LuaTypeExport) {
@@ -42,109 +60,305 @@ func registerType(l *lua.LState, luaTypeExport common.LuaTypeExport) {
 	l.SetField(typeMetatable, "__index", l.SetFuncs(l.NewTable(), luaTypeExport.Methods))
 }
 
+// bootstrapScripts creates the Lua state and schedules the bootstrap script to run on the main loop. Scripts run as
+// scheduler threads that are resumed once per frame, so they never run concurrently with rendering or node updates.
 func (e *Engine) bootstrapScripts() {
-	go func() {
-		time.Sleep(time.Second * 1)
+	e.luaState = lua.NewState()
+	e.scheduler = scheduler.New(e.luaState)
+
+	for _, luaType := range luaTypes {
+		registerType(e.luaState, luaType)
+	}
 
-		e.luaState = lua.NewState()
+	// Inject searcher for loading files
+	lPackage := e.luaState.GetGlobal("package")
+	lSearchers := e.luaState.GetField(lPackage, "loaders").(*lua.LTable)
 
-		for _, luaType := range luaTypes {
-			registerType(e.luaState, luaType)
-		}
+	// TODO: Try to remove normal searchers or something for security
+	//for lSearchers.Len() > 0 {
+	//	lSearchers.Remove(0)
+	//}
+
+	lSearchers.Append(e.luaState.NewFunction(func(l *lua.LState) int { return e.luaLoader(l) }))
+
+	e.luaState.PreloadModule("abyss", func(l *lua.LState) int {
+		mod := l.SetFuncs(l.NewTable(), map[string]lua.LGFunction{
+			// shutdown()
+			// shuts down the engine (exits to desktop)
+			"shutdown": func(l *lua.LState) int { return e.luaShutdown(l) },
+
+			// log(level: string, message: string)
+			// writes a log entry message based on the log level: info, error, fatal, warn, debug, trace
+			"log": func(l *lua.LState) int { return e.luaLog(l) },
+
+			// fmt(format: string, values: any...)
+			// returns a formatted string from the input format and values
+			"fmt": func(l *lua.LState) int { return e.luaFmt(l) },
 
-		// Inject searcher for loading files
-		lPackage := e.luaState.GetGlobal("package")
-		lSearchers := e.luaState.GetField(lPackage, "loaders").(*lua.LTable)
+			// setBootText(text: string)
+			// sets the lower text in the boot splash screen
+			"setBootText": func(l *lua.LState) int { return e.luaSetBootText(l) },
 
-		// TODO: Try to remove normal searchers or something for security
-		//for lSearchers.Len() > 0 {
-		//	lSearchers.Remove(0)
-		//}
+			// joinPath(path: string...)
+			// Joins path names together in an os-specific way
+			"joinPath": func(l *lua.LState) int { return e.luaJoinPath(l) },
 
-		lSearchers.Append(e.luaState.NewFunction(func(l *lua.LState) int { return e.luaLoader(l) }))
+			// sleep(msec: int)
+			// deprecated, same as wait
+			"sleep": func(l *lua.LState) int { return e.luaWait(l) },
 
-		e.luaState.PreloadModule("abyss", func(l *lua.LState) int {
-			mod := l.SetFuncs(e.luaState.NewTable(), map[string]lua.LGFunction{
-				// shutdown()
-				// shuts down the engine (exits to desktop)
-				"shutdown": func(l *lua.LState) int { return e.luaShutdown(l) },
+			// wait(msec: int)
+			// suspends the calling script thread for the specified number of milliseconds
+			"wait": func(l *lua.LState) int { return e.luaWait(l) },
 
-				// log(level: string, message: string)
-				// writes a log entry message based on the log level: info, error, fatal, warn, debug, trace
-				"log": func(l *lua.LState) int { return e.luaLog(l) },
+			// waitFrames(frames: int)
+			// suspends the calling script thread for the specified number of frames
+			"waitFrames": func(l *lua.LState) int { return e.luaWaitFrames(l) },
 
-				// fmt(format: string, values: any...)
-				// returns a formatted string from the input format and values
-				"fmt": func(l *lua.LState) int { return e.luaFmt(l) },
+			// spawn(fn: function, args: any...) Task
+			// runs a function as a new script thread, starting on the next frame
+			"spawn": func(l *lua.LState) int { return e.luaSpawn(l) },
 
-				// setBootText(text: string)
-				// sets the lower text in the boot splash screen
-				"setBootText": func(l *lua.LState) int { return e.luaSetBootText(l) },
+			// getEngineSettings()
+			// returns the engine settings
+			"getEngineSettings": func(l *lua.LState) int { return e.luaGetEngineSettings(l) },
 
-				// joinPath(path: string...)
-				// Joins path names together in an os-specific way
-				"joinPath": func(l *lua.LState) int { return e.luaJoinPath(l) },
+			// addLoaderProvider(type: string, path: string)
+			// adds a loader to the engine
+			"addLoaderProvider": func(l *lua.LState) int { return e.luaAddLoaderProvider(l) },
 
-				// sleep(msec: int)
-				// sleeps the specified number of milliseconds
-				"sleep": func(l *lua.LState) int { return e.luaSleep(l) },
+			// exitBootMode()
+			// exits boot mode and starts the main rendering system
+			"exitBootMode": func(l *lua.LState) int { return e.luaExitBootMode(l) },
 
-				// getEngineSettings()
-				// returns the engine settings
-				"getEngineSettings": func(l *lua.LState) int { return e.luaGetEngineSettings(l) },
+			// loadString(path: string)
+			// loads a string from the loader
+			"loadString": func(l *lua.LState) int { return e.luaLoadString(l) },
 
-				// addLoaderProvider(type: string, path: string)
-				// adds a loader to the engine
-				"addLoaderProvider": func(l *lua.LState) int { return e.luaAddLoaderProvider(l) },
+			// listFiles(pattern: string) table
+			// returns the files matching a glob pattern, where a ** segment matches any number of directories, or
+			// starting with the pattern if it has no wildcards
+			"listFiles": func(l *lua.LState) int { return e.luaListFiles(l) },
 
-				// exitBootMode()
-				// exits boot mode and starts the main rendering system
-				"exitBootMode": func(l *lua.LState) int { return e.luaExitBootMode(l) },
+			// splitString(source: string, splitChars: string)
+			// splits a string by the specified split characters
+			"luaSplitString": func(l *lua.LState) int { return e.luaSplitString(l) },
 
-				// loadString(path: string)
-				// loads a string from the loader
-				"loadString": func(l *lua.LState) int { return e.luaLoadString(l) },
+			// getRootNode()
+			// returns the root node node for the engine
+			"getRootNode": func(l *lua.LState) int { return e.luaGetRootNode(l) },
 
-				// splitString(source: string, splitChars: string)
-				// splits a string by the specified split characters
-				"luaSplitString": func(l *lua.LState) int { return e.luaSplitString(l) },
+			// loadSprite(filePath: string, palette: string) Sprite
+			// returns a sprite based on the path and palette
+			"loadSprite": func(l *lua.LState) int { return e.luaLoadSprite(l) },
 
-				// getRootNode()
-				// returns the root node node for the engine
-				"getRootNode": func(l *lua.LState) int { return e.luaGetRootNode(l) },
+			// loadLabel(fontPath: string, palette: string) Label
+			// returns a label based on the path and palette
+			"loadLabel": func(l *lua.LState) int { return e.luaLoadLabel(l) },
 
-				// loadSprite(filePath: string, palette: string) Sprite
-				// returns a sprite based on the path and palette
-				"loadSprite": func(l *lua.LState) int { return e.luaLoadSprite(l) },
+			// setCursor(cursor: Sprite)
+			// sets the sprite that follows the mouse, in the cursor render layer, or clears it if nil
+			"setCursor": func(l *lua.LState) int { return e.luaSetCursor(l) },
 
-				// loadLabel(fontPath: string, palette: string) Label
-				// returns a label based on the path and palette
-				"loadLabel": func(l *lua.LState) int { return e.luaLoadLabel(l) },
+			// loadPalette(name: string, filePath: string)
+			// loads palette for use with sprites
+			"loadPalette": func(l *lua.LState) int { return e.luaLoadPalette(l) },
 
-				// setCursor(cursor: Sprite)
-				// sets the current cursor, or clears it if nil
-				"setCursor": func(l *lua.LState) int { return e.luaSetCursor(l) },
+			"loadButton": func(l *lua.LState) int { return e.luaLoadButton(l) },
 
-				// loadPalette(name: string, filePath: string)
-				// loads palette for use with sprites
-				"loadPalette": func(l *lua.LState) int { return e.luaLoadPalette(l) },
+			// loadComposite(directory: string, mode: string, weaponClass: string, palette: string) Composite
+			// returns a character, monster or object drawn from the COF and DCC layers in the directory
+			"loadComposite": func(l *lua.LState) int { return e.luaLoadComposite(l) },
 
-				"loadButton": func(l *lua.LState) int { return e.luaLoadButton(l) },
-			})
+			// loadMap(ds1Path: string, palette: string, dt1Paths: string...) MapRenderer
+			// returns a node drawing a DS1 map with its DT1 tilesets (the ones the DS1 lists if none are given)
+			"loadMap": func(l *lua.LState) int { return e.luaLoadMap(l) },
 
-			e.luaState.Push(mod)
+			// createCamera() Camera
+			// returns a camera node, whose children are in world coordinates and are drawn and picked through its view
+			"createCamera": func(l *lua.LState) int { return e.luaCreateCamera(l) },
 
-			return 1
+			// setCamera(camera: Camera)
+			// makes the camera the active one, which follows its target and shakes, and whose world coordinates are
+			// added to the mouse events as worldX and worldY; nil for none
+			"setCamera": func(l *lua.LState) int { return e.luaSetCamera(l) },
+
+			// getCamera() Camera
+			// returns the active camera, or nil if there is none
+			"getCamera": func(l *lua.LState) int { return e.luaGetCamera(l) },
+
+			// getMods() table
+			// returns the mounted mods in mount order, each with a name, version, priority and path
+			"getMods": func(l *lua.LState) int { return e.luaGetMods(l) },
+
+			// getConflicts() table
+			// returns every file supplied by more than one mod or loader, with the sources in lookup order
+			"getConflicts": func(l *lua.LState) int { return e.luaGetConflicts(l) },
+
+			// getFileSource(path: string) string
+			// returns the name of the mod or loader that supplies a file, or nil if no one does
+			"getFileSource": func(l *lua.LState) int { return e.luaGetFileSource(l) },
+
+			// onReload(handler: function)
+			// in dev mode, calls handler(path, module) after a file changes; module is the reloaded module, if any
+			"onReload": func(l *lua.LState) int { return e.luaOnReload(l) },
+
+			// getDisplaySettings() table
+			// returns the virtual resolution, window size, fullscreen state and scale mode
+			"getDisplaySettings": func(l *lua.LState) int { return e.luaGetDisplaySettings(l) },
+
+			// setVirtualResolution(width: int, height: int)
+			// sets the size of the surface the game is drawn on
+			"setVirtualResolution": func(l *lua.LState) int { return e.luaSetVirtualResolution(l) },
+
+			// setWindowSize(width: int, height: int)
+			// resizes the window
+			"setWindowSize": func(l *lua.LState) int { return e.luaSetWindowSize(l) },
+
+			// setFullscreen(fullscreen: bool)
+			// switches between fullscreen and windowed mode
+			"setFullscreen": func(l *lua.LState) int { return e.luaSetFullscreen(l) },
+
+			// setScaleMode(mode: string)
+			// sets how the game is fitted to the window: letterbox, integer or stretch
+			"setScaleMode": func(l *lua.LState) int { return e.luaSetScaleMode(l) },
+
+			// defineAction(action: string, keys: string...)
+			// sets the default keys of an action; keys may have modifiers, e.g. defineAction("save", "ctrl+s", "f5")
+			"defineAction": func(l *lua.LState) int { return e.luaDefineAction(l) },
+
+			// bindAction(action: string, keys: string...)
+			// replaces the keys of an action with the player's own, and saves them to the key bindings file
+			"bindAction": func(l *lua.LState) int { return e.luaBindAction(l) },
+
+			// resetAction(action: string)
+			// puts an action back to its default keys, and saves the key bindings file
+			"resetAction": func(l *lua.LState) int { return e.luaResetAction(l) },
+
+			// getActionBindings(action: string) table
+			// returns the keys that trigger an action
+			"getActionBindings": func(l *lua.LState) int { return e.luaGetActionBindings(l) },
+
+			// getActions() table
+			// returns the names of all defined or bound actions
+			"getActions": func(l *lua.LState) int { return e.luaGetActions(l) },
+
+			// onAction(action: string, handler: function)
+			// calls handler(state) when a key bound to the action changes; state is "down", "up" or "repeat"
+			"onAction": func(l *lua.LState) int { return e.luaOnAction(l) },
+
+			// onKey(handler: function)
+			// calls handler(key, state, modifiers) for every key event; modifiers has shift, ctrl, alt and super fields
+			"onKey": func(l *lua.LState) int { return e.luaOnKey(l) },
+
+			// onTextInput(handler: function)
+			// calls handler(text) with the text typed each frame
+			"onTextInput": func(l *lua.LState) int { return e.luaOnTextInput(l) },
+
+			// isKeyDown(key: string) bool
+			// returns true if the key is held down
+			"isKeyDown": func(l *lua.LState) int { return e.luaIsKeyDown(l) },
+
+			// isActionDown(action: string) bool
+			// returns true if any of the keys bound to the action are held down
+			"isActionDown": func(l *lua.LState) int { return e.luaIsActionDown(l) },
+
+			// onMouse(handler: function)
+			// calls handler(event) for every mouse event that no node handled; event has type, button, x, y, dx, dy,
+			// wheel and modifiers
+			"onMouse": func(l *lua.LState) int { return e.luaOnMouse(l) },
+
+			// getMousePosition() (x: int, y: int)
+			// returns the position of the mouse on the virtual surface
+			"getMousePosition": func(l *lua.LState) int { return e.luaGetMousePosition(l) },
+
+			// isMouseButtonDown(button: string) bool
+			// returns true if the mouse button (left, right or middle) is held down
+			"isMouseButtonDown": func(l *lua.LState) int { return e.luaIsMouseButtonDown(l) },
+
+			// setDragThreshold(pixels: int)
+			// sets how far the mouse moves with a button held before it counts as a drag
+			"setDragThreshold": func(l *lua.LState) int { return e.luaSetDragThreshold(l) },
+
+			// tween(target: Node, duration: int, values: table, easing: string) Tween
+			// creates a tween that moves values of a node to targets over duration milliseconds, e.g. {x = 10,
+			// opacity = 0}; values are x, y, scaleX, scaleY, scale, rotation, opacity and (for sprites) paletteShift.
+			// easing defaults to linear; start the tween with tween:play()
+			"tween": func(l *lua.LState) int { return e.luaTween(l) },
+
+			// sequence(animations: Tween...) Tween
+			// creates an animation that plays the animations one after another
+			"sequence": func(l *lua.LState) int { return e.luaSequence(l) },
+
+			// parallel(animations: Tween...) Tween
+			// creates an animation that plays the animations at the same time
+			"parallel": func(l *lua.LState) int { return e.luaParallel(l) },
+
+			// tweenDelay(duration: int) Tween
+			// creates an animation that does nothing for duration milliseconds, to leave a gap in a sequence
+			"tweenDelay": func(l *lua.LState) int { return e.luaTweenDelay(l) },
+
+			// createScene(name: string, callbacks: table) Scene
+			// creates a scene with a node to build it in; callbacks may hold onEnter, onExit, onPause and onResume
+			// functions, which are called with the scene, and overlay = true to keep the scene below it visible
+			"createScene": func(l *lua.LState) int { return e.luaCreateScene(l) },
+
+			// pushScene(scene: Scene, transition: string, duration: int)
+			// pauses the current scene and enters the new one above it; transition is none (the default), fade or
+			// slide, and duration is in milliseconds
+			"pushScene": func(l *lua.LState) int { return e.luaPushScene(l) },
+
+			// popScene(transition: string, duration: int)
+			// exits the current scene, destroying its nodes, and resumes the one below it
+			"popScene": func(l *lua.LState) int { return e.luaPopScene(l) },
+
+			// replaceScene(scene: Scene, transition: string, duration: int)
+			// exits the current scene, destroying its nodes, and enters the new one in its place
+			"replaceScene": func(l *lua.LState) int { return e.luaReplaceScene(l) },
+
+			// getCurrentScene() Scene
+			// returns the scene on top of the stack, or nil if there is none
+			"getCurrentScene": func(l *lua.LState) int { return e.luaGetCurrentScene(l) },
+
+			// getSceneStack() table
+			// returns the scenes on the stack, from the bottom to the top
+			"getSceneStack": func(l *lua.LState) int { return e.luaGetSceneStack(l) },
+
+			// setDebugOverlay(shown: bool)
+			// shows or hides the debug overlay with the engine stats and the node tree
+			"setDebugOverlay": func(l *lua.LState) int { return e.luaSetDebugOverlay(l) },
+
+			// setBatching(enabled: bool)
+			// sets whether sprites and glyphs are drawn in batches, to compare the draw calls on the debug overlay
+			"setBatching": func(l *lua.LState) int { return e.luaSetBatching(l) },
+
+			// getDebugInfo() string
+			// returns the engine stats and the node tree shown on the debug overlay as JSON, for bug reports
+			"getDebugInfo": func(l *lua.LState) int { return e.luaGetDebugInfo(l) },
+
+			// getResourceStats() table
+			// returns the resource cache statistics (hits, misses, evictions, entries, bytes, budget)
+			"getResourceStats": func(l *lua.LState) int { return e.luaGetResourceStats(l) },
 		})
 
-		err := e.luaState.DoString("require(\"/bootstrap\")")
+		l.Push(mod)
 
-		if err != nil {
-			e.panic(err.Error())
-			return
-		}
+		return 1
+	})
+
+	if err := e.luaState.DoString(media.RequireScript); err != nil {
+		e.panic(err)
+		return
+	}
+
+	bootstrap, err := e.luaState.LoadString("require(\"/bootstrap\")")
 
-	}()
+	if err != nil {
+		e.panic(err)
+		return
+	}
+
+	e.scheduler.Spawn(bootstrap).Delay(bootDelay)
 }
 
 func (e *Engine) luaLoader(l *lua.LState) int {
@@ -269,6 +483,8 @@ func getTableForObject(l *lua.LState, source interface{}) lua.LValue {
 		switch f.Interface().(type) {
 		case int:
 			result.RawSetString(fieldName, lua.LNumber(f.Int()))
+		case int64:
+			result.RawSetString(fieldName, lua.LNumber(f.Int()))
 		case bool:
 			result.RawSetString(fieldName, lua.LBool(f.Bool()))
 		case string:
@@ -300,16 +516,43 @@ func (e *Engine) luaGetEngineSettings(l *lua.LState) int {
 	return 1
 }
 
-func (e *Engine) luaSleep(l *lua.LState) int {
+func (e *Engine) luaWait(l *lua.LState) int {
 	if l.GetTop() != 1 {
 		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
 	}
 
 	val := l.CheckInt(1)
 
-	time.Sleep(time.Millisecond * time.Duration(val))
+	return scheduler.Wait(l, val)
+}
 
-	return 0
+func (e *Engine) luaWaitFrames(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	val := l.CheckInt(1)
+
+	return scheduler.WaitFrames(l, val)
+}
+
+func (e *Engine) luaSpawn(l *lua.LState) int {
+	if l.GetTop() < 1 {
+		l.ArgError(1, "expected at least one argument")
+		return 0
+	}
+
+	fn := l.CheckFunction(1)
+	args := make([]lua.LValue, 0, l.GetTop()-1)
+
+	for i := 2; i <= l.GetTop(); i++ {
+		args = append(args, l.Get(i))
+	}
+
+	l.Push(e.scheduler.Spawn(fn, args...).ToLua(l))
+	return 1
 }
 
 func (e *Engine) luaAddLoaderProvider(l *lua.LState) int {
@@ -332,12 +575,31 @@ func (e *Engine) luaAddLoaderProvider(l *lua.LState) int {
 		e.loader.AddProvider(provider)
 	case "filesystem":
 		provider := filesystemloader.New(p)
+		e.loader.AddProvider(provider)
+	case "casc":
+		provider, err := cascloader.New(p)
+		if err != nil {
+			l.RaiseError(err.Error())
+			return 0
+		}
+
+		e.loader.AddProvider(provider)
+	case "zip":
+		provider, err := ziploader.New(p)
+		if err != nil {
+			l.RaiseError(err.Error())
+			return 0
+		}
+
 		e.loader.AddProvider(provider)
 	default:
 		l.RaiseError("unknown loader type: %s", loaderType)
 		return 0
 	}
 
+	// the new provider may override files that are already cached
+	e.resources.Clear()
+
 	return 0
 }
 
@@ -349,20 +611,48 @@ func (e *Engine) luaLoadString(l *lua.LState) int {
 
 	val := l.CheckString(1)
 
-	file, err := e.loader.Load(val)
+	file, err := e.resources.Acquire(val, common.ResourceTypeBytes)
 
 	if err != nil {
 		l.RaiseError(err.Error())
 		return 0
 	}
 
-	result, err := ioutil.ReadAll(file)
+	defer file.Release()
+
+	l.Push(lua.LString(file.Value().([]byte)))
+	return 1
+}
+
+func (e *Engine) luaListFiles(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	pattern := l.CheckString(1)
+
+	var files []string
+	var err error
+
+	if strings.ContainsAny(pattern, "*?[") {
+		files, err = e.loader.Glob(pattern)
+	} else {
+		files, err = e.loader.List(pattern)
+	}
+
 	if err != nil {
 		l.RaiseError(err.Error())
 		return 0
 	}
 
-	l.Push(lua.LString(result))
+	resultArray := l.NewTable()
+
+	for i := 0; i < len(files); i++ {
+		resultArray.Append(lua.LString(files[i]))
+	}
+
+	l.Push(resultArray)
 	return 1
 }
 
@@ -404,7 +694,7 @@ func (e *Engine) luaLoadButton(l *lua.LState) int {
 		return 0
 	}
 
-	button, err := button.New(e.loader, e, *buttonLayout)
+	button, err := button.New(e.resources, e.renderer, e.atlas, e.mouse, *buttonLayout)
 
 	if err != nil {
 		l.RaiseError(err.Error())
@@ -429,7 +719,7 @@ func (e *Engine) luaLoadSprite(l *lua.LState) int {
 	filePath := l.CheckString(1)
 	palette := l.CheckString(2)
 
-	result, err := sprite.New(e.loader, e, filePath, palette)
+	result, err := sprite.New(e.resources, e.renderer, e.atlas, e.mouse, filePath, palette)
 
 	if err != nil {
 		l.RaiseError(err.Error())
@@ -446,15 +736,27 @@ func (e *Engine) luaSetCursor(l *lua.LState) int {
 		return 0
 	}
 
+	e.removeCursor()
+
 	if l.Get(1).Type() == lua.LTNil {
-		e.cursorSprite = nil
 		return 0
 	}
 
 	sprite, err := sprite.FromLua(l.ToUserData(1))
 
 	if err != nil {
-		e.cursorSprite = nil
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	// the cursor is drawn from the root, in the cursor layer, above every node that was in it before
+	if sprite.Parent != nil {
+		sprite.Parent.RemoveChild(sprite.Node)
+	}
+
+	sprite.Layer = node.RenderLayerCursor
+
+	if err := e.rootNode.AddChild(sprite.Node); err != nil {
 		l.RaiseError(err.Error())
 		return 0
 	}
@@ -464,6 +766,19 @@ func (e *Engine) luaSetCursor(l *lua.LState) int {
 	return 0
 }
 
+// removeCursor takes the cursor sprite out of the node tree, leaving it to the script that created it.
+func (e *Engine) removeCursor() {
+	if e.cursorSprite == nil {
+		return
+	}
+
+	if e.cursorSprite.Parent != nil {
+		e.cursorSprite.Parent.RemoveChild(e.cursorSprite.Node)
+	}
+
+	e.cursorSprite = nil
+}
+
 func (e *Engine) luaLog(l *lua.LState) int {
 	if l.GetTop() != 2 {
 		l.ArgError(l.GetTop(), "expected two arguments")
@@ -526,7 +841,7 @@ func (e *Engine) luaLoadLabel(l *lua.LState) int {
 	fontPath := l.CheckString(1)
 	palette := l.CheckString(2)
 
-	result, err := label.New(e.loader, fontPath, palette)
+	result, err := label.New(e.resources, e.renderer, e.atlas, fontPath, palette)
 
 	if err != nil {
 		l.RaiseError(err.Error())
@@ -537,3 +852,831 @@ func (e *Engine) luaLoadLabel(l *lua.LState) int {
 	return 1
 
 }
+
+func (e *Engine) luaGetResourceStats(l *lua.LState) int {
+	if l.GetTop() > 0 {
+		l.ArgError(1, "no arguments expected")
+		return 0
+	}
+
+	stats := e.resources.Stats()
+	result := l.NewTable()
+
+	result.RawSetString("hits", lua.LNumber(stats.Hits))
+	result.RawSetString("misses", lua.LNumber(stats.Misses))
+	result.RawSetString("evictions", lua.LNumber(stats.Evictions))
+	result.RawSetString("entries", lua.LNumber(stats.Entries))
+	result.RawSetString("bytes", lua.LNumber(stats.Bytes))
+	result.RawSetString("budget", lua.LNumber(stats.Budget))
+
+	l.Push(result)
+	return 1
+}
+
+func (e *Engine) luaGetMods(l *lua.LState) int {
+	if l.GetTop() > 0 {
+		l.ArgError(1, "no arguments expected")
+		return 0
+	}
+
+	result := l.NewTable()
+
+	for _, mod := range e.loader.Mods() {
+		modTable := l.NewTable()
+		dependencies := l.NewTable()
+
+		for _, dependency := range mod.Dependencies {
+			dependencies.Append(lua.LString(dependency))
+		}
+
+		modTable.RawSetString("name", lua.LString(mod.Name))
+		modTable.RawSetString("version", lua.LString(mod.Version))
+		modTable.RawSetString("priority", lua.LNumber(mod.Priority))
+		modTable.RawSetString("path", lua.LString(mod.Path))
+		modTable.RawSetString("dependencies", dependencies)
+
+		result.Append(modTable)
+	}
+
+	l.Push(result)
+	return 1
+}
+
+func (e *Engine) luaGetConflicts(l *lua.LState) int {
+	if l.GetTop() > 0 {
+		l.ArgError(1, "no arguments expected")
+		return 0
+	}
+
+	conflicts, err := e.loader.Conflicts()
+
+	if err != nil {
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	result := l.NewTable()
+
+	for _, conflict := range conflicts {
+		conflictTable := l.NewTable()
+		sources := l.NewTable()
+
+		for _, source := range conflict.Sources {
+			sources.Append(lua.LString(source))
+		}
+
+		conflictTable.RawSetString("path", lua.LString(conflict.Path))
+		conflictTable.RawSetString("sources", sources)
+
+		result.Append(conflictTable)
+	}
+
+	l.Push(result)
+	return 1
+}
+
+func (e *Engine) luaGetFileSource(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	source, ok := e.loader.Source(l.CheckString(1))
+
+	if !ok {
+		l.Push(lua.LNil)
+		return 1
+	}
+
+	l.Push(lua.LString(source))
+	return 1
+}
+
+func (e *Engine) luaOnReload(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	e.reloadHandlers = append(e.reloadHandlers, l.CheckFunction(1))
+
+	return 0
+}
+
+func (e *Engine) luaGetDisplaySettings(l *lua.LState) int {
+	if l.GetTop() > 0 {
+		l.ArgError(1, "no arguments expected")
+		return 0
+	}
+
+	surfaceWidth, surfaceHeight := e.renderer.SurfaceSize()
+	screenWidth, screenHeight := e.renderer.ScreenSize()
+
+	result := l.NewTable()
+	result.RawSetString("virtualWidth", lua.LNumber(surfaceWidth))
+	result.RawSetString("virtualHeight", lua.LNumber(surfaceHeight))
+	result.RawSetString("windowWidth", lua.LNumber(screenWidth))
+	result.RawSetString("windowHeight", lua.LNumber(screenHeight))
+	result.RawSetString("fullscreen", lua.LBool(e.renderer.IsFullscreen()))
+	result.RawSetString("scaleMode", lua.LString(e.scaleMode.ToString()))
+
+	l.Push(result)
+
+	return 1
+}
+
+func (e *Engine) luaSetVirtualResolution(l *lua.LState) int {
+	if l.GetTop() != 2 {
+		l.ArgError(l.GetTop(), "expected two arguments")
+		return 0
+	}
+
+	width := l.CheckInt(1)
+	height := l.CheckInt(2)
+
+	if width <= 0 || height <= 0 {
+		l.ArgError(1, "the resolution must be positive")
+		return 0
+	}
+
+	e.config.VirtualWidth = width
+	e.config.VirtualHeight = height
+	e.renderer.SetSurfaceSize(width, height)
+
+	return 0
+}
+
+func (e *Engine) luaSetWindowSize(l *lua.LState) int {
+	if l.GetTop() != 2 {
+		l.ArgError(l.GetTop(), "expected two arguments")
+		return 0
+	}
+
+	width := l.CheckInt(1)
+	height := l.CheckInt(2)
+
+	if width <= 0 || height <= 0 {
+		l.ArgError(1, "the window size must be positive")
+		return 0
+	}
+
+	e.config.WindowWidth = width
+	e.config.WindowHeight = height
+	e.renderer.SetWindowSize(width, height)
+
+	return 0
+}
+
+func (e *Engine) luaSetFullscreen(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	e.config.Fullscreen = l.CheckBool(1)
+	e.renderer.SetFullscreen(e.config.Fullscreen)
+
+	return 0
+}
+
+func (e *Engine) luaSetScaleMode(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	scaleMode, err := StringToScaleMode(l.CheckString(1))
+
+	if err != nil {
+		l.ArgError(1, err.Error())
+		return 0
+	}
+
+	e.setScaleMode(scaleMode)
+
+	return 0
+}
+
+func (e *Engine) luaDefineAction(l *lua.LState) int {
+	if l.GetTop() < 1 {
+		l.ArgError(1, "expected at least one argument")
+		return 0
+	}
+
+	action := l.CheckString(1)
+	bindings, ok := luaToBindings(l, 2)
+
+	if !ok {
+		return 0
+	}
+
+	e.actions.Define(action, bindings)
+
+	return 0
+}
+
+func (e *Engine) luaBindAction(l *lua.LState) int {
+	if l.GetTop() < 1 {
+		l.ArgError(1, "expected at least one argument")
+		return 0
+	}
+
+	action := l.CheckString(1)
+	bindings, ok := luaToBindings(l, 2)
+
+	if !ok {
+		return 0
+	}
+
+	e.actions.Bind(action, bindings)
+	e.saveKeyBindings()
+
+	return 0
+}
+
+func (e *Engine) luaResetAction(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	e.actions.Reset(l.CheckString(1))
+	e.saveKeyBindings()
+
+	return 0
+}
+
+func (e *Engine) luaGetActionBindings(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	result := l.NewTable()
+
+	for _, binding := range e.actions.Bindings(l.CheckString(1)) {
+		result.Append(lua.LString(binding.ToString()))
+	}
+
+	l.Push(result)
+
+	return 1
+}
+
+func (e *Engine) luaGetActions(l *lua.LState) int {
+	if l.GetTop() > 0 {
+		l.ArgError(1, "no arguments expected")
+		return 0
+	}
+
+	result := l.NewTable()
+
+	for _, action := range e.actions.Actions() {
+		result.Append(lua.LString(action))
+	}
+
+	l.Push(result)
+
+	return 1
+}
+
+func (e *Engine) luaOnAction(l *lua.LState) int {
+	if l.GetTop() != 2 {
+		l.ArgError(l.GetTop(), "expected two arguments")
+		return 0
+	}
+
+	action := l.CheckString(1)
+	e.actionHandlers[action] = append(e.actionHandlers[action], l.CheckFunction(2))
+
+	return 0
+}
+
+func (e *Engine) luaOnKey(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	e.keyHandlers = append(e.keyHandlers, l.CheckFunction(1))
+
+	return 0
+}
+
+func (e *Engine) luaOnTextInput(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	e.textHandlers = append(e.textHandlers, l.CheckFunction(1))
+
+	return 0
+}
+
+func (e *Engine) luaIsKeyDown(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	key, err := renderer.StringToKey(l.CheckString(1))
+
+	if err != nil {
+		l.ArgError(1, err.Error())
+		return 0
+	}
+
+	l.Push(lua.LBool(e.input.IsKeyDown(key)))
+
+	return 1
+}
+
+func (e *Engine) luaIsActionDown(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	l.Push(lua.LBool(e.actions.IsDown(l.CheckString(1), e.input)))
+
+	return 1
+}
+
+func (e *Engine) luaOnMouse(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	e.mouseHandlers = append(e.mouseHandlers, l.CheckFunction(1))
+
+	return 0
+}
+
+func (e *Engine) luaGetMousePosition(l *lua.LState) int {
+	x, y := e.mouse.GetMousePosition()
+
+	l.Push(lua.LNumber(x))
+	l.Push(lua.LNumber(y))
+
+	return 2
+}
+
+func (e *Engine) luaIsMouseButtonDown(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	button, err := renderer.StringToMouseButton(l.CheckString(1))
+
+	if err != nil {
+		l.ArgError(1, err.Error())
+		return 0
+	}
+
+	l.Push(lua.LBool(e.mouse.IsButtonDown(button)))
+
+	return 1
+}
+
+func (e *Engine) luaSetDragThreshold(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	threshold := l.CheckInt(1)
+
+	if threshold < 0 {
+		l.ArgError(1, "threshold must not be negative")
+		return 0
+	}
+
+	e.mouse.SetDragThreshold(threshold)
+
+	return 0
+}
+
+func (e *Engine) luaTween(l *lua.LState) int {
+	if l.GetTop() < 3 || l.GetTop() > 4 {
+		l.ArgError(l.GetTop(), "expected three or four arguments")
+		return 0
+	}
+
+	target, err := node.FromLuaValue(l.Get(1))
+
+	if err != nil {
+		l.ArgError(1, err.Error())
+		return 0
+	}
+
+	duration := l.CheckInt(2)
+	values := l.CheckTable(3)
+	easing := tween.Easing(tween.Linear)
+
+	if l.GetTop() == 4 {
+		if easing, err = tween.StringToEasing(l.CheckString(4)); err != nil {
+			l.ArgError(4, err.Error())
+			return 0
+		}
+	}
+
+	result := tween.New(target, float64(duration)/1000, easing)
+
+	values.ForEach(func(key, value lua.LValue) {
+		if err != nil {
+			return
+		}
+
+		to, ok := value.(lua.LNumber)
+
+		if !ok {
+			err = fmt.Errorf("%s must be a number", key.String())
+			return
+		}
+
+		var tweenValues []tween.Value
+
+		if tweenValues, err = luaTweenValues(l.Get(1), target, key.String()); err != nil {
+			return
+		}
+
+		for idx := range tweenValues {
+			result.Add(tweenValues[idx], float64(to))
+		}
+	})
+
+	if err != nil {
+		l.ArgError(3, err.Error())
+		return 0
+	}
+
+	l.Push(e.tweens.ToLua(l, result))
+
+	return 1
+}
+
+// luaTweenValues returns the values a tween changes for a key of its values table. The scale key changes both scales,
+// and sprites also have their palette shift.
+func luaTweenValues(value lua.LValue, target *node.Node, name string) ([]tween.Value, error) {
+	switch name {
+	case "scale":
+		scaleX, _ := tween.NodeValue(target, "scaleX")
+		scaleY, _ := tween.NodeValue(target, "scaleY")
+
+		return []tween.Value{scaleX, scaleY}, nil
+	case "paletteShift":
+		spr, ok := value.(*lua.LUserData).Value.(*sprite.Sprite)
+
+		if !ok {
+			return nil, errors.New("only sprites have a palette shift")
+		}
+
+		return []tween.Value{{Get: spr.PaletteShift, Set: spr.SetPaletteShift}}, nil
+	}
+
+	result, err := tween.NodeValue(target, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []tween.Value{result}, nil
+}
+
+// luaToAnimations converts the arguments of a Lua function to animations.
+func luaToAnimations(l *lua.LState) ([]tween.Animation, bool) {
+	result := make([]tween.Animation, l.GetTop())
+
+	for idx := range result {
+		animation, err := tween.FromLua(l.CheckUserData(idx + 1))
+
+		if err != nil {
+			l.ArgError(idx+1, "expected a tween")
+			return nil, false
+		}
+
+		result[idx] = animation
+	}
+
+	return result, true
+}
+
+func (e *Engine) luaSequence(l *lua.LState) int {
+	animations, ok := luaToAnimations(l)
+
+	if !ok {
+		return 0
+	}
+
+	result, err := tween.NewSequence(animations...)
+
+	if err != nil {
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	l.Push(e.tweens.ToLua(l, result))
+
+	return 1
+}
+
+func (e *Engine) luaParallel(l *lua.LState) int {
+	animations, ok := luaToAnimations(l)
+
+	if !ok {
+		return 0
+	}
+
+	result, err := tween.NewParallel(animations...)
+
+	if err != nil {
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	l.Push(e.tweens.ToLua(l, result))
+
+	return 1
+}
+
+func (e *Engine) luaTweenDelay(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	l.Push(e.tweens.ToLua(l, tween.NewDelay(float64(l.CheckInt(1))/1000)))
+
+	return 1
+}
+
+// defaultSceneTransitionDuration is how long (in milliseconds) scene transitions take when no duration is given
+const defaultSceneTransitionDuration = 300
+
+func (e *Engine) luaCreateScene(l *lua.LState) int {
+	if l.GetTop() < 1 || l.GetTop() > 2 {
+		l.ArgError(l.GetTop(), "expected one or two arguments")
+		return 0
+	}
+
+	result := scene.New(l.CheckString(1))
+
+	if l.GetTop() == 2 {
+		callbacks := l.CheckTable(2)
+
+		for name, callback := range map[string]**lua.LFunction{
+			"onEnter":  &result.OnEnter,
+			"onExit":   &result.OnExit,
+			"onPause":  &result.OnPause,
+			"onResume": &result.OnResume,
+		} {
+			switch value := l.GetField(callbacks, name).(type) {
+			case *lua.LNilType:
+			case *lua.LFunction:
+				*callback = value
+			default:
+				l.ArgError(2, name+" must be a function")
+				return 0
+			}
+		}
+
+		result.Overlay = lua.LVAsBool(l.GetField(callbacks, "overlay"))
+	}
+
+	l.Push(result.ToLua(l))
+
+	return 1
+}
+
+// luaSceneTransition reads the optional transition and duration arguments of the scene functions, starting at idx.
+func luaSceneTransition(l *lua.LState, idx int) (scene.Transition, float64, bool) {
+	if l.GetTop() > idx+1 {
+		l.ArgError(l.GetTop(), "too many arguments")
+		return scene.TransitionNone, 0, false
+	}
+
+	transition, err := scene.StringToTransition(l.OptString(idx, ""))
+
+	if err != nil {
+		l.ArgError(idx, err.Error())
+		return scene.TransitionNone, 0, false
+	}
+
+	return transition, float64(l.OptInt(idx+1, defaultSceneTransitionDuration)) / 1000, true
+}
+
+func (e *Engine) luaPushScene(l *lua.LState) int {
+	s, err := scene.FromLua(l.CheckUserData(1))
+
+	if err != nil {
+		l.ArgError(1, "expected a scene")
+		return 0
+	}
+
+	transition, duration, ok := luaSceneTransition(l, 2)
+
+	if !ok {
+		return 0
+	}
+
+	if err := e.scenes.Push(s, transition, duration); err != nil {
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	return 0
+}
+
+func (e *Engine) luaPopScene(l *lua.LState) int {
+	transition, duration, ok := luaSceneTransition(l, 1)
+
+	if !ok {
+		return 0
+	}
+
+	if err := e.scenes.Pop(transition, duration); err != nil {
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	return 0
+}
+
+func (e *Engine) luaReplaceScene(l *lua.LState) int {
+	s, err := scene.FromLua(l.CheckUserData(1))
+
+	if err != nil {
+		l.ArgError(1, "expected a scene")
+		return 0
+	}
+
+	transition, duration, ok := luaSceneTransition(l, 2)
+
+	if !ok {
+		return 0
+	}
+
+	if err := e.scenes.Replace(s, transition, duration); err != nil {
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	return 0
+}
+
+func (e *Engine) luaGetCurrentScene(l *lua.LState) int {
+	top := e.scenes.Top()
+
+	if top == nil {
+		l.Push(lua.LNil)
+		return 1
+	}
+
+	l.Push(top.ToLua(l))
+
+	return 1
+}
+
+func (e *Engine) luaGetSceneStack(l *lua.LState) int {
+	result := l.NewTable()
+
+	for _, s := range e.scenes.Stack() {
+		result.Append(s.ToLua(l))
+	}
+
+	l.Push(result)
+
+	return 1
+}
+
+func (e *Engine) luaSetDebugOverlay(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	e.debugOverlay = l.CheckBool(1)
+
+	return 0
+}
+
+func (e *Engine) luaSetBatching(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	e.renderer.SetBatching(l.CheckBool(1))
+
+	return 0
+}
+
+func (e *Engine) luaGetDebugInfo(l *lua.LState) int {
+	result, err := e.debugInfoJSON()
+
+	if err != nil {
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	l.Push(lua.LString(result))
+
+	return 1
+}
+
+func (e *Engine) luaLoadComposite(l *lua.LState) int {
+	if l.GetTop() != 4 {
+		l.ArgError(l.GetTop(), "expected four arguments")
+		return 0
+	}
+
+	directory := l.CheckString(1)
+	mode := l.CheckString(2)
+	weaponClass := l.CheckString(3)
+	palette := l.CheckString(4)
+
+	result, err := composite.New(e.resources, e.renderer, e.atlas, directory, mode, weaponClass, palette)
+
+	if err != nil {
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	l.Push(result.ToLua(l))
+
+	return 1
+}
+
+func (e *Engine) luaLoadMap(l *lua.LState) int {
+	if l.GetTop() < 2 {
+		l.ArgError(l.GetTop(), "expected at least two arguments")
+		return 0
+	}
+
+	ds1Path := l.CheckString(1)
+	palette := l.CheckString(2)
+	dt1Paths := make([]string, 0, l.GetTop()-2)
+
+	for idx := 3; idx <= l.GetTop(); idx++ {
+		dt1Paths = append(dt1Paths, l.CheckString(idx))
+	}
+
+	result, err := maprenderer.New(e.resources, e.renderer, e.atlas, ds1Path, palette, dt1Paths...)
+
+	if err != nil {
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	l.Push(result.ToLua(l))
+
+	return 1
+}
+
+func (e *Engine) luaCreateCamera(l *lua.LState) int {
+	if l.GetTop() > 0 {
+		l.ArgError(1, "no arguments expected")
+		return 0
+	}
+
+	l.Push(camera.New(e.renderer, e).ToLua(l))
+
+	return 1
+}
+
+func (e *Engine) luaSetCamera(l *lua.LState) int {
+	if l.GetTop() != 1 {
+		l.ArgError(l.GetTop(), "expected one argument")
+		return 0
+	}
+
+	if l.Get(1).Type() == lua.LTNil {
+		e.camera = nil
+		return 0
+	}
+
+	result, err := camera.FromLua(l.CheckUserData(1))
+
+	if err != nil {
+		l.ArgError(1, "camera expected")
+		return 0
+	}
+
+	e.camera = result
+
+	return 0
+}
+
+func (e *Engine) luaGetCamera(l *lua.LState) int {
+	if e.camera == nil {
+		l.Push(lua.LNil)
+		return 1
+	}
+
+	l.Push(e.camera.ToLua(l))
+
+	return 1
+}
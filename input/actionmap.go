@@ -0,0 +1,221 @@
+package input
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+)
+
+// Binding is a key, along with the modifier keys that must be held with it. No other modifier may be held, so that
+// "s" and "ctrl+s" can be bound to different actions.
+type Binding struct {
+	Key       renderer.Key
+	Modifiers Modifiers
+}
+
+// ToString returns the binding in the form it is written to the bindings file, e.g. "ctrl+s".
+func (b Binding) ToString() string {
+	if b.Modifiers == 0 {
+		return b.Key.ToString()
+	}
+
+	return b.Modifiers.ToString() + "+" + b.Key.ToString()
+}
+
+// StringToBinding parses a key name, optionally preceded by modifiers, e.g. "i", "shift+tab" or "ctrl+alt+delete".
+func StringToBinding(s string) (Binding, error) {
+	result := Binding{}
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "+")
+
+	for _, part := range parts[:len(parts)-1] {
+		found := false
+
+		for _, modifier := range modifierNames {
+			if modifier.name == part {
+				result.Modifiers |= modifier.modifier
+				found = true
+			}
+		}
+
+		if !found {
+			return result, fmt.Errorf("unknown modifier %s in binding %s", part, s)
+		}
+	}
+
+	key, err := renderer.StringToKey(parts[len(parts)-1])
+
+	if err != nil {
+		return result, fmt.Errorf("unknown key in binding %s", s)
+	}
+
+	result.Key = key
+
+	return result, nil
+}
+
+// Matches reports whether the binding is triggered by the key event. The modifiers held must be exactly those of the
+// binding, but they are not checked when a key is released, since they may have been released first.
+func (b Binding) Matches(event KeyEvent) bool {
+	if b.Key != event.Key {
+		return false
+	}
+
+	return event.Type == KeyEventUp || event.Modifiers == b.Modifiers
+}
+
+// ActionMap maps named actions, such as "inventory", to the keys that trigger them. Every action has default bindings
+// set by the game, which the player can replace with their own. Only the player's bindings are saved.
+type ActionMap struct {
+	defaults map[string][]Binding
+	bindings map[string][]Binding
+}
+
+func NewActionMap() *ActionMap {
+	result := &ActionMap{
+		defaults: make(map[string][]Binding),
+		bindings: make(map[string][]Binding),
+	}
+
+	return result
+}
+
+// Define sets the default bindings of an action.
+func (a *ActionMap) Define(action string, defaults []Binding) {
+	a.defaults[action] = defaults
+}
+
+// Bind replaces the bindings of an action with the player's own.
+func (a *ActionMap) Bind(action string, bindings []Binding) {
+	a.bindings[action] = bindings
+}
+
+// Reset drops the player's bindings of an action, so that it goes back to its defaults.
+func (a *ActionMap) Reset(action string) {
+	delete(a.bindings, action)
+}
+
+// Bindings returns the keys that trigger an action.
+func (a *ActionMap) Bindings(action string) []Binding {
+	if bindings, ok := a.bindings[action]; ok {
+		return bindings
+	}
+
+	return a.defaults[action]
+}
+
+// Actions returns the names of all the actions that are defined or bound, sorted by name.
+func (a *ActionMap) Actions() []string {
+	result := make([]string, 0, len(a.defaults))
+
+	for action := range a.defaults {
+		result = append(result, action)
+	}
+
+	for action := range a.bindings {
+		if _, ok := a.defaults[action]; !ok {
+			result = append(result, action)
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// Match returns the actions triggered by a key event, sorted by name.
+func (a *ActionMap) Match(event KeyEvent) []string {
+	result := make([]string, 0)
+
+	for _, action := range a.Actions() {
+		for _, binding := range a.Bindings(action) {
+			if binding.Matches(event) {
+				result = append(result, action)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// IsDown reports whether any of the keys bound to an action are held, along with exactly their modifiers.
+func (a *ActionMap) IsDown(action string, input *Input) bool {
+	for _, binding := range a.Bindings(action) {
+		if input.IsKeyDown(binding.Key) && input.Modifiers() == binding.Modifiers {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Load reads the player's bindings from a file. A missing file is not an error.
+func (a *ActionMap) Load(path string) error {
+	data, err := ioutil.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fileBindings := make(map[string][]string)
+
+	if err := json.Unmarshal(data, &fileBindings); err != nil {
+		return fmt.Errorf("invalid key bindings file %s: %v", path, err)
+	}
+
+	for action, names := range fileBindings {
+		bindings := make([]Binding, 0, len(names))
+
+		for _, name := range names {
+			binding, err := StringToBinding(name)
+
+			if err != nil {
+				return err
+			}
+
+			bindings = append(bindings, binding)
+		}
+
+		a.bindings[action] = bindings
+	}
+
+	return nil
+}
+
+// Save writes the player's bindings to a file, creating its directory if needed.
+func (a *ActionMap) Save(path string) error {
+	fileBindings := make(map[string][]string)
+
+	for action, bindings := range a.bindings {
+		names := make([]string, len(bindings))
+
+		for idx := range bindings {
+			names[idx] = bindings[idx].ToString()
+		}
+
+		fileBindings[action] = names
+	}
+
+	data, err := json.MarshalIndent(fileBindings, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
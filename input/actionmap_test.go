@@ -0,0 +1,117 @@
+package input
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+)
+
+func TestActionMapMatchesExactModifiers(t *testing.T) {
+	a := NewActionMap()
+	a.Define("moveDown", []Binding{{Key: renderer.KeyS}})
+	a.Define("save", []Binding{{Key: renderer.KeyS, Modifiers: ModifierControl}})
+
+	tests := []struct {
+		name  string
+		event KeyEvent
+		want  []string
+	}{
+		{
+			name:  "no modifiers",
+			event: KeyEvent{Key: renderer.KeyS, Type: KeyEventDown},
+			want:  []string{"moveDown"},
+		},
+		{
+			name:  "the binding's modifiers",
+			event: KeyEvent{Key: renderer.KeyS, Type: KeyEventDown, Modifiers: ModifierControl},
+			want:  []string{"save"},
+		},
+		{
+			name:  "repeat with the binding's modifiers",
+			event: KeyEvent{Key: renderer.KeyS, Type: KeyEventRepeat, Modifiers: ModifierControl},
+			want:  []string{"save"},
+		},
+		{
+			name:  "extra modifiers",
+			event: KeyEvent{Key: renderer.KeyS, Type: KeyEventDown, Modifiers: ModifierControl | ModifierShift},
+			want:  []string{},
+		},
+		{
+			// the modifiers may have been released before the key
+			name:  "release",
+			event: KeyEvent{Key: renderer.KeyS, Type: KeyEventUp, Modifiers: ModifierShift},
+			want:  []string{"moveDown", "save"},
+		},
+		{
+			name:  "other key",
+			event: KeyEvent{Key: renderer.KeyI, Type: KeyEventDown},
+			want:  []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := a.Match(test.event); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("matched %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestActionMapSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "keybindings.json")
+	defaults := map[string][]Binding{
+		"inventory": {{Key: renderer.KeyI}},
+		"save":      {{Key: renderer.KeyS, Modifiers: ModifierControl}},
+		"menu":      {{Key: renderer.KeyEscape}},
+	}
+	bindings := map[string][]Binding{
+		"inventory": {{Key: renderer.KeyTab, Modifiers: ModifierShift}, {Key: renderer.KeyI}},
+		"save":      {{Key: renderer.KeyS, Modifiers: ModifierControl | ModifierAlt}},
+	}
+
+	newActionMap := func() *ActionMap {
+		result := NewActionMap()
+
+		for action, keys := range defaults {
+			result.Define(action, keys)
+		}
+
+		return result
+	}
+
+	saved := newActionMap()
+
+	for action, keys := range bindings {
+		saved.Bind(action, keys)
+	}
+
+	if err := saved.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := newActionMap()
+
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, action := range saved.Actions() {
+		if got, want := loaded.Bindings(action), saved.Bindings(action); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s is bound to %v after loading, want %v", action, got, want)
+		}
+	}
+
+	// only the player's bindings are saved, so a changed default still applies
+	loaded.Define("menu", []Binding{{Key: renderer.KeyF10}})
+
+	if got := loaded.Bindings("menu"); len(got) != 1 || got[0].Key != renderer.KeyF10 {
+		t.Errorf("menu is bound to %v, want its new default", got)
+	}
+
+	if err := NewActionMap().Load(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("loading a missing file returned %v", err)
+	}
+}
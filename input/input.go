@@ -0,0 +1,185 @@
+package input
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+)
+
+const (
+	// repeatDelay is how long (in seconds) a key is held before it starts repeating
+	repeatDelay = 0.5
+	// repeatInterval is the time (in seconds) between repeats of a held key
+	repeatInterval = 1.0 / 30.0
+)
+
+type KeyEventType int
+
+const (
+	KeyEventDown KeyEventType = iota
+	KeyEventUp
+	KeyEventRepeat
+)
+
+func (t KeyEventType) ToString() string {
+	switch t {
+	case KeyEventDown:
+		return "down"
+	case KeyEventUp:
+		return "up"
+	case KeyEventRepeat:
+		return "repeat"
+	}
+
+	return "down"
+}
+
+// Modifiers is a set of modifier keys. The left and right keys of each kind are treated as the same modifier.
+type Modifiers int
+
+const (
+	ModifierShift Modifiers = 1 << iota
+	ModifierControl
+	ModifierAlt
+	ModifierSuper
+)
+
+var modifierNames = []struct {
+	modifier Modifiers
+	name     string
+}{
+	{ModifierControl, "ctrl"},
+	{ModifierAlt, "alt"},
+	{ModifierShift, "shift"},
+	{ModifierSuper, "super"},
+}
+
+// Has reports whether every modifier in other is also in the set.
+func (m Modifiers) Has(other Modifiers) bool {
+	return m&other == other
+}
+
+// ToString returns the modifiers joined with plus signs, e.g. "ctrl+shift".
+func (m Modifiers) ToString() string {
+	names := make([]string, 0)
+
+	for _, modifier := range modifierNames {
+		if m.Has(modifier.modifier) {
+			names = append(names, modifier.name)
+		}
+	}
+
+	return strings.Join(names, "+")
+}
+
+// KeyEvent is a change in the state of a key.
+type KeyEvent struct {
+	Key       renderer.Key
+	Type      KeyEventType
+	Modifiers Modifiers
+}
+
+// Input polls the keyboard once per frame and turns it into key events and text input. Key events are for actions,
+// text comes from the characters the renderer reports, so that it follows the keyboard layout of the system.
+type Input struct {
+	renderer  renderer.Renderer
+	keysDown  map[renderer.Key]float64
+	modifiers Modifiers
+	events    []KeyEvent
+	text      []rune
+}
+
+func New(renderProvider renderer.Renderer) *Input {
+	result := &Input{
+		renderer: renderProvider,
+		keysDown: make(map[renderer.Key]float64),
+		events:   make([]KeyEvent, 0),
+		text:     make([]rune, 0),
+	}
+
+	return result
+}
+
+// Update polls the keyboard, replacing the events and text of the previous frame.
+func (i *Input) Update(elapsed float64) {
+	i.events = i.events[:0]
+	i.text = i.text[:0]
+	i.modifiers = i.pollModifiers()
+
+	for _, key := range renderer.Keys {
+		repeatTime, wasDown := i.keysDown[key]
+		isDown := i.renderer.IsKeyDown(key)
+
+		switch {
+		case isDown && !wasDown:
+			i.keysDown[key] = repeatDelay
+			i.addEvent(key, KeyEventDown)
+		case !isDown && wasDown:
+			delete(i.keysDown, key)
+			i.addEvent(key, KeyEventUp)
+		case isDown:
+			repeatTime -= elapsed
+
+			if repeatTime <= 0 {
+				repeatTime += repeatInterval
+				i.addEvent(key, KeyEventRepeat)
+			}
+
+			i.keysDown[key] = repeatTime
+		}
+	}
+
+	for char := i.renderer.CharPressed(); char != 0; char = i.renderer.CharPressed() {
+		if unicode.IsPrint(char) {
+			i.text = append(i.text, char)
+		}
+	}
+}
+
+func (i *Input) addEvent(key renderer.Key, eventType KeyEventType) {
+	i.events = append(i.events, KeyEvent{Key: key, Type: eventType, Modifiers: i.modifiers})
+}
+
+func (i *Input) pollModifiers() Modifiers {
+	var result Modifiers
+
+	if i.renderer.IsKeyDown(renderer.KeyLeftShift) || i.renderer.IsKeyDown(renderer.KeyRightShift) {
+		result |= ModifierShift
+	}
+
+	if i.renderer.IsKeyDown(renderer.KeyLeftControl) || i.renderer.IsKeyDown(renderer.KeyRightControl) {
+		result |= ModifierControl
+	}
+
+	if i.renderer.IsKeyDown(renderer.KeyLeftAlt) || i.renderer.IsKeyDown(renderer.KeyRightAlt) {
+		result |= ModifierAlt
+	}
+
+	if i.renderer.IsKeyDown(renderer.KeyLeftSuper) || i.renderer.IsKeyDown(renderer.KeyRightSuper) {
+		result |= ModifierSuper
+	}
+
+	return result
+}
+
+// Events returns the key events of the current frame, in key code order.
+func (i *Input) Events() []KeyEvent {
+	return i.events
+}
+
+// Text returns the text typed during the current frame.
+func (i *Input) Text() string {
+	return string(i.text)
+}
+
+func (i *Input) IsKeyDown(key renderer.Key) bool {
+	_, ok := i.keysDown[key]
+
+	return ok
+}
+
+// Modifiers returns the modifier keys held during the current frame.
+func (i *Input) Modifiers() Modifiers {
+	return i.modifiers
+}
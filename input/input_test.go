@@ -0,0 +1,35 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer/headlessrenderer"
+)
+
+func TestTextComesFromTypedCharacters(t *testing.T) {
+	r := headlessrenderer.New(8, 8, 0)
+	i := New(r)
+
+	// keys that would type other characters on a US layout, and a control character that is not text
+	r.SetKeyDown(renderer.KeyLeftShift, true)
+	r.SetKeyDown(renderer.KeyTwo, true)
+	r.TypeText("\"é\b")
+	r.EndScreen()
+	i.Update(0)
+
+	if text := i.Text(); text != "\"é" {
+		t.Errorf("text is %q, want %q", text, "\"é")
+	}
+
+	if len(i.Events()) != 2 || !i.IsKeyDown(renderer.KeyTwo) || !i.Modifiers().Has(ModifierShift) {
+		t.Errorf("events are %+v", i.Events())
+	}
+
+	r.EndScreen()
+	i.Update(0)
+
+	if text := i.Text(); text != "" {
+		t.Errorf("text of the next frame is %q", text)
+	}
+}
@@ -0,0 +1,34 @@
+package input
+
+import lua "github.com/yuin/gopher-lua"
+
+// ToLua returns a table with a shift, ctrl, alt and super field for the modifiers.
+func (m Modifiers) ToLua(l *lua.LState) *lua.LTable {
+	result := l.NewTable()
+	result.RawSetString("shift", lua.LBool(m.Has(ModifierShift)))
+	result.RawSetString("ctrl", lua.LBool(m.Has(ModifierControl)))
+	result.RawSetString("alt", lua.LBool(m.Has(ModifierAlt)))
+	result.RawSetString("super", lua.LBool(m.Has(ModifierSuper)))
+
+	return result
+}
+
+// ToLua returns a table with the type, button, x, y, dx, dy, wheel and modifiers fields of the event. The button is
+// nil for move, wheel, over and leave events.
+func (e MouseEvent) ToLua(l *lua.LState) *lua.LTable {
+	result := l.NewTable()
+	result.RawSetString("type", lua.LString(e.Type.ToString()))
+
+	if e.HasButton() {
+		result.RawSetString("button", lua.LString(e.Button.ToString()))
+	}
+
+	result.RawSetString("x", lua.LNumber(e.X))
+	result.RawSetString("y", lua.LNumber(e.Y))
+	result.RawSetString("dx", lua.LNumber(e.DeltaX))
+	result.RawSetString("dy", lua.LNumber(e.DeltaY))
+	result.RawSetString("wheel", lua.LNumber(e.Wheel))
+	result.RawSetString("modifiers", e.Modifiers.ToLua(l))
+
+	return result
+}
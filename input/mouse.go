@@ -0,0 +1,288 @@
+package input
+
+import "github.com/OpenDiablo2/AbyssEngine/renderer"
+
+const (
+	// DefaultDragThreshold is how far (in pixels) the mouse moves with a button held before it counts as a drag
+	DefaultDragThreshold = 4
+	// doubleClickTime is the longest time (in seconds) between the two clicks of a double click
+	doubleClickTime = 0.4
+	// doubleClickDistance is how far (in pixels) apart the two clicks of a double click may be
+	doubleClickDistance = 4
+)
+
+type MouseEventType int
+
+const (
+	MouseEventMove MouseEventType = iota
+	MouseEventDown
+	MouseEventUp
+	MouseEventClick
+	MouseEventDoubleClick
+	MouseEventDragStart
+	MouseEventDrag
+	MouseEventDragEnd
+	MouseEventWheel
+	// MouseEventOver and MouseEventLeave are not made by Mouse, they are sent by the node tree when the topmost node
+	// under the mouse changes.
+	MouseEventOver
+	MouseEventLeave
+)
+
+func (t MouseEventType) ToString() string {
+	switch t {
+	case MouseEventMove:
+		return "move"
+	case MouseEventDown:
+		return "down"
+	case MouseEventUp:
+		return "up"
+	case MouseEventClick:
+		return "click"
+	case MouseEventDoubleClick:
+		return "doubleclick"
+	case MouseEventDragStart:
+		return "dragstart"
+	case MouseEventDrag:
+		return "drag"
+	case MouseEventDragEnd:
+		return "dragend"
+	case MouseEventWheel:
+		return "wheel"
+	case MouseEventOver:
+		return "over"
+	case MouseEventLeave:
+		return "leave"
+	}
+
+	return "move"
+}
+
+// MouseEvent is a change in the state of the mouse. The position is on the virtual surface.
+type MouseEvent struct {
+	Type   MouseEventType
+	Button renderer.MouseButton
+	X      int
+	Y      int
+	// DeltaX and DeltaY are how far the mouse moved since the previous frame for move events, and how far it moved
+	// from where the button went down for drag events.
+	DeltaX    int
+	DeltaY    int
+	Wheel     float32
+	Modifiers Modifiers
+}
+
+// HasButton reports whether the event is about a mouse button, rather than the mouse moving or the wheel turning.
+func (e MouseEvent) HasButton() bool {
+	switch e.Type {
+	case MouseEventMove, MouseEventWheel, MouseEventOver, MouseEventLeave:
+		return false
+	}
+
+	return true
+}
+
+type mouseButtonState struct {
+	down          bool
+	dragging      bool
+	downX         int
+	downY         int
+	lastClickTime float64
+	lastClickX    int
+	lastClickY    int
+}
+
+// Mouse tracks the mouse buttons, wheel and position once per frame and turns them into mouse events.
+//
+// A node that handles a button going down should capture the mouse, so that it keeps getting the drag and button up
+// events even after the mouse leaves it. The capture is released when all buttons are up.
+type Mouse struct {
+	renderer      renderer.Renderer
+	buttons       []mouseButtonState
+	x             int
+	y             int
+	time          float64
+	dragThreshold int
+	capture       interface{}
+	events        []MouseEvent
+}
+
+func NewMouse(renderProvider renderer.Renderer) *Mouse {
+	result := &Mouse{
+		renderer:      renderProvider,
+		buttons:       make([]mouseButtonState, len(renderer.MouseButtons)),
+		dragThreshold: DefaultDragThreshold,
+		events:        make([]MouseEvent, 0),
+	}
+
+	return result
+}
+
+// Update polls the mouse buttons and wheel, replacing the events of the previous frame. The position is where the
+// mouse is on the virtual surface.
+func (m *Mouse) Update(elapsed float64, x, y int, modifiers Modifiers) {
+	m.events = m.events[:0]
+	m.time += elapsed
+
+	if !m.anyButtonDown() {
+		m.capture = nil
+	}
+
+	if x != m.x || y != m.y {
+		m.addEvent(MouseEvent{Type: MouseEventMove, X: x, Y: y, DeltaX: x - m.x, DeltaY: y - m.y, Modifiers: modifiers})
+	}
+
+	lastX, lastY := m.x, m.y
+	m.x, m.y = x, y
+
+	for _, button := range renderer.MouseButtons {
+		m.updateButton(button, lastX, lastY, modifiers)
+	}
+
+	if wheel := m.renderer.MouseWheelMove(); wheel != 0 {
+		m.addEvent(MouseEvent{Type: MouseEventWheel, X: x, Y: y, Wheel: wheel, Modifiers: modifiers})
+	}
+}
+
+func (m *Mouse) updateButton(button renderer.MouseButton, lastX, lastY int, modifiers Modifiers) {
+	state := &m.buttons[button]
+	isDown := m.renderer.IsMouseButtonDown(button)
+	event := MouseEvent{Button: button, X: m.x, Y: m.y, Modifiers: modifiers}
+
+	switch {
+	case isDown && !state.down:
+		state.down = true
+		state.downX, state.downY = m.x, m.y
+
+		event.Type = MouseEventDown
+		m.addEvent(event)
+	case isDown && state.down:
+		event.DeltaX, event.DeltaY = m.x-state.downX, m.y-state.downY
+
+		if !state.dragging {
+			if abs(event.DeltaX) < m.dragThreshold && abs(event.DeltaY) < m.dragThreshold {
+				return
+			}
+
+			state.dragging = true
+			event.Type = MouseEventDragStart
+			event.X, event.Y = state.downX, state.downY
+			m.addEvent(event)
+
+			event.X, event.Y = m.x, m.y
+		} else if m.x == lastX && m.y == lastY {
+			return
+		}
+
+		event.Type = MouseEventDrag
+		m.addEvent(event)
+	case !isDown && state.down:
+		state.down = false
+
+		event.Type = MouseEventUp
+		m.addEvent(event)
+
+		if state.dragging {
+			state.dragging = false
+			event.Type = MouseEventDragEnd
+			event.DeltaX, event.DeltaY = m.x-state.downX, m.y-state.downY
+			m.addEvent(event)
+
+			return
+		}
+
+		event.Type = MouseEventClick
+		m.addEvent(event)
+
+		if m.time-state.lastClickTime <= doubleClickTime && abs(m.x-state.lastClickX) <= doubleClickDistance &&
+			abs(m.y-state.lastClickY) <= doubleClickDistance {
+			event.Type = MouseEventDoubleClick
+			m.addEvent(event)
+
+			// a third click starts a new double click instead of finishing another one
+			state.lastClickTime = -doubleClickTime
+
+			return
+		}
+
+		state.lastClickTime = m.time
+		state.lastClickX, state.lastClickY = m.x, m.y
+	}
+}
+
+func (m *Mouse) addEvent(event MouseEvent) {
+	m.events = append(m.events, event)
+}
+
+func (m *Mouse) anyButtonDown() bool {
+	for idx := range m.buttons {
+		if m.buttons[idx].down {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Events returns the mouse events of the current frame, in the order they happened.
+func (m *Mouse) Events() []MouseEvent {
+	return m.events
+}
+
+// GetMousePosition returns the position of the mouse on the virtual surface.
+func (m *Mouse) GetMousePosition() (X, Y int) {
+	return m.x, m.y
+}
+
+func (m *Mouse) IsButtonDown(button renderer.MouseButton) bool {
+	return m.buttons[button].down
+}
+
+// IsDragging reports whether the mouse is being dragged with the button held.
+func (m *Mouse) IsDragging(button renderer.MouseButton) bool {
+	return m.buttons[button].dragging
+}
+
+func (m *Mouse) DragThreshold() int {
+	return m.dragThreshold
+}
+
+func (m *Mouse) SetDragThreshold(threshold int) {
+	m.dragThreshold = threshold
+}
+
+// Capture makes owner the receiver of the mouse until all buttons are up. It fails if another owner has the capture.
+func (m *Mouse) Capture(owner interface{}) bool {
+	if m.capture != nil && m.capture != owner {
+		return false
+	}
+
+	m.capture = owner
+
+	return true
+}
+
+// Captured returns the owner of the mouse capture, or nil if the mouse is not captured.
+func (m *Mouse) Captured() interface{} {
+	return m.capture
+}
+
+// HasCapture reports whether owner has the mouse capture.
+func (m *Mouse) HasCapture(owner interface{}) bool {
+	return m.capture != nil && m.capture == owner
+}
+
+// ReleaseCapture releases the mouse capture, if owner has it.
+func (m *Mouse) ReleaseCapture(owner interface{}) {
+	if m.capture == owner {
+		m.capture = nil
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
@@ -0,0 +1,101 @@
+package cascloader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+const blteMagic = "BLTE"
+
+type blteChunk struct {
+	encodedSize uint32
+	decodedSize uint32
+}
+
+// decodeBLTE decodes a BLTE encoded blob. Only plain ('N'), zlib ('Z') and nested ('F') chunks are supported.
+func decodeBLTE(data []byte) ([]byte, error) {
+	if len(data) < 8 || string(data[0:4]) != blteMagic {
+		return nil, errors.New("missing BLTE signature")
+	}
+
+	headerSize := binary.BigEndian.Uint32(data[4:8])
+
+	// a header size of zero means the rest of the blob is a single chunk
+	if headerSize == 0 {
+		return decodeBLTEChunk(data[8:])
+	}
+
+	if len(data) < int(headerSize) || headerSize < 12 {
+		return nil, errors.New("truncated BLTE header")
+	}
+
+	chunkCount := int(binary.BigEndian.Uint32(data[8:12]) & 0x00FFFFFF)
+	chunks := make([]blteChunk, chunkCount)
+
+	for idx := range chunks {
+		offset := 12 + (idx * 24)
+
+		if offset+24 > int(headerSize) {
+			return nil, errors.New("truncated BLTE chunk table")
+		}
+
+		chunks[idx].encodedSize = binary.BigEndian.Uint32(data[offset : offset+4])
+		chunks[idx].decodedSize = binary.BigEndian.Uint32(data[offset+4 : offset+8])
+	}
+
+	result := make([]byte, 0)
+	offset := int(headerSize)
+
+	for idx, chunk := range chunks {
+		if offset+int(chunk.encodedSize) > len(data) {
+			return nil, fmt.Errorf("BLTE chunk %d is truncated", idx)
+		}
+
+		decoded, err := decodeBLTEChunk(data[offset : offset+int(chunk.encodedSize)])
+
+		if err != nil {
+			return nil, fmt.Errorf("BLTE chunk %d: %v", idx, err)
+		}
+
+		if len(decoded) != int(chunk.decodedSize) {
+			return nil, fmt.Errorf("BLTE chunk %d decoded to %d bytes, expected %d", idx, len(decoded),
+				chunk.decodedSize)
+		}
+
+		result = append(result, decoded...)
+		offset += int(chunk.encodedSize)
+	}
+
+	return result, nil
+}
+
+func decodeBLTEChunk(chunk []byte) ([]byte, error) {
+	if len(chunk) == 0 {
+		return nil, errors.New("empty chunk")
+	}
+
+	switch chunk[0] {
+	case 'N':
+		return chunk[1:], nil
+	case 'Z':
+		reader, err := zlib.NewReader(bytes.NewReader(chunk[1:]))
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer reader.Close()
+
+		return ioutil.ReadAll(reader)
+	case 'F':
+		return decodeBLTE(chunk[1:])
+	case 'E':
+		return nil, errors.New("encrypted chunks are not supported")
+	default:
+		return nil, fmt.Errorf("unsupported chunk encoding '%c'", chunk[0])
+	}
+}
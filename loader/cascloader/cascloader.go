@@ -0,0 +1,111 @@
+package cascloader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/OpenDiablo2/AbyssEngine/loader"
+)
+
+// CascLoader serves files out of a local CASC storage (a game install with a .build.info file and a Data directory
+// of index files and data.NNN archives). File names are resolved through the TVFS root of the active build.
+type CascLoader struct {
+	storage *storage
+	files   map[string][]fileSpan
+	names   []string
+}
+
+type fileReader struct {
+	*bytes.Reader
+}
+
+func (f *fileReader) Close() error {
+	return nil
+}
+
+func (c *CascLoader) Name() string {
+	return "CASC Loader"
+}
+
+func (c *CascLoader) Exists(path string) bool {
+	if len(path) == 0 {
+		return false
+	}
+
+	_, ok := c.files[normalizePath(path)]
+
+	return ok
+}
+
+func (c *CascLoader) Load(path string) (io.ReadSeekCloser, error) {
+	spans, ok := c.files[normalizePath(path)]
+
+	if !ok {
+		return nil, fmt.Errorf("could not locate file \"%s\" in \"%s\"", path, c.storage.basePath)
+	}
+
+	data := make([]byte, 0)
+
+	for _, span := range spans {
+		spanData, err := c.storage.readFile(span.eKey)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not read \"%s\": %v", path, err)
+		}
+
+		data = append(data, spanData...)
+	}
+
+	return &fileReader{Reader: bytes.NewReader(data)}, nil
+}
+
+func (c *CascLoader) List(prefix string) ([]string, error) {
+	result := make([]string, 0)
+
+	for _, name := range c.names {
+		if loader.HasPathPrefix(name, prefix) {
+			result = append(result, name)
+		}
+	}
+
+	return result, nil
+}
+
+func New(basePath string) (*CascLoader, error) {
+	result := &CascLoader{}
+
+	s, err := openStorage(basePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.storage = s
+
+	vfsRoot, err := s.readFile(s.vfsRootKey)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read vfs root: %v", err)
+	}
+
+	files, err := parseTVFS(vfsRoot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.files = make(map[string][]fileSpan, len(files))
+
+	for name, spans := range files {
+		result.files[normalizePath(name)] = spans
+		result.names = append(result.names, loader.NormalizePath(name))
+	}
+
+	return result, nil
+}
+
+func normalizePath(path string) string {
+	return strings.ToLower(loader.NormalizePath(path))
+}
@@ -0,0 +1,218 @@
+package cascloader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fixturePath is a synthetic CASC storage written by testdata/gen.go.
+const fixturePath = "testdata/casc"
+
+func openFixture(t *testing.T) *CascLoader {
+	t.Helper()
+
+	result, err := New(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+func TestIndexLookup(t *testing.T) {
+	s, err := openStorage(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.locations) != 6 {
+		t.Fatalf("the index has %d entries, want 6", len(s.locations))
+	}
+
+	var key eKey
+
+	copy(key[:], []byte{0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18})
+
+	location, ok := s.locations[key]
+	if !ok {
+		t.Fatal("the first entry is not in the index")
+	}
+
+	if location.archive != 0 || location.offset != 0 || location.size != dataHeaderSize+53 {
+		t.Errorf("the first entry is at %+v", location)
+	}
+
+	data, err := s.readFile(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "hello from casc\n" {
+		t.Errorf("the first entry is %q", data)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/Data/Global/Test.txt", want: "hello from casc\n"},
+		{path: "/data/compressed.bin", want: "plain chunk|" + strings.Repeat("zlib compressed chunk ", 8)},
+		{path: "/data/split.bin", want: "first span,second span"},
+		{path: "/readme.txt", want: "single chunk without a table"},
+	}
+
+	c := openFixture(t)
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if !c.Exists(test.path) {
+				t.Fatal("the file does not exist")
+			}
+
+			reader, err := c.Load(test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer reader.Close()
+
+			data, err := ioutil.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(data) != test.want {
+				t.Errorf("the file is %q, want %q", data, test.want)
+			}
+		})
+	}
+}
+
+func TestCaseInsensitivePaths(t *testing.T) {
+	c := openFixture(t)
+
+	for _, path := range []string{"/data/global/test.txt", "/DATA/GLOBAL/TEST.TXT", "data\\Global\\test.TXT"} {
+		if !c.Exists(path) {
+			t.Errorf("%s does not exist", path)
+			continue
+		}
+
+		if _, err := c.Load(path); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
+
+func TestMissingFile(t *testing.T) {
+	c := openFixture(t)
+
+	for _, path := range []string{"", "/nothing.txt", "/data", "/data/global"} {
+		if c.Exists(path) {
+			t.Errorf("%q exists", path)
+		}
+
+		if _, err := c.Load(path); err == nil {
+			t.Errorf("%q was loaded", path)
+		}
+	}
+
+	// missing.txt is listed in the TVFS root, but its data is not in the index
+	if _, err := c.Load("/missing.txt"); err == nil || !strings.Contains(err.Error(), "not in any index") {
+		t.Errorf("loading a file without data returned %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	c := openFixture(t)
+
+	names, err := c.List("/data")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(names) != 3 {
+		t.Errorf("listed %q", names)
+	}
+}
+
+func TestDecodeBLTE(t *testing.T) {
+	var compressed bytes.Buffer
+
+	w := zlib.NewWriter(&compressed)
+	_, _ = w.Write([]byte("zlib"))
+	_ = w.Close()
+
+	chunks := [][2][]byte{
+		{append([]byte{'N'}, "plain,"...), []byte("plain,")},
+		{append([]byte{'Z'}, compressed.Bytes()...), []byte("zlib")},
+	}
+
+	table := func(decodedSizes ...int) []byte {
+		var result bytes.Buffer
+
+		result.WriteString(blteMagic)
+		_ = binary.Write(&result, binary.BigEndian, uint32(12+(24*len(chunks))))
+		_ = binary.Write(&result, binary.BigEndian, uint32(0x0F000000|len(chunks)))
+
+		for idx, chunk := range chunks {
+			_ = binary.Write(&result, binary.BigEndian, uint32(len(chunk[0])))
+			_ = binary.Write(&result, binary.BigEndian, uint32(decodedSizes[idx]))
+			result.Write(make([]byte, 16))
+		}
+
+		for _, chunk := range chunks {
+			result.Write(chunk[0])
+		}
+
+		return result.Bytes()
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+		err  string
+	}{
+		{name: "plain and zlib chunks", data: table(6, 4), want: []byte("plain,zlib")},
+		{name: "single chunk", data: append([]byte("BLTE\x00\x00\x00\x00"), chunks[1][0]...), want: []byte("zlib")},
+		{
+			name: "nested chunk",
+			data: append([]byte("BLTE\x00\x00\x00\x00F"), append([]byte("BLTE\x00\x00\x00\x00"), chunks[0][0]...)...),
+			want: []byte("plain,"),
+		},
+		{name: "wrong decoded size", data: table(6, 5), err: "decoded to 4 bytes"},
+		{name: "truncated", data: table(6, 4)[:60], err: "truncated"},
+		{name: "missing signature", data: []byte("ELTB\x00\x00\x00\x00N"), err: "signature"},
+		{name: "encrypted chunk", data: []byte("BLTE\x00\x00\x00\x00E"), err: "encrypted"},
+		{name: "unknown encoding", data: []byte("BLTE\x00\x00\x00\x004"), err: "unsupported"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := decodeBLTE(test.data)
+
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("error is %v, want one containing %q", err, test.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(data, test.want) {
+				t.Errorf("decoded %q, want %q", data, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package cascloader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// eKeySize is the number of encoding key bytes stored in the index files
+	eKeySize = 9
+
+	// dataHeaderSize is the size of the header in front of every file in a data.NNN archive
+	dataHeaderSize = 30
+
+	indexVersion = 7
+	bucketCount  = 16
+)
+
+type eKey [eKeySize]byte
+
+type dataLocation struct {
+	archive int
+	offset  int64
+	size    uint32
+}
+
+type storage struct {
+	basePath   string
+	dataPath   string
+	vfsRootKey eKey
+	locations  map[eKey]dataLocation
+}
+
+func openStorage(basePath string) (*storage, error) {
+	result := &storage{
+		basePath:  basePath,
+		dataPath:  path.Join(basePath, "Data"),
+		locations: make(map[eKey]dataLocation),
+	}
+
+	buildKey, err := readBuildInfo(path.Join(basePath, ".build.info"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	buildConfig, err := readConfig(path.Join(result.dataPath, "config", buildKey[0:2], buildKey[2:4], buildKey))
+
+	if err != nil {
+		return nil, err
+	}
+
+	vfsRoot := strings.Fields(buildConfig["vfs-root"])
+
+	if len(vfsRoot) != 2 {
+		return nil, errors.New("build config does not define a vfs root")
+	}
+
+	key, err := parseEKey(vfsRoot[1])
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.vfsRootKey = key
+
+	if err := result.readIndexes(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// readBuildInfo returns the build key of the active build listed in the .build.info file.
+func readBuildInfo(fileName string) (string, error) {
+	file, err := os.Open(fileName)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	buildKeyColumn := -1
+	activeColumn := -1
+	buildKey := ""
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		fields := strings.Split(line, "|")
+
+		if buildKeyColumn < 0 {
+			for idx, field := range fields {
+				switch strings.SplitN(field, "!", 2)[0] {
+				case "Build Key":
+					buildKeyColumn = idx
+				case "Active":
+					activeColumn = idx
+				}
+			}
+
+			if buildKeyColumn < 0 {
+				return "", fmt.Errorf("%s does not have a build key column", fileName)
+			}
+
+			continue
+		}
+
+		if buildKeyColumn >= len(fields) {
+			continue
+		}
+
+		// prefer the active build, but fall back to the first one listed
+		if activeColumn < 0 || activeColumn >= len(fields) || fields[activeColumn] == "1" {
+			buildKey = fields[buildKeyColumn]
+			break
+		}
+
+		if len(buildKey) == 0 {
+			buildKey = fields[buildKeyColumn]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if len(buildKey) < 4 {
+		return "", fmt.Errorf("%s does not list a build", fileName)
+	}
+
+	return strings.ToLower(buildKey), nil
+}
+
+// readConfig reads a "key = value" style config file.
+func readConfig(fileName string) (map[string]string, error) {
+	file, err := os.Open(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	result := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+
+		if len(parts) != 2 {
+			continue
+		}
+
+		result[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return result, scanner.Err()
+}
+
+// readIndexes loads the newest version of each of the index buckets in the data directory.
+func (s *storage) readIndexes() error {
+	dataDir := path.Join(s.dataPath, "data")
+
+	entries, err := ioutil.ReadDir(dataDir)
+
+	if err != nil {
+		return err
+	}
+
+	newest := make(map[int]string)
+	versions := make(map[int]uint64)
+
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+
+		if entry.IsDir() || !strings.HasSuffix(name, ".idx") || len(name) != 14 {
+			continue
+		}
+
+		bucket, err := strconv.ParseUint(name[0:2], 16, 8)
+
+		if err != nil || bucket >= bucketCount {
+			continue
+		}
+
+		version, err := strconv.ParseUint(name[2:10], 16, 32)
+
+		if err != nil {
+			continue
+		}
+
+		if _, ok := newest[int(bucket)]; !ok || version > versions[int(bucket)] {
+			newest[int(bucket)] = entry.Name()
+			versions[int(bucket)] = version
+		}
+	}
+
+	if len(newest) == 0 {
+		return fmt.Errorf("no index files found in %s", dataDir)
+	}
+
+	buckets := make([]int, 0, len(newest))
+
+	for bucket := range newest {
+		buckets = append(buckets, bucket)
+	}
+
+	sort.Ints(buckets)
+
+	for _, bucket := range buckets {
+		if err := s.readIndex(path.Join(dataDir, newest[bucket])); err != nil {
+			return fmt.Errorf("could not read index %s: %v", newest[bucket], err)
+		}
+	}
+
+	return nil
+}
+
+func (s *storage) readIndex(fileName string) error {
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		return err
+	}
+
+	if len(data) < 8 {
+		return io.ErrUnexpectedEOF
+	}
+
+	headerSize := int(binary.LittleEndian.Uint32(data[0:4]))
+	headerEnd := 8 + headerSize
+
+	if headerSize < 16 || len(data) < headerEnd {
+		return io.ErrUnexpectedEOF
+	}
+
+	header := data[8:headerEnd]
+
+	if version := binary.LittleEndian.Uint16(header[0:2]); version != indexVersion {
+		return fmt.Errorf("unsupported index version %d", version)
+	}
+
+	sizeBytes := int(header[4])
+	offsetBytes := int(header[5])
+	keyBytes := int(header[6])
+	offsetBits := uint(header[7])
+
+	if keyBytes != eKeySize || offsetBytes > 8 || sizeBytes > 4 {
+		return errors.New("unsupported index entry layout")
+	}
+
+	// the entries block is aligned to 16 bytes and starts with its own size and hash
+	entriesStart := (headerEnd + 0x0F) &^ 0x0F
+
+	if len(data) < entriesStart+8 {
+		return io.ErrUnexpectedEOF
+	}
+
+	entriesSize := int(binary.LittleEndian.Uint32(data[entriesStart : entriesStart+4]))
+	entries := data[entriesStart+8:]
+
+	if len(entries) < entriesSize {
+		return io.ErrUnexpectedEOF
+	}
+
+	entrySize := keyBytes + offsetBytes + sizeBytes
+	offsetMask := uint64(1)<<offsetBits - 1
+
+	for pos := 0; pos+entrySize <= entriesSize; pos += entrySize {
+		entry := entries[pos : pos+entrySize]
+
+		var key eKey
+
+		copy(key[:], entry[0:keyBytes])
+
+		if _, ok := s.locations[key]; ok {
+			continue
+		}
+
+		archiveOffset := uint64(0)
+
+		for _, b := range entry[keyBytes : keyBytes+offsetBytes] {
+			archiveOffset = archiveOffset<<8 | uint64(b)
+		}
+
+		size := uint32(0)
+
+		for idx, b := range entry[keyBytes+offsetBytes : entrySize] {
+			size |= uint32(b) << (8 * uint(idx))
+		}
+
+		s.locations[key] = dataLocation{
+			archive: int(archiveOffset >> offsetBits),
+			offset:  int64(archiveOffset & offsetMask),
+			size:    size,
+		}
+	}
+
+	return nil
+}
+
+// readFile reads and decodes the file stored under the given encoding key.
+func (s *storage) readFile(key eKey) ([]byte, error) {
+	location, ok := s.locations[key]
+
+	if !ok {
+		return nil, fmt.Errorf("encoding key %s is not in any index", hex.EncodeToString(key[:]))
+	}
+
+	if location.size < dataHeaderSize {
+		return nil, fmt.Errorf("encoding key %s has an invalid size", hex.EncodeToString(key[:]))
+	}
+
+	file, err := os.Open(path.Join(s.dataPath, "data", fmt.Sprintf("data.%03d", location.archive)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	data := make([]byte, location.size-dataHeaderSize)
+
+	if _, err := file.ReadAt(data, location.offset+dataHeaderSize); err != nil {
+		return nil, err
+	}
+
+	return decodeBLTE(data)
+}
+
+func parseEKey(value string) (eKey, error) {
+	var result eKey
+
+	data, err := hex.DecodeString(value)
+
+	if err != nil {
+		return result, err
+	}
+
+	if len(data) < eKeySize {
+		return result, fmt.Errorf("encoding key %s is too short", value)
+	}
+
+	copy(result[:], data)
+
+	return result, nil
+}
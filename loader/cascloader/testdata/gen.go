@@ -0,0 +1,246 @@
+//go:build ignore
+// +build ignore
+
+// gen writes the synthetic CASC storage in testdata/casc that the loader tests read. Run it from this directory with
+// `go run gen.go`.
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	buildKey = "0123456789abcdef0123456789abcdef"
+	eKeySize = 9
+)
+
+// entry is a file stored in data.000.
+type entry struct {
+	key  []byte
+	blte []byte
+}
+
+type chunk struct {
+	encoding byte
+	data     []byte
+}
+
+// blte encodes chunks. Without a chunk table, only the first chunk is encoded.
+func blte(withTable bool, chunks ...chunk) []byte {
+	encoded := make([][]byte, len(chunks))
+
+	for idx, c := range chunks {
+		switch c.encoding {
+		case 'N':
+			encoded[idx] = append([]byte{'N'}, c.data...)
+		case 'Z':
+			var buf bytes.Buffer
+
+			w := zlib.NewWriter(&buf)
+			_, _ = w.Write(c.data)
+			_ = w.Close()
+
+			encoded[idx] = append([]byte{'Z'}, buf.Bytes()...)
+		}
+	}
+
+	var result bytes.Buffer
+
+	result.WriteString("BLTE")
+
+	if !withTable {
+		_ = binary.Write(&result, binary.BigEndian, uint32(0))
+		result.Write(encoded[0])
+
+		return result.Bytes()
+	}
+
+	_ = binary.Write(&result, binary.BigEndian, uint32(12+(24*len(chunks))))
+	_ = binary.Write(&result, binary.BigEndian, uint32(0x0F000000|len(chunks)))
+
+	for idx, c := range chunks {
+		_ = binary.Write(&result, binary.BigEndian, uint32(len(encoded[idx])))
+		_ = binary.Write(&result, binary.BigEndian, uint32(len(c.data)))
+		result.Write(make([]byte, 16))
+	}
+
+	for _, e := range encoded {
+		result.Write(e)
+	}
+
+	return result.Bytes()
+}
+
+func key(n byte) []byte {
+	result := make([]byte, 16)
+
+	for idx := range result {
+		result[idx] = n + byte(idx)
+	}
+
+	return result
+}
+
+// pathEntry writes a path table entry: an optional leading separator, a name and a node value.
+func pathEntry(buf *bytes.Buffer, separator bool, name string, value uint32) {
+	if separator {
+		buf.WriteByte(0)
+	}
+
+	buf.WriteByte(byte(len(name)))
+	buf.WriteString(name)
+	buf.WriteByte(0xFF)
+	_ = binary.Write(buf, binary.BigEndian, value)
+}
+
+// tvfs builds a TVFS root. Each file has one span for each of its keys.
+func tvfs() []byte {
+	var cft, vfs bytes.Buffer
+
+	cftOffsets := make(map[string]int)
+
+	for _, k := range [][]byte{key(0x10), key(0x20), key(0x30), key(0x40), key(0x50), key(0x60)} {
+		cftOffsets[string(k)] = cft.Len()
+		cft.Write(k[:eKeySize])
+	}
+
+	vfsEntry := func(keys ...[]byte) uint32 {
+		offset := uint32(vfs.Len())
+
+		vfs.WriteByte(byte(len(keys)))
+
+		for _, k := range keys {
+			_ = binary.Write(&vfs, binary.BigEndian, uint32(0))
+			_ = binary.Write(&vfs, binary.BigEndian, uint32(0))
+			vfs.WriteByte(byte(cftOffsets[string(k)]))
+		}
+
+		return offset
+	}
+
+	var folder, paths bytes.Buffer
+
+	pathEntry(&folder, true, "compressed.bin", vfsEntry(key(0x20)))
+	pathEntry(&folder, true, "split.bin", vfsEntry(key(0x30), key(0x40)))
+
+	pathEntry(&paths, false, "Data/Global/Test.txt", vfsEntry(key(0x10)))
+	pathEntry(&paths, false, "data", 0x80000000|uint32(folder.Len()+4))
+	paths.Write(folder.Bytes())
+	pathEntry(&paths, false, "readme.txt", vfsEntry(key(0x50)))
+	pathEntry(&paths, false, "missing.txt", vfsEntry(key(0x60)))
+
+	const headerSize = 38
+
+	var result bytes.Buffer
+
+	result.WriteString("TVFS")
+	result.Write([]byte{1, headerSize, eKeySize, eKeySize})
+	_ = binary.Write(&result, binary.BigEndian, uint32(0))
+
+	pathOffset := uint32(headerSize)
+	vfsOffset := pathOffset + uint32(paths.Len())
+	cftOffset := vfsOffset + uint32(vfs.Len())
+
+	for _, v := range []uint32{pathOffset, uint32(paths.Len()), vfsOffset, uint32(vfs.Len()), cftOffset,
+		uint32(cft.Len())} {
+		_ = binary.Write(&result, binary.BigEndian, v)
+	}
+
+	_ = binary.Write(&result, binary.BigEndian, uint16(2))
+	result.Write(paths.Bytes())
+	result.Write(vfs.Bytes())
+	result.Write(cft.Bytes())
+
+	return result.Bytes()
+}
+
+// index builds a version 7 index of the entries, which are stored one after the other in data.000.
+func index(entries []entry) []byte {
+	const (
+		offsetBytes = 5
+		sizeBytes   = 4
+		offsetBits  = 30
+		headerSize  = 16
+	)
+
+	var list bytes.Buffer
+
+	offset := 0
+
+	for _, e := range entries {
+		list.Write(e.key[:eKeySize])
+
+		location := uint64(offset)
+		for idx := offsetBytes - 1; idx >= 0; idx-- {
+			list.WriteByte(byte(location >> (8 * uint(idx))))
+		}
+
+		_ = binary.Write(&list, binary.LittleEndian, uint32(30+len(e.blte)))
+		offset += 30 + len(e.blte)
+	}
+
+	var result bytes.Buffer
+
+	_ = binary.Write(&result, binary.LittleEndian, uint32(headerSize))
+	_ = binary.Write(&result, binary.LittleEndian, uint32(0))
+	_ = binary.Write(&result, binary.LittleEndian, uint16(7))
+	result.Write([]byte{0, 0, sizeBytes, offsetBytes, eKeySize, offsetBits})
+	_ = binary.Write(&result, binary.LittleEndian, uint64(1<<offsetBits))
+	result.Write(make([]byte, 8))
+	_ = binary.Write(&result, binary.LittleEndian, uint32(list.Len()))
+	_ = binary.Write(&result, binary.LittleEndian, uint32(0))
+	result.Write(list.Bytes())
+
+	return result.Bytes()
+}
+
+func write(name string, data []byte) {
+	name = filepath.Join("casc", name)
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		panic(err)
+	}
+
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		panic(err)
+	}
+}
+
+func main() {
+	compressed := bytes.Repeat([]byte("zlib compressed chunk "), 8)
+	rootKey := key(0x70)
+
+	// the key of missing.txt (0x60) is left out of the index
+	entries := []entry{
+		{key: key(0x10), blte: blte(true, chunk{'N', []byte("hello from casc\n")})},
+		{key: key(0x20), blte: blte(true, chunk{'N', []byte("plain chunk|")}, chunk{'Z', compressed})},
+		{key: key(0x30), blte: blte(true, chunk{'Z', []byte("first span,")})},
+		{key: key(0x40), blte: blte(true, chunk{'N', []byte("second span")})},
+		{key: key(0x50), blte: blte(false, chunk{'N', []byte("single chunk without a table")})},
+		{key: rootKey, blte: blte(true, chunk{'N', tvfs()})},
+	}
+
+	var data bytes.Buffer
+
+	for _, e := range entries {
+		data.Write(make([]byte, 30))
+		data.Write(e.blte)
+	}
+
+	write(".build.info", []byte("Branch!STRING:0|Active!DEC:1|Build Key!HEX:16\n"+
+		"us|0|ffffffffffffffffffffffffffffffff\n"+
+		"us|1|"+buildKey+"\n"))
+	write(filepath.Join("Data", "config", buildKey[0:2], buildKey[2:4], buildKey),
+		[]byte(fmt.Sprintf("# Build Configuration\n\nvfs-root = %s %s\n", hex.EncodeToString(key(0x80)),
+			hex.EncodeToString(rootKey))))
+	write(filepath.Join("Data", "data", "0000000001.idx"), index(entries))
+	// an older version of the same bucket, which is ignored
+	write(filepath.Join("Data", "data", "0000000000.idx"), []byte("stale"))
+	write(filepath.Join("Data", "data", "data.000"), data.Bytes())
+}
@@ -0,0 +1,256 @@
+package cascloader
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	tvfsMagic        = "TVFS"
+	tvfsHeaderSize   = 38
+	tvfsFolderNode   = 0x80000000
+	tvfsFolderSize   = 0x7FFFFFFF
+	tvfsNodeValue    = 0xFF
+	tvfsMaxSpanCount = 224
+)
+
+type fileSpan struct {
+	eKey eKey
+}
+
+type tvfsHeader struct {
+	eKeySize        int
+	pathTableOffset uint32
+	pathTableSize   uint32
+	vfsTableOffset  uint32
+	vfsTableSize    uint32
+	cftTableOffset  uint32
+	cftTableSize    uint32
+}
+
+type tvfs struct {
+	header     tvfsHeader
+	data       []byte
+	cftOffsLen int
+	files      map[string][]fileSpan
+}
+
+type pathEntry struct {
+	name     string
+	hasValue bool
+	value    uint32
+}
+
+// parseTVFS reads a TVFS directory and returns the encoding key spans for every file in it.
+func parseTVFS(data []byte) (map[string][]fileSpan, error) {
+	if len(data) < tvfsHeaderSize || string(data[0:4]) != tvfsMagic {
+		return nil, errors.New("missing TVFS signature")
+	}
+
+	result := &tvfs{
+		header: tvfsHeader{
+			eKeySize:        int(data[6]),
+			pathTableOffset: binary.BigEndian.Uint32(data[12:16]),
+			pathTableSize:   binary.BigEndian.Uint32(data[16:20]),
+			vfsTableOffset:  binary.BigEndian.Uint32(data[20:24]),
+			vfsTableSize:    binary.BigEndian.Uint32(data[24:28]),
+			cftTableOffset:  binary.BigEndian.Uint32(data[28:32]),
+			cftTableSize:    binary.BigEndian.Uint32(data[32:36]),
+		},
+		data:  data,
+		files: make(map[string][]fileSpan),
+	}
+
+	if result.header.eKeySize < eKeySize {
+		return nil, fmt.Errorf("unsupported TVFS encoding key size %d", result.header.eKeySize)
+	}
+
+	for _, table := range [][2]uint32{
+		{result.header.pathTableOffset, result.header.pathTableSize},
+		{result.header.vfsTableOffset, result.header.vfsTableSize},
+		{result.header.cftTableOffset, result.header.cftTableSize},
+	} {
+		if uint64(table[0])+uint64(table[1]) > uint64(len(data)) {
+			return nil, errors.New("TVFS table is out of bounds")
+		}
+	}
+
+	result.cftOffsLen = offsetFieldSize(result.header.cftTableSize)
+
+	pathTable := data[result.header.pathTableOffset : result.header.pathTableOffset+result.header.pathTableSize]
+
+	if err := result.parsePathTable(pathTable, ""); err != nil {
+		return nil, err
+	}
+
+	return result.files, nil
+}
+
+func (t *tvfs) parsePathTable(data []byte, prefix string) error {
+	for len(data) > 0 {
+		entry, rest, err := readPathEntry(data)
+
+		if err != nil {
+			return err
+		}
+
+		name := joinPath(prefix, entry.name)
+
+		// entries without a value share their name as a prefix with the entry that follows them
+		for !entry.hasValue {
+			if len(rest) == 0 {
+				return errors.New("TVFS path table ends in the middle of a name")
+			}
+
+			if entry, rest, err = readPathEntry(rest); err != nil {
+				return err
+			}
+
+			name = joinPath(name, entry.name)
+		}
+
+		if entry.value&tvfsFolderNode != 0 {
+			// the folder size includes the node value itself
+			size := int(entry.value&tvfsFolderSize) - 4
+
+			if size < 0 || size > len(rest) {
+				return fmt.Errorf("TVFS folder \"%s\" is out of bounds", name)
+			}
+
+			if err := t.parsePathTable(rest[:size], name); err != nil {
+				return err
+			}
+
+			data = rest[size:]
+
+			continue
+		}
+
+		spans, err := t.readSpans(entry.value)
+
+		if err != nil {
+			return fmt.Errorf("TVFS file \"%s\": %v", name, err)
+		}
+
+		t.files[name] = spans
+		data = rest
+	}
+
+	return nil
+}
+
+// readPathEntry reads a single path table entry: an optional leading separator, the name fragment, an optional
+// trailing separator and, if the entry ends a name, the node value.
+func readPathEntry(data []byte) (pathEntry, []byte, error) {
+	result := pathEntry{}
+	separatorBefore := false
+	separatorAfter := false
+
+	if len(data) > 0 && data[0] == 0 {
+		separatorBefore = true
+		data = data[1:]
+	}
+
+	if len(data) > 0 && data[0] != tvfsNodeValue {
+		length := int(data[0])
+
+		if 1+length > len(data) {
+			return result, nil, errors.New("TVFS path name is out of bounds")
+		}
+
+		result.name = string(data[1 : 1+length])
+		data = data[1+length:]
+	}
+
+	if len(data) > 0 && data[0] == 0 {
+		separatorAfter = true
+		data = data[1:]
+	}
+
+	if len(data) > 0 {
+		if data[0] == tvfsNodeValue {
+			if len(data) < 5 {
+				return result, nil, errors.New("TVFS node value is out of bounds")
+			}
+
+			result.hasValue = true
+			result.value = binary.BigEndian.Uint32(data[1:5])
+			data = data[5:]
+		} else {
+			// another name fragment follows directly, so this one is a directory
+			separatorAfter = true
+		}
+	}
+
+	if separatorBefore {
+		result.name = "/" + result.name
+	}
+
+	if separatorAfter {
+		result.name += "/"
+	}
+
+	return result, data, nil
+}
+
+func joinPath(prefix, name string) string {
+	if strings.HasSuffix(prefix, "/") && strings.HasPrefix(name, "/") {
+		return prefix + name[1:]
+	}
+
+	return prefix + name
+}
+
+func (t *tvfs) readSpans(offset uint32) ([]fileSpan, error) {
+	if offset >= t.header.vfsTableSize {
+		return nil, errors.New("vfs entry is out of bounds")
+	}
+
+	vfs := t.data[t.header.vfsTableOffset+offset : t.header.vfsTableOffset+t.header.vfsTableSize]
+	spanCount := int(vfs[0])
+
+	if spanCount == 0 || spanCount > tvfsMaxSpanCount {
+		return nil, fmt.Errorf("unsupported span count %d", spanCount)
+	}
+
+	spanSize := 8 + t.cftOffsLen
+
+	if 1+(spanCount*spanSize) > len(vfs) {
+		return nil, errors.New("vfs spans are out of bounds")
+	}
+
+	result := make([]fileSpan, spanCount)
+	cft := t.data[t.header.cftTableOffset : t.header.cftTableOffset+t.header.cftTableSize]
+
+	for idx := range result {
+		span := vfs[1+(idx*spanSize) : 1+((idx+1)*spanSize)]
+		cftOffset := 0
+
+		for _, b := range span[8:] {
+			cftOffset = cftOffset<<8 | int(b)
+		}
+
+		if cftOffset+t.header.eKeySize > len(cft) {
+			return nil, errors.New("content file table entry is out of bounds")
+		}
+
+		copy(result[idx].eKey[:], cft[cftOffset:cftOffset+eKeySize])
+	}
+
+	return result, nil
+}
+
+func offsetFieldSize(tableSize uint32) int {
+	switch {
+	case tableSize > 0xFFFFFF:
+		return 4
+	case tableSize > 0xFFFF:
+		return 3
+	case tableSize > 0xFF:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -2,8 +2,12 @@ package filesystemloader
 
 import (
 	"io"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
+
+	"github.com/OpenDiablo2/AbyssEngine/loader"
 )
 
 type FileSystemLoader struct {
@@ -24,6 +28,46 @@ func (f *FileSystemLoader) Load(p string) (io.ReadSeekCloser, error) {
 	return os.Open(path.Join(f.basePath, p))
 }
 
+// List walks the directory tree under the base path.
+func (f *FileSystemLoader) List(prefix string) ([]string, error) {
+	result := make([]string, 0)
+
+	err := filepath.WalkDir(f.basePath, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(f.basePath, p)
+
+		if err != nil {
+			return err
+		}
+
+		relPath = filepath.ToSlash(relPath)
+
+		if entry.IsDir() {
+			// skip directories that cannot contain a match
+			if relPath != "." && !loader.HasPathPrefix(relPath+"/", prefix) && !loader.HasPathPrefix(prefix, relPath+"/") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if loader.HasPathPrefix(relPath, prefix) {
+			result = append(result, relPath)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func New(basePath string) *FileSystemLoader {
 	result := &FileSystemLoader{
 		basePath: basePath,
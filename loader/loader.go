@@ -4,20 +4,39 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"path"
+	"sort"
 	"strings"
 
 	"github.com/OpenDiablo2/AbyssEngine/common"
 )
 
+type mount struct {
+	name     string
+	provider LoaderProvider
+}
+
+// Conflict is a path that more than one mount supplies. Sources are listed in lookup order, so the first one wins.
+type Conflict struct {
+	Path    string   `json:"path"`
+	Sources []string `json:"sources"`
+}
+
 type Loader struct {
 	sysLanguageProvider common.SysLanguageProvider
 	providers           []LoaderProvider
+	mods                []*Mod
+	mounts              []mount
+	sources             map[string]string
 }
 
 func New(sysLanguageProvider common.SysLanguageProvider) *Loader {
 	result := &Loader{
 		sysLanguageProvider: sysLanguageProvider,
 		providers:           make([]LoaderProvider, 0),
+		mods:                make([]*Mod, 0),
+		mounts:              make([]mount, 0),
+		sources:             make(map[string]string),
 	}
 
 	return result
@@ -25,6 +44,82 @@ func New(sysLanguageProvider common.SysLanguageProvider) *Loader {
 
 func (l *Loader) AddProvider(provider LoaderProvider) {
 	l.providers = append(l.providers, provider)
+	l.updateMounts()
+}
+
+// MountMods replaces the mounted mods. Mods are mounted in dependency order, and each mod overrides the files of the
+// mods mounted before it as well as those of every provider added with AddProvider. Mods that cannot be mounted are
+// reported in the returned errors.
+//
+// The loader owns the providers of the mods passed in: those of the mods that cannot be mounted are closed right away,
+// and those of the mods that were mounted before and are not any more are closed too.
+func (l *Loader) MountMods(mods []*Mod) []error {
+	sorted, errs := SortMods(mods)
+	mounted := make(map[*Mod]bool, len(sorted))
+
+	for _, mod := range sorted {
+		mounted[mod] = true
+	}
+
+	for _, mod := range append(l.mods, mods...) {
+		if mounted[mod] {
+			continue
+		}
+
+		if err := closeProvider(mod.Provider); err != nil {
+			errs = append(errs, fmt.Errorf("could not close mod %s: %v", mod.Name, err))
+		}
+
+		mounted[mod] = true
+	}
+
+	l.mods = sorted
+	l.updateMounts()
+
+	return errs
+}
+
+// Close closes every provider and mod that holds files open, such as zip archives. The loader cannot be used
+// afterwards.
+func (l *Loader) Close() error {
+	var firstErr error
+
+	for _, m := range l.mounts {
+		if err := closeProvider(m.provider); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("could not close %s: %v", m.name, err)
+		}
+	}
+
+	l.providers = nil
+	l.mods = nil
+	l.mounts = nil
+
+	return firstErr
+}
+
+func closeProvider(provider LoaderProvider) error {
+	if closer, ok := provider.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// Mods returns the mounted mods in mount order.
+func (l *Loader) Mods() []*Mod {
+	return l.mods
+}
+
+func (l *Loader) updateMounts() {
+	l.mounts = make([]mount, 0, len(l.mods)+len(l.providers))
+
+	for idx := len(l.mods) - 1; idx >= 0; idx-- {
+		l.mounts = append(l.mounts, mount{name: l.mods[idx].String(), provider: l.mods[idx].Provider})
+	}
+
+	for _, provider := range l.providers {
+		l.mounts = append(l.mounts, mount{name: provider.Name(), provider: provider})
+	}
 }
 
 func (l *Loader) Load(path string) (io.ReadSeekCloser, error) {
@@ -32,22 +127,216 @@ func (l *Loader) Load(path string) (io.ReadSeekCloser, error) {
 		return nil, errors.New("blank path provided")
 	}
 
-	path = strings.ReplaceAll(path, "\\", "/")
+	path = l.resolvePath(path)
 
-	if strings.HasPrefix(path, "/") {
-		path = path[1:]
+	for mountIdx := range l.mounts {
+		if !l.mounts[mountIdx].provider.Exists(path) {
+			continue
+		}
+
+		l.sources[path] = l.mounts[mountIdx].name
+
+		return l.mounts[mountIdx].provider.Load(path)
 	}
 
-	path = strings.ReplaceAll(path, "{LANG}", l.sysLanguageProvider.GetLanguageCode())
-	path = strings.ReplaceAll(path, "{LANG_FONT}", l.sysLanguageProvider.GetLanguageFontCode())
+	return nil, fmt.Errorf("file not found: \"%s\"", path)
+}
+
+// Source returns the name of the mod or provider that supplies the file at the given path.
+func (l *Loader) Source(path string) (string, bool) {
+	path = l.resolvePath(path)
+
+	for mountIdx := range l.mounts {
+		if l.mounts[mountIdx].provider.Exists(path) {
+			return l.mounts[mountIdx].name, true
+		}
+	}
+
+	return "", false
+}
+
+// LoadedSources returns the mod or provider that supplied each file loaded so far, keyed by path.
+func (l *Loader) LoadedSources() map[string]string {
+	result := make(map[string]string, len(l.sources))
+
+	for path, source := range l.sources {
+		result[path] = source
+	}
+
+	return result
+}
+
+// Conflicts returns every path supplied by more than one listable mod or provider, sorted by path.
+func (l *Loader) Conflicts() ([]Conflict, error) {
+	sources := make(map[string]*Conflict)
+
+	for mountIdx := range l.mounts {
+		provider, ok := l.mounts[mountIdx].provider.(ListableProvider)
+
+		if !ok {
+			continue
+		}
+
+		files, err := provider.List("")
+
+		if err != nil {
+			return nil, fmt.Errorf("could not list files in %s: %v", l.mounts[mountIdx].name, err)
+		}
+
+		for _, file := range files {
+			key := strings.ToLower(file)
+
+			// every mod has its own manifest
+			if key == ManifestFileName {
+				continue
+			}
 
-	for providerIdx := range l.providers {
-		if !l.providers[providerIdx].Exists(path) {
+			if _, ok := sources[key]; !ok {
+				sources[key] = &Conflict{Path: file}
+			}
+
+			sources[key].Sources = append(sources[key].Sources, l.mounts[mountIdx].name)
+		}
+	}
+
+	result := make([]Conflict, 0)
+
+	for _, conflict := range sources {
+		if len(conflict.Sources) > 1 {
+			result = append(result, *conflict)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+
+	return result, nil
+}
+
+// List returns the files of every listable provider whose path starts with the prefix, sorted by path. When several
+// providers have the same file, it is listed once, just as Load only ever returns the first one's copy.
+func (l *Loader) List(prefix string) ([]string, error) {
+	prefix = l.resolvePath(prefix)
+	seen := make(map[string]bool)
+	result := make([]string, 0)
+
+	for mountIdx := range l.mounts {
+		provider, ok := l.mounts[mountIdx].provider.(ListableProvider)
+
+		if !ok {
 			continue
 		}
 
-		return l.providers[providerIdx].Load(path)
+		files, err := provider.List(prefix)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not list files in %s: %v", l.mounts[mountIdx].name, err)
+		}
+
+		for _, file := range files {
+			key := strings.ToLower(file)
+
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			result = append(result, file)
+		}
 	}
 
-	return nil, fmt.Errorf("file not found: \"%s\"", path)
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// Glob returns the files matching the pattern, compared case-insensitively. Each segment of the pattern uses the
+// syntax of path.Match, and a segment that is just ** matches any number of directories, so that
+// "data/global/ui/**/*.dc6" matches the DC6 files anywhere under data/global/ui.
+func (l *Loader) Glob(pattern string) ([]string, error) {
+	pattern = strings.ToLower(l.resolvePath(pattern))
+	segments := strings.Split(pattern, "/")
+
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	// only list the files under the part of the pattern that has no wildcards
+	prefix := pattern
+
+	if idx := strings.IndexAny(pattern, "*?["); idx >= 0 {
+		prefix = pattern[:idx]
+	}
+
+	files, err := l.List(prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+
+	for _, file := range files {
+		if matchSegments(segments, strings.Split(strings.ToLower(file), "/")) {
+			result = append(result, file)
+		}
+	}
+
+	return result, nil
+}
+
+// matchSegments reports whether the segments of a path match those of a pattern, where a ** segment matches zero or
+// more path segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for skip := 0; skip <= len(name); skip++ {
+				if matchSegments(pattern[1:], name[skip:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+func (l *Loader) resolvePath(path string) string {
+	path = NormalizePath(path)
+	path = strings.ReplaceAll(path, "{LANG}", l.sysLanguageProvider.GetLanguageCode())
+	path = strings.ReplaceAll(path, "{LANG_FONT}", l.sysLanguageProvider.GetLanguageFontCode())
+
+	return path
+}
+
+// HasPathPrefix reports whether the normalized path starts with the normalized prefix, ignoring case.
+func HasPathPrefix(path, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(NormalizePath(path)), strings.ToLower(NormalizePath(prefix)))
+}
+
+// NormalizePath converts backslashes to forward slashes and strips the leading slash, so that paths can be handed to
+// any of the providers.
+func NormalizePath(path string) string {
+	path = strings.ReplaceAll(path, "\\", "/")
+
+	if strings.HasPrefix(path, "/") {
+		path = path[1:]
+	}
+
+	return path
 }
@@ -0,0 +1,348 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testLanguageProvider struct{}
+
+func (testLanguageProvider) GetLanguageCode() string {
+	return "eng"
+}
+
+func (testLanguageProvider) GetLanguageFontCode() string {
+	return "latin"
+}
+
+// memoryProvider is a listable provider that holds the contents of its files, keyed by path.
+type memoryProvider struct {
+	name  string
+	files map[string]string
+}
+
+func (p *memoryProvider) Name() string {
+	return p.name
+}
+
+func (p *memoryProvider) find(path string) (string, bool) {
+	for name := range p.files {
+		if strings.EqualFold(name, NormalizePath(path)) {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *memoryProvider) Exists(path string) bool {
+	_, ok := p.find(path)
+	return ok
+}
+
+func (p *memoryProvider) Load(path string) (io.ReadSeekCloser, error) {
+	name, ok := p.find(path)
+
+	if !ok {
+		return nil, errors.New("file not found")
+	}
+
+	return nopCloser{bytes.NewReader([]byte(p.files[name]))}, nil
+}
+
+func (p *memoryProvider) List(prefix string) ([]string, error) {
+	result := make([]string, 0)
+
+	for name := range p.files {
+		if HasPathPrefix(name, prefix) {
+			result = append(result, name)
+		}
+	}
+
+	return result, nil
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// closingProvider is a provider without files that records whether it was closed.
+type closingProvider struct {
+	closed bool
+}
+
+func (p *closingProvider) Name() string {
+	return "closing provider"
+}
+
+func (p *closingProvider) Exists(_ string) bool {
+	return false
+}
+
+func (p *closingProvider) Load(path string) (io.ReadSeekCloser, error) {
+	return nil, errors.New("no files")
+}
+
+func (p *closingProvider) Close() error {
+	p.closed = true
+	return nil
+}
+
+func newTestMod(name string, dependencies ...string) (*Mod, *closingProvider) {
+	provider := &closingProvider{}
+
+	return &Mod{Manifest: Manifest{Name: name, Dependencies: dependencies}, Provider: provider}, provider
+}
+
+func TestMountModsClosesUnmountedMods(t *testing.T) {
+	l := New(nil)
+
+	first, firstProvider := newTestMod("first")
+	kept, keptProvider := newTestMod("kept")
+	broken, brokenProvider := newTestMod("broken", "missing")
+
+	if errs := l.MountMods([]*Mod{first, kept, broken}); len(errs) != 1 {
+		t.Fatalf("mounting returned %v", errs)
+	}
+
+	if !brokenProvider.closed || firstProvider.closed || keptProvider.closed {
+		t.Fatal("only the mod that could not be mounted should be closed")
+	}
+
+	if errs := l.MountMods([]*Mod{kept}); len(errs) != 0 {
+		t.Fatalf("mounting returned %v", errs)
+	}
+
+	if !firstProvider.closed || keptProvider.closed {
+		t.Fatal("only the mod that is no longer mounted should be closed")
+	}
+
+	provider := &closingProvider{}
+	l.AddProvider(provider)
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !keptProvider.closed || !provider.closed {
+		t.Error("closing the loader did not close its mods and providers")
+	}
+}
+
+func newTestLoader() *Loader {
+	l := New(testLanguageProvider{})
+
+	l.AddProvider(&memoryProvider{name: "patch", files: map[string]string{
+		"data/global/excel/Armor.txt":     "patch",
+		"data/global/ui/panel/inv.dc6":    "patch",
+		"data/local/font/eng/font16.tbl":  "patch",
+		"data/global/ui/CURSOR/ohand.dc6": "patch",
+	}})
+
+	l.AddProvider(&memoryProvider{name: "data", files: map[string]string{
+		"data/global/excel/armor.txt":        "data",
+		"data/global/excel/weapons.txt":      "data",
+		"data/global/ui/cursor/ohand.dc6":    "data",
+		"data/global/ui/loading/loading.dc6": "data",
+	}})
+
+	return l
+}
+
+func TestLoaderOverridesAcrossProviders(t *testing.T) {
+	l := newTestLoader()
+
+	tests := []struct {
+		path   string
+		source string
+	}{
+		{path: "data/global/excel/armor.txt", source: "patch"},
+		{path: "/Data/Global/Excel/ARMOR.txt", source: "patch"},
+		{path: "data\\global\\excel\\weapons.txt", source: "data"},
+		{path: "data/local/font/{LANG}/font16.tbl", source: "patch"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			reader, err := l.Load(test.path)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := io.ReadAll(reader)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(content) != test.source {
+				t.Errorf("loaded the copy of %s, want the one of %s", content, test.source)
+			}
+
+			if source, _ := l.Source(test.path); source != test.source {
+				t.Errorf("the source is %s, want %s", source, test.source)
+			}
+		})
+	}
+}
+
+func TestLoaderListAndGlob(t *testing.T) {
+	l := newTestLoader()
+
+	tests := []struct {
+		name    string
+		pattern string
+		glob    bool
+		want    []string
+	}{
+		{
+			name:    "list merges providers and lists files they share once",
+			pattern: "data/global/excel",
+			want:    []string{"data/global/excel/Armor.txt", "data/global/excel/weapons.txt"},
+		},
+		{
+			name:    "list ignores case",
+			pattern: "\\DATA\\Global\\UI\\Cursor",
+			want:    []string{"data/global/ui/CURSOR/ohand.dc6"},
+		},
+		{
+			name:    "star does not cross directories",
+			pattern: "data/global/ui/*.dc6",
+			glob:    true,
+			want:    []string{},
+		},
+		{
+			name:    "star matches within a directory",
+			pattern: "data/global/ui/*/*.DC6",
+			glob:    true,
+			want: []string{
+				"data/global/ui/CURSOR/ohand.dc6", "data/global/ui/loading/loading.dc6", "data/global/ui/panel/inv.dc6",
+			},
+		},
+		{
+			name:    "double star matches any number of directories",
+			pattern: "data/**/*.dc6",
+			glob:    true,
+			want: []string{
+				"data/global/ui/CURSOR/ohand.dc6", "data/global/ui/loading/loading.dc6", "data/global/ui/panel/inv.dc6",
+			},
+		},
+		{
+			name:    "double star matches no directory",
+			pattern: "data/global/excel/**/weapons.txt",
+			glob:    true,
+			want:    []string{"data/global/excel/weapons.txt"},
+		},
+		{
+			name:    "double star at the end matches everything below",
+			pattern: "data/local/**",
+			glob:    true,
+			want:    []string{"data/local/font/eng/font16.tbl"},
+		},
+		{
+			name:    "language is substituted",
+			pattern: "data/local/font/{LANG}/*.tbl",
+			glob:    true,
+			want:    []string{"data/local/font/eng/font16.tbl"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var files []string
+			var err error
+
+			if test.glob {
+				files, err = l.Glob(test.pattern)
+			} else {
+				files, err = l.List(test.pattern)
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(files, test.want) {
+				t.Errorf("got %v, want %v", files, test.want)
+			}
+		})
+	}
+
+	if _, err := l.Glob("data/[/*.txt"); err == nil {
+		t.Error("a malformed pattern did not return an error")
+	}
+}
+
+func TestLoaderConflictsAndSources(t *testing.T) {
+	l := newTestLoader()
+	hd := &Mod{
+		Manifest: Manifest{Name: "hd", Version: "1.0", Priority: 1},
+		Provider: &memoryProvider{name: "hd", files: map[string]string{
+			ManifestFileName:                  "{}",
+			"data/global/ui/cursor/ohand.dc6": "hd",
+			"data/global/excel/weapons.txt":   "hd",
+		}},
+	}
+	fixes := &Mod{
+		Manifest: Manifest{Name: "fixes"},
+		Provider: &memoryProvider{name: "fixes", files: map[string]string{
+			ManifestFileName:                "{}",
+			"data/global/excel/Weapons.txt": "fixes",
+			"data/global/excel/misc.txt":    "fixes",
+		}},
+	}
+
+	if errs := l.MountMods([]*Mod{hd, fixes}); len(errs) != 0 {
+		t.Fatalf("mounting returned %v", errs)
+	}
+
+	conflicts, err := l.Conflicts()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Conflict{
+		{Path: "data/global/excel/Armor.txt", Sources: []string{"patch", "data"}},
+		{Path: "data/global/excel/weapons.txt", Sources: []string{"hd@1.0", "fixes", "data"}},
+		{Path: "data/global/ui/cursor/ohand.dc6", Sources: []string{"hd@1.0", "patch", "data"}},
+	}
+
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("conflicts are %+v, want %+v", conflicts, want)
+	}
+
+	sources := map[string]string{
+		"data/global/excel/WEAPONS.txt":      "hd@1.0",
+		"data/global/excel/misc.txt":         "fixes",
+		"data/global/excel/armor.txt":        "patch",
+		"data/global/ui/loading/loading.dc6": "data",
+	}
+
+	for path, want := range sources {
+		if source, ok := l.Source(path); !ok || source != want {
+			t.Errorf("the source of %s is %s, want %s", path, source, want)
+		}
+	}
+
+	if _, ok := l.Source("data/global/excel/missing.txt"); ok {
+		t.Error("a missing file has a source")
+	}
+
+	if _, err := l.Load("data/global/excel/misc.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded := l.LoadedSources(); loaded["data/global/excel/misc.txt"] != "fixes" {
+		t.Errorf("loaded sources are %v", loaded)
+	}
+}
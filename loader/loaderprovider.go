@@ -8,3 +8,9 @@ type LoaderProvider interface {
 	Load(path string) (io.ReadSeekCloser, error)
 }
 
+// ListableProvider is implemented by providers that can enumerate the files they contain.
+type ListableProvider interface {
+	// List returns the paths of all files whose normalized path starts with the prefix, compared case-insensitively.
+	// Paths use forward slashes and have no leading slash.
+	List(prefix string) ([]string, error)
+}
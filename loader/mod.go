@@ -0,0 +1,226 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// ManifestFileName is the name of the manifest file at the root of a mod
+const ManifestFileName = "mod.json"
+
+// Manifest describes a mod.
+type Manifest struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	Dependencies []string `json:"dependencies"`
+
+	// Priority decides which of two unrelated mods wins when both supply the same file, the higher one wins
+	Priority int `json:"priority"`
+}
+
+// Mod is a provider mounted along with its manifest.
+type Mod struct {
+	Manifest
+	Path     string
+	Provider LoaderProvider
+}
+
+// String returns the name and version of the mod.
+func (m *Mod) String() string {
+	if len(m.Version) == 0 {
+		return m.Name
+	}
+
+	return fmt.Sprintf("%s@%s", m.Name, m.Version)
+}
+
+// ReadManifest reads the manifest of a mod from its provider. If the mod has no manifest, the manifest is named after
+// the fallback name and has no dependencies.
+func ReadManifest(provider LoaderProvider, fallbackName string) (*Manifest, error) {
+	result := &Manifest{Name: fallbackName}
+
+	if !provider.Exists(ManifestFileName) {
+		return result, nil
+	}
+
+	stream, err := provider.Load(ManifestFileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer stream.Close()
+
+	data, err := ioutil.ReadAll(stream)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", ManifestFileName, err)
+	}
+
+	if len(result.Name) == 0 {
+		result.Name = fallbackName
+	}
+
+	return result, nil
+}
+
+// SortMods orders mods so that every mod comes after its dependencies, and unrelated mods come in ascending priority
+// order (then by name). Mods that have a missing dependency, that are part of a dependency cycle or that depend on such
+// a mod are left out and reported in the returned errors, in a stable order.
+func SortMods(mods []*Mod) ([]*Mod, []error) {
+	errs := make([]error, 0)
+	byName := make(map[string]*Mod)
+
+	for _, mod := range mods {
+		key := strings.ToLower(mod.Name)
+
+		if existing, ok := byName[key]; ok {
+			errs = append(errs, fmt.Errorf("mod %s (%s) is already provided by %s", mod.Name, mod.Path, existing.Path))
+			continue
+		}
+
+		byName[key] = mod
+	}
+
+	// drop mods with missing dependencies until nothing changes, since dropping one can break another
+	for changed := true; changed; {
+		changed = false
+
+		for _, mod := range mods {
+			key := strings.ToLower(mod.Name)
+
+			if byName[key] != mod {
+				continue
+			}
+
+			for _, dependency := range mod.Dependencies {
+				if _, ok := byName[strings.ToLower(dependency)]; ok {
+					continue
+				}
+
+				errs = append(errs, fmt.Errorf("mod %s is missing dependency %s", mod.Name, dependency))
+				delete(byName, key)
+				changed = true
+
+				break
+			}
+		}
+	}
+
+	pending := make([]*Mod, 0, len(byName))
+
+	for _, mod := range byName {
+		pending = append(pending, mod)
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].Priority != pending[j].Priority {
+			return pending[i].Priority < pending[j].Priority
+		}
+
+		return strings.ToLower(pending[i].Name) < strings.ToLower(pending[j].Name)
+	})
+
+	result := make([]*Mod, 0, len(pending))
+	mounted := make(map[string]bool)
+
+	for len(pending) > 0 {
+		next := -1
+
+		for idx, mod := range pending {
+			ready := true
+
+			for _, dependency := range mod.Dependencies {
+				if !mounted[strings.ToLower(dependency)] {
+					ready = false
+					break
+				}
+			}
+
+			if ready {
+				next = idx
+				break
+			}
+		}
+
+		if next < 0 {
+			errs = append(errs, cycleErrors(pending)...)
+			break
+		}
+
+		mounted[strings.ToLower(pending[next].Name)] = true
+		result = append(result, pending[next])
+		pending = append(pending[:next], pending[next+1:]...)
+	}
+
+	return result, errs
+}
+
+// cycleErrors reports the mods that cannot be mounted because of a dependency cycle. Every one of them waits for
+// another, but only those that depend on themselves are part of a cycle, the others are blocked by a dependency.
+func cycleErrors(pending []*Mod) []error {
+	byName := make(map[string]*Mod, len(pending))
+
+	for _, mod := range pending {
+		byName[strings.ToLower(mod.Name)] = mod
+	}
+
+	result := make([]error, 0, len(pending))
+
+	for _, mod := range pending {
+		if dependsOn(byName, mod, mod, make(map[*Mod]bool)) {
+			result = append(result, fmt.Errorf("mod %s is part of a dependency cycle", mod.Name))
+			continue
+		}
+
+		for _, dependency := range mod.Dependencies {
+			blocking, ok := byName[strings.ToLower(dependency)]
+
+			if !ok {
+				continue
+			}
+
+			if dependsOn(byName, blocking, blocking, make(map[*Mod]bool)) {
+				result = append(result, fmt.Errorf("mod %s is blocked by dependency %s, which is part of a dependency cycle",
+					mod.Name, blocking.Name))
+			} else {
+				result = append(result, fmt.Errorf("mod %s is blocked by dependency %s, which cannot be mounted",
+					mod.Name, blocking.Name))
+			}
+
+			break
+		}
+	}
+
+	return result
+}
+
+// dependsOn reports whether the mod depends on the target, directly or through other mods.
+func dependsOn(byName map[string]*Mod, mod, target *Mod, visited map[*Mod]bool) bool {
+	for _, dependency := range mod.Dependencies {
+		next, ok := byName[strings.ToLower(dependency)]
+
+		if !ok || visited[next] {
+			continue
+		}
+
+		if next == target {
+			return true
+		}
+
+		visited[next] = true
+
+		if dependsOn(byName, next, target, visited) {
+			return true
+		}
+	}
+
+	return false
+}
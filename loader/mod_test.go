@@ -0,0 +1,89 @@
+package loader
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortMods(t *testing.T) {
+	mod := func(name string, priority int, dependencies ...string) *Mod {
+		return &Mod{Manifest: Manifest{Name: name, Priority: priority, Dependencies: dependencies}, Path: name + ".zip"}
+	}
+
+	tests := []struct {
+		name   string
+		mods   []*Mod
+		sorted []string
+		errs   []string
+	}{
+		{
+			name:   "priority, then name",
+			mods:   []*Mod{mod("b", 0), mod("high", 1), mod("A", 0), mod("low", -1)},
+			sorted: []string{"low", "A", "b", "high"},
+		},
+		{
+			name:   "dependencies before priority",
+			mods:   []*Mod{mod("ui", 0, "Base"), mod("base", 5), mod("other", 1)},
+			sorted: []string{"other", "base", "ui"},
+		},
+		{
+			name:   "missing dependencies",
+			mods:   []*Mod{mod("d", 0, "c"), mod("c", 0, "missing"), mod("b", 0, "gone"), mod("a", 0)},
+			sorted: []string{"a"},
+			errs: []string{
+				"mod c is missing dependency missing",
+				"mod b is missing dependency gone",
+				"mod d is missing dependency c",
+			},
+		},
+		{
+			name: "cycles",
+			mods: []*Mod{
+				mod("base", 0), mod("x", 0, "y"), mod("y", 0, "x"), mod("needsX", 0, "base", "x"),
+				mod("needsNeedsX", 0, "needsX"), mod("self", 0, "self"),
+			},
+			sorted: []string{"base"},
+			errs: []string{
+				"mod needsNeedsX is blocked by dependency needsX, which cannot be mounted",
+				"mod needsX is blocked by dependency x, which is part of a dependency cycle",
+				"mod self is part of a dependency cycle",
+				"mod x is part of a dependency cycle",
+				"mod y is part of a dependency cycle",
+			},
+		},
+		{
+			name:   "duplicate names",
+			mods:   []*Mod{mod("hd", 0), mod("HD", 0), mod("other", 0, "hd")},
+			sorted: []string{"hd", "other"},
+			errs:   []string{"mod HD (HD.zip) is already provided by hd.zip"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sorted, errs := SortMods(test.mods)
+			names := make([]string, 0)
+			messages := make([]string, 0)
+
+			for _, mod := range sorted {
+				names = append(names, mod.Name)
+			}
+
+			for _, err := range errs {
+				messages = append(messages, err.Error())
+			}
+
+			if test.errs == nil {
+				test.errs = []string{}
+			}
+
+			if !reflect.DeepEqual(names, test.sorted) {
+				t.Errorf("sorted %v, want %v", names, test.sorted)
+			}
+
+			if !reflect.DeepEqual(messages, test.errs) {
+				t.Errorf("errors are %q, want %q", messages, test.errs)
+			}
+		})
+	}
+}
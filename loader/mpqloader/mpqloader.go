@@ -5,11 +5,13 @@ import (
 	"io"
 	"strings"
 
+	"github.com/OpenDiablo2/AbyssEngine/loader"
 	"github.com/OpenDiablo2/mpq"
 )
 
 type MpqLoader struct {
-	mpq *mpq.MPQ
+	mpq      *mpq.MPQ
+	listfile []string
 }
 
 func (m *MpqLoader) Name() string {
@@ -36,6 +38,39 @@ func (m *MpqLoader) Load(path string) (io.ReadSeekCloser, error) {
 	return m.mpq.ReadFileStream(path)
 }
 
+// List returns the matching files named in the archive's (listfile).
+func (m *MpqLoader) List(prefix string) ([]string, error) {
+	if m.listfile == nil {
+		files, err := m.mpq.Listfile()
+
+		if err != nil {
+			return nil, err
+		}
+
+		m.listfile = make([]string, 0, len(files))
+
+		for _, file := range files {
+			file = strings.TrimSpace(file)
+
+			if len(file) == 0 {
+				continue
+			}
+
+			m.listfile = append(m.listfile, loader.NormalizePath(file))
+		}
+	}
+
+	result := make([]string, 0)
+
+	for _, file := range m.listfile {
+		if loader.HasPathPrefix(file, prefix) {
+			result = append(result, file)
+		}
+	}
+
+	return result, nil
+}
+
 func New(fileName string) (*MpqLoader, error) {
 	result := &MpqLoader{}
 
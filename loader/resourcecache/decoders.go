@@ -0,0 +1,102 @@
+package resourcecache
+
+import (
+	"bytes"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2cof"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2ds1"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2dt1"
+	dc6 "github.com/OpenDiablo2/dc6/pkg"
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+	pl2 "github.com/OpenDiablo2/pl2/pkg"
+	tblfont "github.com/OpenDiablo2/tbl_font/pkg"
+)
+
+func decodeBytes(data []byte) (interface{}, int64, error) {
+	return data, int64(len(data)), nil
+}
+
+func decodeDC6(data []byte) (interface{}, int64, error) {
+	result, err := dc6.FromBytes(data)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size := int64(len(data))
+
+	for _, direction := range result.Directions {
+		for _, frame := range direction.Frames {
+			size += int64(len(frame.IndexData))
+		}
+	}
+
+	return result, size, nil
+}
+
+func decodeDCC(data []byte) (interface{}, int64, error) {
+	result, err := dcc.FromBytes(data)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size := int64(len(data))
+
+	for _, direction := range result.Directions() {
+		bounds := direction.Bounds()
+		size += int64(bounds.Dx()*bounds.Dy()) * int64(len(direction.Frames()))
+	}
+
+	return result, size, nil
+}
+
+func decodeCOF(data []byte) (interface{}, int64, error) {
+	result, err := d2cof.Unmarshal(data)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, int64(len(data)), nil
+}
+
+func decodeDS1(data []byte) (interface{}, int64, error) {
+	result, err := d2ds1.Unmarshal(data)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, int64(len(data)), nil
+}
+
+func decodeDT1(data []byte) (interface{}, int64, error) {
+	result, err := d2dt1.LoadDT1(data)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, int64(len(data)), nil
+}
+
+func decodeFontTable(data []byte) (interface{}, int64, error) {
+	result, err := tblfont.Load(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, int64(len(data)), nil
+}
+
+func decodePalette(data []byte) (interface{}, int64, error) {
+	result, err := pl2.FromBytes(data)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, int64(len(data)), nil
+}
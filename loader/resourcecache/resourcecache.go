@@ -0,0 +1,246 @@
+package resourcecache
+
+import (
+	"container/list"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/loader"
+)
+
+// DefaultBudget is the byte budget used when none is configured
+const DefaultBudget = 256 * 1024 * 1024
+
+// Decoder turns the raw bytes of a file into a resource, and reports roughly how many bytes the resource occupies.
+type Decoder func(data []byte) (value interface{}, size int64, err error)
+
+type cacheKey struct {
+	path         string
+	resourceType string
+}
+
+type entry struct {
+	key      cacheKey
+	value    interface{}
+	size     int64
+	refCount int
+	element  *list.Element
+}
+
+// Stats is a snapshot of the cache counters.
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+	Bytes     int64  `json:"bytes"`
+	Budget    int64  `json:"budget"`
+}
+
+// ResourceCache keeps decoded resources keyed by path and type. Resources stay alive while any handle to them is
+// held; unreferenced resources are kept around and evicted least recently used first once the cache is over budget.
+type ResourceCache struct {
+	loaderProvider common.LoaderProvider
+	decoders       map[string]Decoder
+	entries        map[cacheKey]*entry
+	unused         *list.List
+	budget         int64
+	bytes          int64
+	hits           uint64
+	misses         uint64
+	evictions      uint64
+}
+
+// Handle is a reference to a cached resource.
+type Handle struct {
+	cache    *ResourceCache
+	entry    *entry
+	released bool
+}
+
+func (h *Handle) Value() interface{} {
+	return h.entry.value
+}
+
+// Release drops the reference. Releasing a handle more than once has no effect.
+func (h *Handle) Release() {
+	if h.released {
+		return
+	}
+
+	h.released = true
+	h.cache.release(h.entry)
+}
+
+func New(loaderProvider common.LoaderProvider, budget int64) *ResourceCache {
+	if budget <= 0 {
+		budget = DefaultBudget
+	}
+
+	result := &ResourceCache{
+		loaderProvider: loaderProvider,
+		decoders:       make(map[string]Decoder),
+		entries:        make(map[cacheKey]*entry),
+		unused:         list.New(),
+		budget:         budget,
+	}
+
+	result.RegisterDecoder(common.ResourceTypeBytes, decodeBytes)
+	result.RegisterDecoder(common.ResourceTypeDC6, decodeDC6)
+	result.RegisterDecoder(common.ResourceTypeDCC, decodeDCC)
+	result.RegisterDecoder(common.ResourceTypeCOF, decodeCOF)
+	result.RegisterDecoder(common.ResourceTypeDS1, decodeDS1)
+	result.RegisterDecoder(common.ResourceTypeDT1, decodeDT1)
+	result.RegisterDecoder(common.ResourceTypeFontTable, decodeFontTable)
+	result.RegisterDecoder(common.ResourceTypePalette, decodePalette)
+
+	return result
+}
+
+// RegisterDecoder sets the decoder used for the given resource type.
+func (c *ResourceCache) RegisterDecoder(resourceType string, decoder Decoder) {
+	c.decoders[resourceType] = decoder
+}
+
+// Acquire returns a handle to the resource at the given path, loading and decoding it if it is not cached.
+func (c *ResourceCache) Acquire(path string, resourceType string) (common.ResourceHandle, error) {
+	decoder, ok := c.decoders[resourceType]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown resource type: %s", resourceType)
+	}
+
+	key := cacheKey{path: keyPath(path), resourceType: resourceType}
+
+	if e, ok := c.entries[key]; ok {
+		c.hits++
+		c.retain(e)
+
+		return &Handle{cache: c, entry: e}, nil
+	}
+
+	c.misses++
+
+	stream, err := c.loaderProvider.Load(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer stream.Close()
+
+	data, err := ioutil.ReadAll(stream)
+
+	if err != nil {
+		return nil, err
+	}
+
+	value, size, err := decoder(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not decode \"%s\": %v", path, err)
+	}
+
+	e := &entry{
+		key:      key,
+		value:    value,
+		size:     size,
+		refCount: 1,
+	}
+
+	c.entries[key] = e
+	c.bytes += size
+	c.trim()
+
+	return &Handle{cache: c, entry: e}, nil
+}
+
+// Invalidate drops the cached copies of the resource at the given path, of every type. Handles that are still held
+// keep their value, but the next Acquire loads the resource again.
+func (c *ResourceCache) Invalidate(path string) {
+	path = keyPath(path)
+
+	for key, e := range c.entries {
+		if key.path == path {
+			c.remove(e)
+		}
+	}
+}
+
+// keyPath returns the path a resource is cached under. Paths are case insensitive, like those of the loader, so that
+// every spelling of a path shares one entry and is invalidated together.
+func keyPath(path string) string {
+	return strings.ToLower(loader.NormalizePath(path))
+}
+
+// Clear drops every cached resource.
+func (c *ResourceCache) Clear() {
+	for _, e := range c.entries {
+		c.remove(e)
+	}
+}
+
+func (c *ResourceCache) SetBudget(budget int64) {
+	if budget <= 0 {
+		budget = DefaultBudget
+	}
+
+	c.budget = budget
+	c.trim()
+}
+
+func (c *ResourceCache) Stats() Stats {
+	return Stats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Entries:   len(c.entries),
+		Bytes:     c.bytes,
+		Budget:    c.budget,
+	}
+}
+
+func (c *ResourceCache) retain(e *entry) {
+	if e.refCount == 0 && e.element != nil {
+		c.unused.Remove(e.element)
+		e.element = nil
+	}
+
+	e.refCount++
+}
+
+func (c *ResourceCache) release(e *entry) {
+	e.refCount--
+
+	if e.refCount > 0 {
+		return
+	}
+
+	// the entry may have been invalidated while it was still referenced
+	if c.entries[e.key] != e {
+		return
+	}
+
+	e.element = c.unused.PushFront(e)
+	c.trim()
+}
+
+func (c *ResourceCache) remove(e *entry) {
+	if e.element != nil {
+		c.unused.Remove(e.element)
+		e.element = nil
+	}
+
+	delete(c.entries, e.key)
+	c.bytes -= e.size
+}
+
+// trim evicts unreferenced resources, oldest first, until the cache is within its budget.
+func (c *ResourceCache) trim() {
+	for c.bytes > c.budget && c.unused.Len() > 0 {
+		c.remove(c.unused.Back().Value.(*entry))
+		c.evictions++
+	}
+}
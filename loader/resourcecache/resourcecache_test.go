@@ -0,0 +1,60 @@
+package resourcecache
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+)
+
+// countingProvider serves the same bytes for every path, and counts the loads.
+type countingProvider struct {
+	loads int
+}
+
+func (p *countingProvider) Load(_ string) (io.ReadSeekCloser, error) {
+	p.loads++
+
+	return readSeekCloser{bytes.NewReader([]byte("data"))}, nil
+}
+
+type readSeekCloser struct {
+	*bytes.Reader
+}
+
+func (readSeekCloser) Close() error {
+	return nil
+}
+
+func TestPathsAreCaseInsensitive(t *testing.T) {
+	provider := &countingProvider{}
+	c := New(provider, 0)
+
+	for _, path := range []string{"/Data/Global/Test.txt", "data\\global\\test.TXT", "/DATA/GLOBAL/TEST.TXT"} {
+		handle, err := c.Acquire(path, common.ResourceTypeBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handle.Release()
+	}
+
+	if provider.loads != 1 || c.Stats().Entries != 1 {
+		t.Fatalf("loaded %d times into %d entries, want one", provider.loads, c.Stats().Entries)
+	}
+
+	c.Invalidate("/data/GLOBAL/test.txt")
+
+	if c.Stats().Entries != 0 {
+		t.Fatal("the entry was not invalidated")
+	}
+
+	if _, err := c.Acquire("/Data/Global/Test.txt", common.ResourceTypeBytes); err != nil {
+		t.Fatal(err)
+	}
+
+	if provider.loads != 2 {
+		t.Errorf("loaded %d times after invalidating, want 2", provider.loads)
+	}
+}
@@ -0,0 +1,123 @@
+package ziploader
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/OpenDiablo2/AbyssEngine/loader"
+)
+
+// ZipLoader serves files straight out of a zip (or pk3) archive. Lookups are case-insensitive.
+type ZipLoader struct {
+	fileName string
+	archive  *zip.ReadCloser
+	files    map[string]*zip.File
+	names    []string
+}
+
+type fileReader struct {
+	*bytes.Reader
+}
+
+func (f *fileReader) Close() error {
+	return nil
+}
+
+func (z *ZipLoader) Name() string {
+	return "Zip Loader"
+}
+
+func (z *ZipLoader) Exists(path string) bool {
+	if len(path) == 0 {
+		return false
+	}
+
+	_, ok := z.files[normalizePath(path)]
+
+	return ok
+}
+
+func (z *ZipLoader) Load(path string) (io.ReadSeekCloser, error) {
+	file, ok := z.files[normalizePath(path)]
+
+	if !ok {
+		return nil, fmt.Errorf("could not locate file \"%s\" in \"%s\"", path, z.fileName)
+	}
+
+	reader, err := file.Open()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileReader{Reader: bytes.NewReader(data)}, nil
+}
+
+func (z *ZipLoader) List(prefix string) ([]string, error) {
+	result := make([]string, 0)
+
+	for _, name := range z.names {
+		if loader.HasPathPrefix(name, prefix) {
+			result = append(result, name)
+		}
+	}
+
+	return result, nil
+}
+
+// Close closes the archive. No files can be loaded from it afterwards.
+func (z *ZipLoader) Close() error {
+	if z.archive == nil {
+		return nil
+	}
+
+	err := z.archive.Close()
+
+	z.archive = nil
+	z.files = make(map[string]*zip.File)
+	z.names = nil
+
+	return err
+}
+
+func New(fileName string) (*ZipLoader, error) {
+	result := &ZipLoader{
+		fileName: fileName,
+		files:    make(map[string]*zip.File),
+	}
+
+	archive, err := zip.OpenReader(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.archive = archive
+
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		result.files[normalizePath(file.Name)] = file
+		result.names = append(result.names, loader.NormalizePath(file.Name))
+	}
+
+	return result, nil
+}
+
+func normalizePath(path string) string {
+	return strings.ToLower(loader.NormalizePath(path))
+}
@@ -0,0 +1,124 @@
+package ziploader
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	fileName := filepath.Join(t.TempDir(), "test.zip")
+	file, err := os.Create(fileName)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := zip.NewWriter(file)
+
+	for name, content := range files {
+		f, err := w.Create(name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return fileName
+}
+
+func TestLoad(t *testing.T) {
+	z, err := New(writeZip(t, map[string]string{
+		"Data/Global/Test.txt": "hello",
+		// written by tools that keep the separators of Windows
+		"Data\\Local\\Font.tbl": "font",
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer z.Close()
+
+	tests := []struct {
+		path    string
+		content string
+	}{
+		{path: "/data/global/test.txt", content: "hello"},
+		{path: "/DATA/Global/test.TXT", content: "hello"},
+		{path: "data\\global\\test.txt", content: "hello"},
+		{path: "\\Data\\Global\\Test.txt", content: "hello"},
+		{path: "/data/local/font.tbl", content: "font"},
+		{path: "Data\\Local\\Font.tbl", content: "font"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if !z.Exists(test.path) {
+				t.Fatal("the file does not exist")
+			}
+
+			reader, err := z.Load(test.path)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, _ := ioutil.ReadAll(reader)
+
+			if string(data) != test.content {
+				t.Errorf("the file is %q, want %q", data, test.content)
+			}
+		})
+	}
+
+	files, err := z.List("data\\LOCAL")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 || files[0] != "Data/Local/Font.tbl" {
+		t.Errorf("listed %v, want the entry with forward slashes", files)
+	}
+}
+
+func TestClose(t *testing.T) {
+	z, err := New(writeZip(t, map[string]string{"test.txt": "hello"}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if z.Exists("/test.txt") {
+		t.Error("the file exists after closing")
+	}
+
+	if _, err := z.Load("/test.txt"); err == nil {
+		t.Error("the file was loaded after closing")
+	}
+
+	if err := z.Close(); err != nil {
+		t.Errorf("closing twice returned %v", err)
+	}
+}
@@ -3,12 +3,15 @@ package main
 import (
 	"encoding/json"
 	"flag"
+	"image/png"
 	"io/ioutil"
 	"os"
 	"path"
 
 	"github.com/OpenDiablo2/AbyssEngine/engine"
-	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer/headlessrenderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer/raylibrenderer"
 	"github.com/pkg/profile"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -17,10 +20,20 @@ import (
 
 var runPath string
 var doProfile bool
+var headless bool
+var headlessFrames int
+var screenshotPath string
+var devMode bool
+var noBatch bool
 
 func initFlags() {
 	flag.StringVar(&runPath, "path", "", "path to the engine runtime files")
 	flag.BoolVar(&doProfile, "profile", false, "profile the engine")
+	flag.BoolVar(&headless, "headless", false, "run without a window using the software renderer")
+	flag.IntVar(&headlessFrames, "frames", 0, "number of frames to run in headless mode (0 runs until shutdown)")
+	flag.StringVar(&screenshotPath, "screenshot", "", "in headless mode, write the final frame to this PNG file")
+	flag.BoolVar(&devMode, "dev", false, "enable developer mode (reloads scripts and assets when they change)")
+	flag.BoolVar(&noBatch, "nobatch", false, "draw every sprite and glyph with a draw call of its own, without batching")
 	flag.Parse()
 
 	if runPath == "" {
@@ -44,20 +57,7 @@ func main() {
 	log.Info().Msg("Abyss Engine")
 	log.Debug().Msgf("Runtime Path: %s", runPath)
 
-	rl.SetTraceLogCallback(func(logLevel int, s string) {
-		[]func() *zerolog.Event{
-			log.Trace,
-			log.Debug,
-			log.Info,
-			log.Warn,
-			log.Error,
-			log.Fatal,
-		}[logLevel-1]().Msg(s)
-	})
-
-	engineConfig := engine.Configuration{
-		RootPath: runPath,
-	}
+	engineConfig := engine.DefaultConfiguration(runPath)
 
 	jsonFile, err := os.Open(path.Join(runPath, "config.json"))
 	if err == nil {
@@ -66,9 +66,46 @@ func main() {
 		_ = jsonFile.Close()
 	}
 
-	coreEngine := engine.New(engineConfig)
+	if devMode {
+		engineConfig.DevMode = true
+	}
+
+	if noBatch {
+		engineConfig.DisableBatching = true
+	}
+
+	var engineRenderer renderer.Renderer
+	var softwareRenderer *headlessrenderer.HeadlessRenderer
+
+	if headless {
+		softwareRenderer = headlessrenderer.New(engineConfig.WindowWidth, engineConfig.WindowHeight, headlessFrames)
+		engineRenderer = softwareRenderer
+	} else {
+		engineRenderer = raylibrenderer.New("Abyss Engine", engineConfig.WindowWidth, engineConfig.WindowHeight,
+			engineConfig.VirtualWidth, engineConfig.VirtualHeight)
+	}
+
+	coreEngine := engine.New(engineConfig, engineRenderer)
 
 	coreEngine.Run()
 	coreEngine.Destroy()
 
+	if softwareRenderer != nil && screenshotPath != "" {
+		writeScreenshot(softwareRenderer)
+	}
+}
+
+func writeScreenshot(softwareRenderer *headlessrenderer.HeadlessRenderer) {
+	file, err := os.Create(screenshotPath)
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create screenshot file")
+		return
+	}
+
+	defer file.Close()
+
+	if err := png.Encode(file, softwareRenderer.Surface()); err != nil {
+		log.Error().Err(err).Msg("failed to write screenshot")
+	}
 }
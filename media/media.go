@@ -13,3 +13,9 @@ var PaletteFragmentShader string
 
 //go:embed shaders/standard.vs
 var StandardVertexShader string
+
+//go:embed scripts/require.lua
+var RequireScript string
+
+//go:embed scripts/reload.lua
+var ReloadScript string
@@ -0,0 +1,33 @@
+-- Reloads a module that was loaded with require, then calls the reload handlers.
+-- The module table is updated in place, so that scripts holding on to the old
+-- table see the new functions. If name is nil only the handlers are called.
+local loaded = package.loaded
+
+return function(name, path, handlers)
+    local module
+
+    if name ~= nil then
+        local previous = loaded[name]
+
+        loaded[name] = nil
+        module = require(name)
+
+        if type(previous) == "table" and type(module) == "table" and previous ~= module then
+            for key in pairs(previous) do
+                previous[key] = nil
+            end
+
+            for key, value in pairs(module) do
+                previous[key] = value
+            end
+
+            setmetatable(previous, getmetatable(module))
+            loaded[name] = previous
+            module = previous
+        end
+    end
+
+    for _, handler in ipairs(handlers) do
+        handler(path, module)
+    end
+end
@@ -0,0 +1,34 @@
+-- A replacement for the built-in require that is written in Lua, so that modules
+-- can call abyss.sleep (and yield the script coroutine) while they are loading.
+local loaded = package.loaded
+local loaders = package.loaders
+
+function require(name)
+    local module = loaded[name]
+
+    if module ~= nil then
+        return module
+    end
+
+    local messages = {}
+
+    for _, loader in ipairs(loaders) do
+        local chunk = loader(name)
+
+        if type(chunk) == "function" then
+            local result = chunk(name)
+
+            if result ~= nil then
+                loaded[name] = result
+            elseif loaded[name] == nil then
+                loaded[name] = true
+            end
+
+            return loaded[name]
+        elseif type(chunk) == "string" then
+            messages[#messages + 1] = chunk
+        end
+    end
+
+    error("module '" .. name .. "' not found:\n\t" .. table.concat(messages, "\n\t"), 2)
+end
@@ -16,5 +16,5 @@ out vec4 finalColor;
 
 void main() {
   vec4 index = texture(texture0, fragTexCoord);
-  finalColor = texture(palette, vec2(index.x, paletteOffset));
+  finalColor = texture(palette, vec2(index.x, paletteOffset)) * fragColor;
 }
@@ -2,9 +2,12 @@ package button
 
 import (
 	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/input"
 	"github.com/OpenDiablo2/AbyssEngine/node"
 	"github.com/OpenDiablo2/AbyssEngine/node/button/buttonlayout"
 	"github.com/OpenDiablo2/AbyssEngine/node/sprite"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer/atlas"
 )
 
 const (
@@ -20,15 +23,17 @@ type Button struct {
 	enabled      bool
 	pressed      bool
 	toggled      bool
-	onClick      func()
+	onClick      func(event input.MouseEvent)
+	mouse        common.MouseProvider
 	sprite       *sprite.Sprite
 	text         string
 }
 
-func New(loaderProvider common.LoaderProvider, mousePositionProvider common.MousePositionProvider,
-	buttonLayout buttonlayout.ButtonLayout) (*Button, error) {
+func New(resourceProvider common.ResourceProvider, renderProvider renderer.Renderer, textureAtlas *atlas.Atlas,
+	mouse common.MouseProvider, buttonLayout buttonlayout.ButtonLayout) (*Button, error) {
 	result := &Button{
 		Node:         node.New(),
+		mouse:        mouse,
 		buttonLayout: buttonLayout,
 		enabled:      true,
 		pressed:      false,
@@ -37,16 +42,21 @@ func New(loaderProvider common.LoaderProvider, mousePositionProvider common.Mous
 
 	result.RenderCallback = result.render
 	result.UpdateCallback = result.update
+	result.ToLuaCallback = result.ToLua
+	result.Type = luaTypeExportName
 
 	var err error
 
-	result.sprite, err = sprite.New(loaderProvider, mousePositionProvider,
-		buttonLayout.ResourceName, buttonLayout.PaletteName)
+	result.sprite, err = sprite.New(resourceProvider, renderProvider, textureAtlas, mouse, buttonLayout.ResourceName,
+		buttonLayout.PaletteName)
 
 	if err != nil {
 		return nil, err
 	}
 
+	result.sprite.SetMouseButtonDownHandler(result.onMouseButtonDown)
+	result.sprite.SetMouseButtonUpHandler(result.onMouseButtonUp)
+
 	result.sprite.CellSizeX = buttonLayout.XSegments
 	result.sprite.CellSizeY = buttonLayout.YSegments
 	err = result.AddChild(result.sprite.Node)
@@ -91,5 +101,28 @@ func (b *Button) render() {
 }
 
 func (b *Button) update(elapsed float64) {
+	// the sprite only sees the button go up when the mouse is over it, the capture ends either way
+	if b.pressed && !b.mouse.HasCapture(b.sprite.Node) {
+		b.pressed = false
+	}
+}
 
+func (b *Button) onMouseButtonDown(event input.MouseEvent) {
+	if !b.enabled {
+		return
+	}
+
+	b.pressed = true
+}
+
+func (b *Button) onMouseButtonUp(event input.MouseEvent) {
+	if !b.pressed {
+		return
+	}
+
+	b.pressed = false
+
+	if b.onClick != nil {
+		b.onClick(event)
+	}
 }
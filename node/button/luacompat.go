@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/input"
 	lua "github.com/yuin/gopher-lua"
 )
 
@@ -12,7 +13,10 @@ var LuaTypeExport = common.LuaTypeExport{
 	Name: luaTypeExportName,
 	//ConstructorFunc: newLuaEntity,
 	Methods: map[string]lua.LGFunction{
-		"node": luaGetNode,
+		"node":         luaGetNode,
+		"clickHandler": luaGetSetClickHandler,
+		"enabled":      luaGetSetEnabled,
+		"toggled":      luaGetSetToggled,
 	},
 }
 
@@ -47,3 +51,82 @@ func luaGetNode(l *lua.LState) int {
 
 	return 1
 }
+
+func luaGetSetClickHandler(l *lua.LState) int {
+	button, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(l.NewFunction(func(l *lua.LState) int {
+			if button.onClick != nil {
+				button.onClick(input.MouseEvent{})
+			}
+
+			return 0
+		}))
+
+		return 1
+	}
+
+	if l.Get(2) == lua.LNil {
+		button.onClick = nil
+		return 0
+	}
+
+	luaFunc := l.CheckFunction(2)
+	button.onClick = func(event input.MouseEvent) {
+		if err := l.CallByParam(lua.P{
+			Fn:      luaFunc,
+			NRet:    1,
+			Protect: true,
+		}, button.ToLua(l), event.ToLua(l)); err != nil {
+			panic(err)
+		}
+	}
+
+	return 0
+}
+
+func luaGetSetEnabled(l *lua.LState) int {
+	button, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LBool(button.enabled))
+		return 1
+	}
+
+	button.enabled = l.CheckBool(2)
+
+	if !button.enabled {
+		button.pressed = false
+	}
+
+	return 0
+}
+
+func luaGetSetToggled(l *lua.LState) int {
+	button, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LBool(button.toggled))
+		return 1
+	}
+
+	button.toggled = l.CheckBool(2)
+
+	return 0
+}
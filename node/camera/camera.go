@@ -0,0 +1,223 @@
+package camera
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/node"
+	"github.com/OpenDiablo2/AbyssEngine/node/maprenderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+)
+
+// Camera is a view into the world. Its children are in world coordinates, such as the map pixels of a map renderer,
+// and are drawn and hit tested through the view. The position of the camera is the point of the world at the center
+// of the surface. The scale of the camera zooms the view, and its rotation turns it, around that point.
+//
+// The view is moved by the engine when the camera is the active one: it follows its target, stays inside its limits
+// and shakes, after everything else has been updated.
+type Camera struct {
+	*node.Node
+
+	renderer       renderer.Renderer
+	display        common.DisplayProvider
+	target         *node.Node
+	smoothing      float64
+	limited        bool
+	limitX         float64
+	limitY         float64
+	limitWidth     float64
+	limitHeight    float64
+	shakeIntensity float64
+	shakeDuration  float64
+	shakeRemaining float64
+	shakeX         float64
+	shakeY         float64
+}
+
+func New(renderProvider renderer.Renderer, display common.DisplayProvider) *Camera {
+	result := &Camera{
+		Node:     node.New(),
+		renderer: renderProvider,
+		display:  display,
+	}
+
+	result.LocalTransformCallback = result.viewTransform
+	result.ToLuaCallback = result.ToLua
+	result.BoundsCallback = result.bounds
+	result.Type = luaTypeExportName
+	result.DestroyCallback = result.Destroy
+
+	return result
+}
+
+func (c *Camera) Destroy() {
+	c.ShouldRemove = true
+	c.Active = false
+	c.target = nil
+}
+
+// viewTransform moves the world so that the position of the camera is at the center of the surface, zoomed by the
+// scale of the camera and turned by its rotation. The position is kept on whole surface pixels, so that the world is
+// not filtered as the camera moves.
+func (c *Camera) viewTransform() renderer.Transform {
+	width, height := c.renderer.SurfaceSize()
+
+	return renderer.Translation(math.Round(float64(width)/2+c.shakeX), math.Round(float64(height)/2+c.shakeY)).
+		Multiply(renderer.Rotation(c.Rotation)).
+		Multiply(renderer.Scaling(c.ScaleX, c.ScaleY)).
+		Multiply(renderer.Translation(-snap(c.X, c.ScaleX), -snap(c.Y, c.ScaleY)))
+}
+
+// snap rounds a position of the world to the nearest whole surface pixel at the given scale.
+func snap(position, scale float64) float64 {
+	if scale == 0 {
+		return position
+	}
+
+	return math.Round(position*scale) / scale
+}
+
+// viewSize returns the size of the part of the world that is in view, or of the rectangle around it when the view is
+// rotated.
+func (c *Camera) viewSize() (width, height float64) {
+	surfaceWidth, surfaceHeight := c.renderer.SurfaceSize()
+	inverse, ok := renderer.Rotation(c.Rotation).Multiply(renderer.Scaling(c.ScaleX, c.ScaleY)).Invert()
+
+	if !ok {
+		return float64(surfaceWidth), float64(surfaceHeight)
+	}
+
+	for _, corner := range [][2]int{{surfaceWidth, surfaceHeight}, {surfaceWidth, -surfaceHeight}} {
+		x, y := inverse.Apply(float64(corner[0]), float64(corner[1]))
+		width, height = math.Max(width, math.Abs(x)), math.Max(height, math.Abs(y))
+	}
+
+	return width, height
+}
+
+// Follow makes the camera move to the position of a node every frame, or stop following when the node is nil. With
+// a smoothing of zero the camera stays on the node; otherwise it is the time, in seconds, it takes to cover about two
+// thirds of the distance to it.
+func (c *Camera) Follow(target *node.Node, smoothing float64) {
+	c.target = target
+	c.smoothing = math.Max(0, smoothing)
+}
+
+func (c *Camera) Target() *node.Node {
+	return c.target
+}
+
+func (c *Camera) Smoothing() float64 {
+	return c.smoothing
+}
+
+// SetLimits keeps the view inside a rectangle of the world, such as the extent of a map. A view larger than the
+// rectangle is centered on it.
+func (c *Camera) SetLimits(x, y, width, height float64) {
+	c.limited = true
+	c.limitX, c.limitY, c.limitWidth, c.limitHeight = x, y, width, height
+}
+
+func (c *Camera) ClearLimits() {
+	c.limited = false
+}
+
+func (c *Camera) Limits() (x, y, width, height float64, ok bool) {
+	return c.limitX, c.limitY, c.limitWidth, c.limitHeight, c.limited
+}
+
+// Shake shakes the view by up to intensity pixels, fading out over the duration in seconds.
+func (c *Camera) Shake(intensity, duration float64) {
+	c.shakeIntensity = intensity
+	c.shakeDuration = duration
+	c.shakeRemaining = duration
+}
+
+// UpdateView moves the camera to its target, keeps it inside its limits, and shakes it.
+func (c *Camera) UpdateView(elapsed float64) {
+	if c.target != nil && c.target.ShouldRemove {
+		c.target = nil
+	}
+
+	if c.target != nil {
+		targetX, targetY := c.SurfaceToWorld(c.target.WorldTransform().Apply(0, 0))
+		amount := 1.0
+
+		if c.smoothing > 0 {
+			amount = 1 - math.Exp(-elapsed/c.smoothing)
+		}
+
+		c.X += (targetX - c.X) * amount
+		c.Y += (targetY - c.Y) * amount
+	}
+
+	if c.limited {
+		width, height := c.viewSize()
+		c.X = limit(c.X, c.limitX, c.limitWidth, width)
+		c.Y = limit(c.Y, c.limitY, c.limitHeight, height)
+	}
+
+	c.shakeX, c.shakeY = 0, 0
+
+	if c.shakeRemaining > 0 {
+		c.shakeRemaining = math.Max(0, c.shakeRemaining-elapsed)
+		strength := c.shakeIntensity * c.shakeRemaining / c.shakeDuration
+		c.shakeX = (rand.Float64()*2 - 1) * strength
+		c.shakeY = (rand.Float64()*2 - 1) * strength
+	}
+}
+
+// limit keeps the center of a view of the given size inside a range. A view larger than the range is centered on it.
+func limit(center, start, length, viewLength float64) float64 {
+	if length <= viewLength {
+		return start + length/2
+	}
+
+	return math.Max(start+viewLength/2, math.Min(start+length-viewLength/2, center))
+}
+
+// WorldToSurface returns where a point of the world is on the surface.
+func (c *Camera) WorldToSurface(x, y float64) (surfaceX, surfaceY float64) {
+	return c.WorldTransform().Apply(x, y)
+}
+
+// SurfaceToWorld returns the point of the world at a position on the surface.
+func (c *Camera) SurfaceToWorld(x, y float64) (worldX, worldY float64) {
+	inverse, ok := c.WorldTransform().Invert()
+
+	if !ok {
+		return x, y
+	}
+
+	return inverse.Apply(x, y)
+}
+
+// WorldToScreen returns where a point of the world is in the window.
+func (c *Camera) WorldToScreen(x, y float64) (screenX, screenY float64) {
+	return c.display.SurfaceToScreen(c.WorldToSurface(x, y))
+}
+
+// ScreenToWorld returns the point of the world at a position in the window.
+func (c *Camera) ScreenToWorld(x, y float64) (worldX, worldY float64) {
+	return c.SurfaceToWorld(c.display.ScreenToSurface(x, y))
+}
+
+// TileToScreen returns where the top corner of a map tile is in the window. See maprenderer.TileToWorld.
+func (c *Camera) TileToScreen(tileX, tileY float64) (screenX, screenY float64) {
+	return c.WorldToScreen(maprenderer.TileToWorld(tileX, tileY))
+}
+
+// ScreenToTile returns the map tile at a position in the window. See maprenderer.WorldToTile.
+func (c *Camera) ScreenToTile(x, y float64) (tileX, tileY float64) {
+	return maprenderer.WorldToTile(c.ScreenToWorld(x, y))
+}
+
+// bounds returns the part of the world that is in view, or the rectangle around it when the view is rotated.
+func (c *Camera) bounds() (x, y, width, height float64, ok bool) {
+	surfaceWidth, surfaceHeight := c.renderer.SurfaceSize()
+	centerX, centerY := c.SurfaceToWorld(float64(surfaceWidth)/2, float64(surfaceHeight)/2)
+	width, height = c.viewSize()
+
+	return centerX - width/2, centerY - height/2, width, height, true
+}
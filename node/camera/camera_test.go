@@ -0,0 +1,91 @@
+package camera
+
+import (
+	"math"
+	"testing"
+
+	"github.com/OpenDiablo2/AbyssEngine/node/maprenderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer/headlessrenderer"
+)
+
+// doubleDisplay is a window twice the size of the surface.
+type doubleDisplay struct{}
+
+func (doubleDisplay) ScreenToSurface(x, y float64) (surfaceX, surfaceY float64) {
+	return x / 2, y / 2
+}
+
+func (doubleDisplay) SurfaceToScreen(x, y float64) (screenX, screenY float64) {
+	return x * 2, y * 2
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestViewTransform(t *testing.T) {
+	tests := []struct {
+		name           string
+		zoom, rotation float64
+		world          [2]float64
+		surface        [2]float64
+		bounds         [4]float64
+	}{
+		{
+			name: "centered", zoom: 1,
+			world: [2]float64{110, 60}, surface: [2]float64{60, 60}, bounds: [4]float64{50, 0, 100, 100},
+		},
+		{
+			name: "zoomed in", zoom: 2,
+			world: [2]float64{110, 60}, surface: [2]float64{70, 70}, bounds: [4]float64{75, 25, 50, 50},
+		},
+		{
+			name: "rotated", zoom: 1, rotation: 90,
+			world: [2]float64{110, 60}, surface: [2]float64{40, 60}, bounds: [4]float64{50, 0, 100, 100},
+		},
+		{
+			name: "zoomed out and rotated", zoom: 0.5, rotation: 90,
+			world: [2]float64{120, 50}, surface: [2]float64{50, 60}, bounds: [4]float64{0, -50, 200, 200},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := New(headlessrenderer.New(100, 100, 0), doubleDisplay{})
+			c.X, c.Y = 100, 50
+			c.ScaleX, c.ScaleY = test.zoom, test.zoom
+			c.Rotation = test.rotation
+
+			if x, y := c.WorldToSurface(test.world[0], test.world[1]); !near(x, test.surface[0]) || !near(y, test.surface[1]) {
+				t.Errorf("the world point is at %v,%v on the surface, want %v", x, y, test.surface)
+			}
+
+			if x, y := c.SurfaceToWorld(test.surface[0], test.surface[1]); !near(x, test.world[0]) || !near(y, test.world[1]) {
+				t.Errorf("the surface point is at %v,%v in the world, want %v", x, y, test.world)
+			}
+
+			x, y, width, height, _ := c.bounds()
+
+			for idx, got := range []float64{x, y, width, height} {
+				if !near(got, test.bounds[idx]) {
+					t.Errorf("the view is %v,%v %vx%v, want %v", x, y, width, height, test.bounds)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestTileConversions(t *testing.T) {
+	c := New(headlessrenderer.New(100, 100, 0), doubleDisplay{})
+	c.X, c.Y = maprenderer.TileToWorld(3, 2)
+	c.ScaleX, c.ScaleY = 2, 2
+
+	if x, y := c.TileToScreen(3, 2); !near(x, 100) || !near(y, 100) {
+		t.Errorf("the tile under the camera is at %v,%v in the window, want the center", x, y)
+	}
+
+	if tileX, tileY := c.ScreenToTile(100, 100); !near(tileX, 3) || !near(tileY, 2) {
+		t.Errorf("the center of the window is on tile %v,%v, want 3,2", tileX, tileY)
+	}
+}
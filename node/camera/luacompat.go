@@ -0,0 +1,289 @@
+package camera
+
+import (
+	"fmt"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/node"
+	"github.com/OpenDiablo2/AbyssEngine/node/maprenderer"
+	lua "github.com/yuin/gopher-lua"
+)
+
+var luaTypeExportName = "camera"
+var LuaTypeExport = common.LuaTypeExport{
+	Name: luaTypeExportName,
+	//ConstructorFunc: newLuaEntity,
+	Methods: map[string]lua.LGFunction{
+		"node":           luaGetNode,
+		"position":       luaGetSetPosition,
+		"visible":        luaGetSetVisible,
+		"active":         luaGetSetActive,
+		"follow":         luaFollow,
+		"limits":         luaGetSetLimits,
+		"shake":          luaShake,
+		"worldToSurface": luaWorldToSurface,
+		"surfaceToWorld": luaSurfaceToWorld,
+		"worldToScreen":  luaWorldToScreen,
+		"screenToWorld":  luaScreenToWorld,
+		"tileToWorld":    luaTileToWorld,
+		"worldToTile":    luaWorldToTile,
+		"tileToScreen":   luaTileToScreen,
+		"screenToTile":   luaScreenToTile,
+		"zoom":           luaGetSetZoom,
+		"destroy":        luaDestroy,
+	},
+}
+
+func (c *Camera) ToLua(l *lua.LState) *lua.LUserData {
+	result := l.NewUserData()
+	result.Value = c
+
+	l.SetMetatable(result, l.GetTypeMetatable(luaTypeExportName))
+
+	return result
+}
+
+func FromLua(ud *lua.LUserData) (*Camera, error) {
+	v, ok := ud.Value.(*Camera)
+
+	if !ok {
+		return nil, fmt.Errorf("failed to convert")
+	}
+
+	return v, nil
+}
+
+func luaGetNode(l *lua.LState) int {
+	camera, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(camera.Node.ToLua(l))
+
+	return 1
+}
+
+// luaGetSetPosition gets or sets the point of the world at the center of the surface.
+func luaGetSetPosition(l *lua.LState) int {
+	camera, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(camera.X))
+		l.Push(lua.LNumber(camera.Y))
+		return 2
+	}
+
+	camera.X = float64(l.ToNumber(2))
+	camera.Y = float64(l.ToNumber(3))
+
+	return 0
+}
+
+func luaGetSetVisible(l *lua.LState) int {
+	camera, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LBool(camera.Visible))
+		return 1
+	}
+
+	camera.Visible = l.CheckBool(2)
+
+	return 0
+}
+
+func luaGetSetActive(l *lua.LState) int {
+	camera, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LBool(camera.Active))
+		return 1
+	}
+
+	camera.Active = l.CheckBool(2)
+
+	return 0
+}
+
+// luaFollow makes the camera follow a node, e.g. camera:follow(player, 0.25), or stop following it with
+// camera:follow(nil). The smoothing is optional; see Camera.Follow.
+func luaFollow(l *lua.LState) int {
+	camera, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.Get(2) == lua.LNil {
+		camera.Follow(nil, 0)
+		return 0
+	}
+
+	target, err := node.FromLuaValue(l.Get(2))
+
+	if err != nil {
+		l.ArgError(2, err.Error())
+		return 0
+	}
+
+	camera.Follow(target, float64(l.OptNumber(3, 0)))
+
+	return 0
+}
+
+// luaGetSetLimits gets or sets the rectangle of the world the view stays in, as x, y, width and height, e.g.
+// camera:limits(map:extent()). Setting it to nil removes the limits, and getting it returns nil when there are none.
+func luaGetSetLimits(l *lua.LState) int {
+	camera, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		x, y, width, height, ok := camera.Limits()
+
+		if !ok {
+			l.Push(lua.LNil)
+			return 1
+		}
+
+		l.Push(lua.LNumber(x))
+		l.Push(lua.LNumber(y))
+		l.Push(lua.LNumber(width))
+		l.Push(lua.LNumber(height))
+
+		return 4
+	}
+
+	if l.Get(2) == lua.LNil {
+		camera.ClearLimits()
+		return 0
+	}
+
+	camera.SetLimits(float64(l.CheckNumber(2)), float64(l.CheckNumber(3)), float64(l.CheckNumber(4)),
+		float64(l.CheckNumber(5)))
+
+	return 0
+}
+
+// luaShake shakes the view, e.g. camera:shake(8, 0.5) for up to 8 pixels over half a second.
+func luaShake(l *lua.LState) int {
+	camera, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	camera.Shake(float64(l.CheckNumber(2)), float64(l.CheckNumber(3)))
+
+	return 0
+}
+
+func luaWorldToSurface(l *lua.LState) int {
+	return luaConvert(l, (*Camera).WorldToSurface)
+}
+
+func luaSurfaceToWorld(l *lua.LState) int {
+	return luaConvert(l, (*Camera).SurfaceToWorld)
+}
+
+func luaWorldToScreen(l *lua.LState) int {
+	return luaConvert(l, (*Camera).WorldToScreen)
+}
+
+func luaScreenToWorld(l *lua.LState) int {
+	return luaConvert(l, (*Camera).ScreenToWorld)
+}
+
+func luaTileToWorld(l *lua.LState) int {
+	return luaConvert(l, func(_ *Camera, x, y float64) (float64, float64) { return maprenderer.TileToWorld(x, y) })
+}
+
+func luaWorldToTile(l *lua.LState) int {
+	return luaConvert(l, func(_ *Camera, x, y float64) (float64, float64) { return maprenderer.WorldToTile(x, y) })
+}
+
+func luaTileToScreen(l *lua.LState) int {
+	return luaConvert(l, (*Camera).TileToScreen)
+}
+
+func luaScreenToTile(l *lua.LState) int {
+	return luaConvert(l, (*Camera).ScreenToTile)
+}
+
+// luaGetSetZoom gets or sets the scale of the view, the same on both axes. A zoom of 2 draws the world twice as large.
+func luaGetSetZoom(l *lua.LState) int {
+	camera, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(camera.ScaleX))
+		return 1
+	}
+
+	zoom := float64(l.CheckNumber(2))
+
+	if zoom <= 0 {
+		l.ArgError(2, "zoom must be greater than zero")
+		return 0
+	}
+
+	camera.ScaleX, camera.ScaleY = zoom, zoom
+
+	return 0
+}
+
+// luaConvert converts the x and y arguments with one of the conversions of the camera.
+func luaConvert(l *lua.LState, convert func(c *Camera, x, y float64) (float64, float64)) int {
+	camera, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	x, y := convert(camera, float64(l.CheckNumber(2)), float64(l.CheckNumber(3)))
+	l.Push(lua.LNumber(x))
+	l.Push(lua.LNumber(y))
+
+	return 2
+}
+
+func luaDestroy(l *lua.LState) int {
+	camera, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	camera.Destroy()
+
+	return 0
+}
@@ -0,0 +1,358 @@
+package composite
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/loader"
+	"github.com/OpenDiablo2/AbyssEngine/node"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer/atlas"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2cof"
+	dcc "github.com/OpenDiablo2/dcc/pkg"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultEquipment is the armor code layers are drawn with until something else is equipped.
+const defaultEquipment = "lit"
+
+// Composite is a character, monster or object made up of layers (head, torso, weapons, and so on), each drawn from its
+// own DCC file. A COF file for each mode (e.g. walking) and weapon class says which layers there are, and in which
+// order they are drawn for each direction and frame.
+//
+// Files are found the way Diablo II lays them out, in lower case. For the directory /data/global/chars/ba, the COF of
+// the TN mode with the HTH weapon class is cof/batnhth.cof, and the head layer with the LIT armor code is
+// hd/bahdlittnhth.dcc.
+type Composite struct {
+	*node.Node
+
+	renderer         renderer.Renderer
+	atlas            *atlas.Atlas
+	resourceProvider common.ResourceProvider
+	directory        string
+	token            string
+	mode             string
+	weaponClass      string
+	palette          string
+	cof              *d2cof.COF
+	cofResource      common.ResourceHandle
+	cofPath          string
+	equipment        map[d2enum.CompositeType]string
+	layers           map[d2enum.CompositeType]*layer
+	direction        int
+	currentFrame     int
+	lastFrameTime    float64
+	playing          bool
+	playSpeed        float64
+}
+
+// layer is one of the DCC files a composite is drawn from, with the atlas regions of the frames it has drawn so far in
+// the current direction.
+type layer struct {
+	filePath  string
+	resource  common.ResourceHandle
+	sequences common.SequenceProvider
+	regions   []*atlas.Region
+	blendMode renderer.BlendMode
+	opacity   float64
+}
+
+// New loads the COF of a mode and weapon class (e.g. "tn" and "hth") from a directory such as /data/global/chars/ba,
+// along with its layers. The name of the directory is the token the files are named after. Frames are packed into the
+// atlas as they are drawn.
+func New(resourceProvider common.ResourceProvider, renderProvider renderer.Renderer, textureAtlas *atlas.Atlas,
+	directory, mode, weaponClass, palette string) (*Composite, error) {
+	result := &Composite{
+		Node:             node.New(),
+		renderer:         renderProvider,
+		atlas:            textureAtlas,
+		resourceProvider: resourceProvider,
+		directory:        directory,
+		token:            strings.ToLower(path.Base(directory)),
+		palette:          palette,
+		equipment:        make(map[d2enum.CompositeType]string),
+		layers:           make(map[d2enum.CompositeType]*layer),
+		playing:          true,
+		playSpeed:        1,
+	}
+
+	result.RenderCallback = result.render
+	result.UpdateCallback = result.update
+	result.ToLuaCallback = result.ToLua
+	result.BoundsCallback = result.bounds
+	result.Type = luaTypeExportName
+	result.ReloadCallback = result.reload
+	result.DestroyCallback = result.Destroy
+
+	if _, ok := common.PaletteTexture[palette]; !ok {
+		return nil, errors.New("composite loaded with non-existent palette")
+	}
+
+	if err := result.SetMode(mode, weaponClass); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SetMode switches to the animation of another mode and weapon class, starting from its first frame.
+func (c *Composite) SetMode(mode, weaponClass string) error {
+	mode, weaponClass = strings.ToLower(mode), strings.ToLower(weaponClass)
+	cofPath := path.Join(c.directory, "cof", c.token+mode+weaponClass+".cof")
+
+	handle, err := c.resourceProvider.Acquire(cofPath, common.ResourceTypeCOF)
+
+	if err != nil {
+		return err
+	}
+
+	if c.cofResource != nil {
+		c.cofResource.Release()
+	}
+
+	c.cofResource = handle
+	c.cof = handle.Value().(*d2cof.COF)
+	c.cofPath = cofPath
+	c.mode = mode
+	c.weaponClass = weaponClass
+	c.currentFrame = 0
+	c.lastFrameTime = 0
+
+	if c.direction >= c.cof.NumberOfDirections {
+		c.direction = 0
+	}
+
+	c.loadLayers()
+
+	return nil
+}
+
+func (c *Composite) Mode() (mode, weaponClass string) {
+	return c.mode, c.weaponClass
+}
+
+// SetEquipment sets the armor code a layer is drawn with, e.g. "lit" or "hvy" for the torso. An empty code hides the
+// layer.
+func (c *Composite) SetEquipment(layerType d2enum.CompositeType, code string) {
+	c.equipment[layerType] = strings.ToLower(code)
+
+	c.unloadLayer(layerType)
+
+	if idx, ok := c.cof.CompositeLayers[layerType]; ok {
+		c.loadLayer(c.cof.CofLayers[idx])
+	}
+}
+
+// Equipment returns the armor code a layer is drawn with.
+func (c *Composite) Equipment(layerType d2enum.CompositeType) string {
+	code, ok := c.equipment[layerType]
+
+	if !ok {
+		return defaultEquipment
+	}
+
+	return code
+}
+
+// Layers returns the layers of the current mode, in the order the COF lists them.
+func (c *Composite) Layers() []d2enum.CompositeType {
+	result := make([]d2enum.CompositeType, len(c.cof.CofLayers))
+
+	for idx := range c.cof.CofLayers {
+		result[idx] = c.cof.CofLayers[idx].Type
+	}
+
+	return result
+}
+
+func (c *Composite) DirectionCount() int {
+	return c.cof.NumberOfDirections
+}
+
+func (c *Composite) Direction() int {
+	return c.direction
+}
+
+// SetDirection sets the direction the composite faces, from 0 to DirectionCount-1, in the order the COF has them.
+func (c *Composite) SetDirection(direction int) error {
+	if direction < 0 || direction >= c.cof.NumberOfDirections {
+		return fmt.Errorf("direction must be between 0 and %d", c.cof.NumberOfDirections-1)
+	}
+
+	if direction == c.direction {
+		return nil
+	}
+
+	c.direction = direction
+
+	for _, l := range c.layers {
+		c.unloadRegions(l)
+	}
+
+	return nil
+}
+
+func (c *Composite) FrameCount() int {
+	return c.cof.FramesPerDirection
+}
+
+func (c *Composite) CurrentFrame() int {
+	return c.currentFrame
+}
+
+func (c *Composite) SetCurrentFrame(frame int) error {
+	if frame < 0 || frame >= c.cof.FramesPerDirection {
+		return fmt.Errorf("frame must be between 0 and %d", c.cof.FramesPerDirection-1)
+	}
+
+	c.currentFrame = frame
+	c.lastFrameTime = 0
+
+	return nil
+}
+
+// SetPlaying starts or stops the animation. It plays in a loop at the speed of the COF, times the play speed.
+func (c *Composite) SetPlaying(playing bool) {
+	c.playing = playing
+}
+
+func (c *Composite) IsPlaying() bool {
+	return c.playing
+}
+
+func (c *Composite) SetPlaySpeed(playSpeed float64) {
+	c.playSpeed = playSpeed
+}
+
+func (c *Composite) PlaySpeed() float64 {
+	return c.playSpeed
+}
+
+func (c *Composite) Destroy() {
+	c.ShouldRemove = true
+	c.Active = false
+
+	for layerType := range c.layers {
+		c.unloadLayer(layerType)
+	}
+
+	if c.cofResource != nil {
+		c.cofResource.Release()
+		c.cofResource = nil
+	}
+}
+
+// loadLayers loads the DCC files of the layers in the COF, replacing the ones that are loaded.
+func (c *Composite) loadLayers() {
+	for layerType := range c.layers {
+		c.unloadLayer(layerType)
+	}
+
+	for idx := range c.cof.CofLayers {
+		c.loadLayer(c.cof.CofLayers[idx])
+	}
+}
+
+// loadLayer loads the DCC file of a layer for the equipment it has. Layers that have nothing equipped, or whose file
+// cannot be loaded, are not drawn.
+func (c *Composite) loadLayer(cofLayer d2cof.CofLayer) {
+	code := c.Equipment(cofLayer.Type)
+
+	if len(code) == 0 {
+		return
+	}
+
+	layerName := strings.ToLower(cofLayer.Type.String())
+	fileName := c.token + layerName + code + c.mode + strings.ToLower(cofLayer.WeaponClass.String()) + ".dcc"
+	filePath := path.Join(c.directory, layerName, fileName)
+
+	handle, err := c.resourceProvider.Acquire(filePath, common.ResourceTypeDCC)
+
+	if err != nil {
+		log.Warn().Err(err).Msgf("failed to load the %s layer %s", cofLayer.Type.Name(), filePath)
+		return
+	}
+
+	result := &layer{
+		filePath:  filePath,
+		resource:  handle,
+		sequences: &common.DCCSequenceProvider{Sequences: handle.Value().(*dcc.DCC).Directions()},
+		blendMode: renderer.BlendModeNone,
+		opacity:   1,
+	}
+
+	if cofLayer.Transparent {
+		result.blendMode, result.opacity = drawEffectToBlendMode(cofLayer.DrawEffect)
+	}
+
+	c.layers[cofLayer.Type] = result
+}
+
+func (c *Composite) unloadLayer(layerType d2enum.CompositeType) {
+	l, ok := c.layers[layerType]
+
+	if !ok {
+		return
+	}
+
+	c.unloadRegions(l)
+	l.resource.Release()
+
+	delete(c.layers, layerType)
+}
+
+func (c *Composite) unloadRegions(l *layer) {
+	for idx := range l.regions {
+		if l.regions[idx] != nil {
+			c.atlas.Remove(l.regions[idx])
+		}
+	}
+
+	l.regions = nil
+}
+
+// reload loads the COF and the layers again if one of their files changed.
+func (c *Composite) reload(changedPath string) {
+	changedPath = loader.NormalizePath(changedPath)
+	changed := strings.EqualFold(changedPath, loader.NormalizePath(c.cofPath))
+
+	for _, l := range c.layers {
+		changed = changed || strings.EqualFold(changedPath, loader.NormalizePath(l.filePath))
+	}
+
+	if !changed {
+		return
+	}
+
+	direction, frame := c.direction, c.currentFrame
+
+	if err := c.SetMode(c.mode, c.weaponClass); err != nil {
+		log.Error().Err(err).Msgf("failed to reload %s", c.cofPath)
+		return
+	}
+
+	_ = c.SetDirection(direction)
+	_ = c.SetCurrentFrame(frame)
+}
+
+// drawEffectToBlendMode returns how a transparent layer is drawn. Modulated layers multiply what is behind them.
+func drawEffectToBlendMode(drawEffect d2enum.DrawEffect) (renderer.BlendMode, float64) {
+	switch drawEffect {
+	case d2enum.DrawEffectPctTransparency25:
+		return renderer.BlendModeNone, 0.75
+	case d2enum.DrawEffectPctTransparency50:
+		return renderer.BlendModeNone, 0.5
+	case d2enum.DrawEffectPctTransparency75:
+		return renderer.BlendModeNone, 0.25
+	case d2enum.DrawEffectModulate, d2enum.DrawEffectBurn:
+		return renderer.BlendModeMultiplied, 1
+	case d2enum.DrawEffectMod2X, d2enum.DrawEffectMod2XTrans:
+		return renderer.BlendModeAdditive, 1
+	}
+
+	return renderer.BlendModeNone, 1
+}
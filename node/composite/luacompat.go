@@ -0,0 +1,326 @@
+package composite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	lua "github.com/yuin/gopher-lua"
+)
+
+var luaTypeExportName = "composite"
+var LuaTypeExport = common.LuaTypeExport{
+	Name: luaTypeExportName,
+	//ConstructorFunc: newLuaEntity,
+	Methods: map[string]lua.LGFunction{
+		"node":           luaGetNode,
+		"position":       luaGetSetPosition,
+		"visible":        luaGetSetVisible,
+		"active":         luaGetSetActive,
+		"mode":           luaGetSetMode,
+		"direction":      luaGetSetDirection,
+		"directionCount": luaGetDirectionCount,
+		"currentFrame":   luaGetSetCurrentFrame,
+		"frameCount":     luaGetFrameCount,
+		"playing":        luaGetSetPlaying,
+		"playSpeed":      luaGetSetPlaySpeed,
+		"layers":         luaGetLayers,
+		"equipment":      luaGetSetEquipment,
+		"destroy":        luaDestroy,
+	},
+}
+
+func (c *Composite) ToLua(l *lua.LState) *lua.LUserData {
+	result := l.NewUserData()
+	result.Value = c
+
+	l.SetMetatable(result, l.GetTypeMetatable(luaTypeExportName))
+
+	return result
+}
+
+func FromLua(ud *lua.LUserData) (*Composite, error) {
+	v, ok := ud.Value.(*Composite)
+
+	if !ok {
+		return nil, fmt.Errorf("failed to convert")
+	}
+
+	return v, nil
+}
+
+// layerTypeFromString returns the layer with the given code, e.g. "hd" for the head.
+func layerTypeFromString(code string) (d2enum.CompositeType, error) {
+	for layerType := d2enum.CompositeType(0); layerType < d2enum.CompositeTypeMax; layerType++ {
+		if strings.EqualFold(layerType.String(), code) {
+			return layerType, nil
+		}
+	}
+
+	return -1, fmt.Errorf("unknown layer %q", code)
+}
+
+func luaGetNode(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(composite.Node.ToLua(l))
+
+	return 1
+}
+
+func luaGetSetPosition(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(composite.X))
+		l.Push(lua.LNumber(composite.Y))
+		return 2
+	}
+
+	composite.X = float64(l.ToNumber(2))
+	composite.Y = float64(l.ToNumber(3))
+
+	return 0
+}
+
+func luaGetSetVisible(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LBool(composite.Visible))
+		return 1
+	}
+
+	composite.Visible = l.CheckBool(2)
+
+	return 0
+}
+
+func luaGetSetActive(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LBool(composite.Active))
+		return 1
+	}
+
+	composite.Active = l.CheckBool(2)
+
+	return 0
+}
+
+// luaGetSetMode gets the mode and weapon class, e.g. "tn" and "hth", or switches to another one.
+func luaGetSetMode(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		mode, weaponClass := composite.Mode()
+		l.Push(lua.LString(mode))
+		l.Push(lua.LString(weaponClass))
+
+		return 2
+	}
+
+	_, weaponClass := composite.Mode()
+
+	if l.GetTop() >= 3 {
+		weaponClass = l.CheckString(3)
+	}
+
+	if err := composite.SetMode(l.CheckString(2), weaponClass); err != nil {
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	return 0
+}
+
+func luaGetSetDirection(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(composite.Direction()))
+		return 1
+	}
+
+	if err := composite.SetDirection(l.CheckInt(2)); err != nil {
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	return 0
+}
+
+func luaGetDirectionCount(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(lua.LNumber(composite.DirectionCount()))
+
+	return 1
+}
+
+func luaGetSetCurrentFrame(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(composite.CurrentFrame()))
+		return 1
+	}
+
+	if err := composite.SetCurrentFrame(l.CheckInt(2)); err != nil {
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	return 0
+}
+
+func luaGetFrameCount(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(lua.LNumber(composite.FrameCount()))
+
+	return 1
+}
+
+func luaGetSetPlaying(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LBool(composite.IsPlaying()))
+		return 1
+	}
+
+	composite.SetPlaying(l.CheckBool(2))
+
+	return 0
+}
+
+func luaGetSetPlaySpeed(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(composite.PlaySpeed()))
+		return 1
+	}
+
+	composite.SetPlaySpeed(float64(l.CheckNumber(2)))
+
+	return 0
+}
+
+// luaGetLayers returns the codes of the layers of the current mode, e.g. {"hd", "tr", "lg"}.
+func luaGetLayers(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	result := l.NewTable()
+
+	for _, layerType := range composite.Layers() {
+		result.Append(lua.LString(strings.ToLower(layerType.String())))
+	}
+
+	l.Push(result)
+
+	return 1
+}
+
+// luaGetSetEquipment gets or sets the armor code of a layer, e.g. composite:equipment("tr", "hvy"). Setting it to nil
+// or "" hides the layer.
+func luaGetSetEquipment(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	layerType, err := layerTypeFromString(l.CheckString(2))
+
+	if err != nil {
+		l.ArgError(2, err.Error())
+		return 0
+	}
+
+	if l.GetTop() == 2 {
+		l.Push(lua.LString(composite.Equipment(layerType)))
+		return 1
+	}
+
+	composite.SetEquipment(layerType, l.OptString(3, ""))
+
+	return 0
+}
+
+func luaDestroy(l *lua.LState) int {
+	composite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	composite.Destroy()
+
+	return 0
+}
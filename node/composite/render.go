@@ -0,0 +1,145 @@
+package composite
+
+import (
+	"math"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer/atlas"
+)
+
+const (
+	// baseFrameRate is the number of frames a second the animations of Diablo II are played at, with a COF speed of
+	// speedDivisor.
+	baseFrameRate = 25
+	speedDivisor  = 256
+)
+
+func (c *Composite) update(elapsed float64) {
+	c.animate(elapsed)
+
+	for _, l := range c.layers {
+		if c.direction >= l.sequences.SequenceCount() {
+			continue
+		}
+
+		if l.regions == nil {
+			l.regions = make([]*atlas.Region, l.sequences.FrameCount(c.direction))
+		}
+
+		if c.currentFrame < len(l.regions) && l.regions[c.currentFrame] == nil {
+			c.initializeTexture(l)
+		}
+	}
+}
+
+// animate advances the animation in a loop, at the speed the COF gives.
+func (c *Composite) animate(elapsed float64) {
+	if !c.playing || c.playSpeed <= 0 || c.cof.FramesPerDirection == 0 {
+		return
+	}
+
+	frameRate := float64(baseFrameRate)
+
+	if c.cof.Speed > 0 {
+		frameRate = baseFrameRate * float64(c.cof.Speed) / speedDivisor
+	}
+
+	frameLength := 1 / (frameRate * c.playSpeed)
+	c.lastFrameTime += elapsed
+	framesAdvanced := int(c.lastFrameTime / frameLength)
+	c.lastFrameTime -= float64(framesAdvanced) * frameLength
+
+	c.currentFrame = (c.currentFrame + framesAdvanced) % c.cof.FramesPerDirection
+}
+
+// render draws the layers in the order the COF gives for the current direction and frame.
+func (c *Composite) render() {
+	if !c.Visible || !c.Active || c.direction >= len(c.cof.Priority) {
+		return
+	}
+
+	tex := common.PaletteTexture[c.palette]
+	if !tex.Init {
+		tex.Texture = c.renderer.LoadPaletteTexture(tex.Data, common.PaletteTransformsCount)
+
+		tex.Init = true
+	}
+
+	tint := c.WorldTint()
+
+	if tint.A == 0 {
+		return
+	}
+
+	transform := c.WorldTransform()
+
+	for _, layerType := range c.cof.Priority[c.direction][c.currentFrame] {
+		l, ok := c.layers[layerType]
+
+		if !ok || c.currentFrame >= len(l.regions) || l.regions[c.currentFrame] == nil {
+			continue
+		}
+
+		region := l.regions[c.currentFrame]
+
+		if region.Empty() {
+			continue
+		}
+
+		layerTint := tint
+		layerTint.A = uint8(math.Round(float64(tint.A) * l.opacity))
+		posX, posY := c.frameOffset(l)
+
+		c.renderer.DrawIndexedTextureRegion(region.Texture(), tex.Texture, 0, region.Rect(),
+			transform.Multiply(renderer.Translation(float64(posX), float64(posY))), layerTint, l.blendMode)
+	}
+}
+
+// frameOffset returns where the current frame of a layer is drawn, relative to the composite's position.
+func (c *Composite) frameOffset(l *layer) (X, Y int) {
+	posX := l.sequences.GetFrameOffsetX(c.direction, c.currentFrame)
+	posY := l.sequences.GetFrameOffsetY(c.direction, c.currentFrame)
+	posY -= l.sequences.FrameHeight(c.direction, c.currentFrame)
+
+	return posX, posY
+}
+
+// bounds returns the rectangle that holds the current frame of every layer.
+func (c *Composite) bounds() (x, y, width, height float64, ok bool) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, l := range c.layers {
+		if c.currentFrame >= len(l.regions) || l.regions[c.currentFrame] == nil {
+			continue
+		}
+
+		region := l.regions[c.currentFrame]
+		posX, posY := c.frameOffset(l)
+		minX, minY = math.Min(minX, float64(posX)), math.Min(minY, float64(posY))
+		maxX = math.Max(maxX, float64(posX+region.Width()))
+		maxY = math.Max(maxY, float64(posY+region.Height()))
+	}
+
+	if math.IsInf(minX, 1) {
+		return 0, 0, 0, 0, false
+	}
+
+	return minX, minY, maxX - minX, maxY - minY, true
+}
+
+// initializeTexture packs the current frame of a layer into the atlas.
+func (c *Composite) initializeTexture(l *layer) {
+	width := l.sequences.FrameWidth(c.direction, c.currentFrame)
+	height := l.sequences.FrameHeight(c.direction, c.currentFrame)
+	pixels := make([]byte, width*height)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pixels[x+(y*width)] = l.sequences.GetColorIndexAt(c.direction, c.currentFrame, x, y)
+		}
+	}
+
+	l.regions[c.currentFrame] = c.atlas.Add(pixels, width, height)
+}
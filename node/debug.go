@@ -0,0 +1,105 @@
+package node
+
+import (
+	"math"
+)
+
+// Bounds is a rectangle on the surface.
+type Bounds struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// WorldBounds returns the smallest rectangle on the surface that holds what the node draws, once it is transformed.
+// It fails for nodes that do not know where they draw (see BoundsCallback).
+func (e *Node) WorldBounds() (Bounds, bool) {
+	if e.BoundsCallback == nil {
+		return Bounds{}, false
+	}
+
+	x, y, width, height, ok := e.BoundsCallback()
+
+	if !ok {
+		return Bounds{}, false
+	}
+
+	transform := e.WorldTransform()
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, corner := range [4][2]float64{{x, y}, {x + width, y}, {x, y + height}, {x + width, y + height}} {
+		cornerX, cornerY := transform.Apply(corner[0], corner[1])
+		minX, minY = math.Min(minX, cornerX), math.Min(minY, cornerY)
+		maxX, maxY = math.Max(maxX, cornerX), math.Max(maxY, cornerY)
+	}
+
+	result := Bounds{
+		X:      int(math.Floor(minX)),
+		Y:      int(math.Floor(minY)),
+		Width:  int(math.Ceil(maxX) - math.Floor(minX)),
+		Height: int(math.Ceil(maxY) - math.Floor(minY)),
+	}
+
+	return result, true
+}
+
+// DebugInfo describes a node and its children, for the debug overlay and bug reports.
+type DebugInfo struct {
+	Id          string      `json:"id"`
+	Name        string      `json:"name,omitempty"`
+	Type        string      `json:"type"`
+	Tags        []string    `json:"tags,omitempty"`
+	X           float64     `json:"x"`
+	Y           float64     `json:"y"`
+	WorldX      int         `json:"worldX"`
+	WorldY      int         `json:"worldY"`
+	Bounds      *Bounds     `json:"bounds,omitempty"`
+	Active      bool        `json:"active"`
+	Visible     bool        `json:"visible"`
+	Interactive bool        `json:"interactive"`
+	ZIndex      int         `json:"zIndex"`
+	Layer       string      `json:"layer"`
+	Children    []DebugInfo `json:"children,omitempty"`
+}
+
+func (e *Node) DebugInfo() DebugInfo {
+	result := DebugInfo{
+		Id:          e.Id.String(),
+		Name:        e.Name,
+		Type:        e.Type,
+		Tags:        e.Tags,
+		X:           e.X,
+		Y:           e.Y,
+		Active:      e.Active,
+		Visible:     e.Visible,
+		Interactive: e.Interactive,
+		ZIndex:      e.ZIndex,
+		Layer:       e.Layer.ToString(),
+		Children:    make([]DebugInfo, len(e.Children)),
+	}
+
+	result.WorldX, result.WorldY = e.GetPosition()
+
+	if bounds, ok := e.WorldBounds(); ok {
+		result.Bounds = &bounds
+	}
+
+	for idx := range e.Children {
+		result.Children[idx] = e.Children[idx].DebugInfo()
+	}
+
+	return result
+}
+
+// Count returns the number of nodes in the tree described by the info.
+func (d *DebugInfo) Count() int {
+	result := 1
+
+	for idx := range d.Children {
+		result += d.Children[idx].Count()
+	}
+
+	return result
+}
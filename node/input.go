@@ -0,0 +1,147 @@
+package node
+
+import (
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/input"
+)
+
+// MouseEvent is a mouse event on its way through the node tree.
+type MouseEvent struct {
+	input.MouseEvent
+
+	// Target is the node the event was sent to. The event then bubbles up through the target's ancestors.
+	Target  *Node
+	stopped bool
+}
+
+// StopPropagation stops the event from being sent to any more nodes.
+func (m *MouseEvent) StopPropagation() {
+	m.stopped = true
+}
+
+func (m *MouseEvent) Stopped() bool {
+	return m.stopped
+}
+
+// HitTest returns the topmost node at the given point that takes mouse input, or nil if there is none. Nodes are tested
+// in the reverse of the order they are drawn in: the top render layer first, and within a layer, children before their
+// parents and later siblings before earlier ones. Nodes that are not active, visible or interactive, along with their
+// children, are skipped.
+func (e *Node) HitTest(x, y int) *Node {
+	layer := e.EffectiveLayer()
+
+	for idx := len(RenderLayers) - 1; idx >= 0; idx-- {
+		if result := e.hitTestLayer(x, y, RenderLayers[idx], layer); result != nil {
+			return result
+		}
+	}
+
+	return nil
+}
+
+func (e *Node) hitTestLayer(x, y int, renderLayer, layer RenderLayer) *Node {
+	if !e.Active || !e.Visible || !e.Interactive {
+		return nil
+	}
+
+	children := e.drawOrder()
+
+	for idx := len(children) - 1; idx >= 0; idx-- {
+		if result := children[idx].hitTestLayer(x, y, renderLayer, childLayer(children[idx], layer)); result != nil {
+			return result
+		}
+	}
+
+	if layer == renderLayer && e.MouseCallback != nil && e.HitTestCallback != nil && e.HitTestCallback(x, y) {
+		return e
+	}
+
+	return nil
+}
+
+// DispatchMouseEvent sends the event to the target node, and then to each of its ancestors, until one of them stops
+// its propagation. It returns true if the event was stopped.
+func DispatchMouseEvent(target *Node, event *MouseEvent) bool {
+	event.Target = target
+
+	for current := target; current != nil && !event.stopped; current = current.Parent {
+		if current.MouseCallback != nil && current.Active {
+			current.MouseCallback(event)
+		}
+	}
+
+	return event.stopped
+}
+
+// MouseDispatcher sends the mouse events of each frame through a node tree. Events go to the topmost node under the
+// mouse, except for the button, click and drag events after a node captures the mouse, which go to that node. When
+// the topmost node under the mouse changes, the old one gets a leave event and the new one an over event; these do
+// not bubble.
+type MouseDispatcher struct {
+	mouse   common.MouseProvider
+	hovered *Node
+}
+
+func NewMouseDispatcher(mouse common.MouseProvider) *MouseDispatcher {
+	result := &MouseDispatcher{
+		mouse: mouse,
+	}
+
+	return result
+}
+
+// Dispatch sends the mouse events of the current frame through the tree, and returns the ones that no node stopped.
+func (d *MouseDispatcher) Dispatch(root *Node) []input.MouseEvent {
+	result := make([]input.MouseEvent, 0)
+
+	// the hover is updated first, so that nodes know whether the mouse is over them when they get its events. The tree
+	// may have changed under a mouse that did not move, so it is checked every frame.
+	x, y := d.mouse.GetMousePosition()
+	d.setHovered(root.HitTest(x, y), x, y)
+
+	for _, event := range d.mouse.Events() {
+		target := root.HitTest(event.X, event.Y)
+
+		if captured, ok := d.mouse.Captured().(*Node); ok && event.HasButton() && event.Type != input.MouseEventDown {
+			target = captured
+		}
+
+		if target == nil || !DispatchMouseEvent(target, &MouseEvent{MouseEvent: event}) {
+			result = append(result, event)
+		}
+	}
+
+	return result
+}
+
+func (d *MouseDispatcher) setHovered(node *Node, x, y int) {
+	if node == d.hovered {
+		return
+	}
+
+	if d.hovered != nil && d.hovered.MouseCallback != nil {
+		d.hovered.MouseCallback(&MouseEvent{
+			MouseEvent: input.MouseEvent{Type: input.MouseEventLeave, X: x, Y: y},
+			Target:     d.hovered,
+		})
+	}
+
+	d.hovered = node
+
+	if node != nil {
+		node.MouseCallback(&MouseEvent{
+			MouseEvent: input.MouseEvent{Type: input.MouseEventOver, X: x, Y: y},
+			Target:     node,
+		})
+	}
+}
+
+// Hovered returns the topmost node under the mouse that takes mouse input, as of the last dispatch.
+func (d *MouseDispatcher) Hovered() *Node {
+	return d.hovered
+}
+
+// Reset forgets the hovered node without sending it a leave event, for when the tree is thrown away.
+func (d *MouseDispatcher) Reset() {
+	d.hovered = nil
+}
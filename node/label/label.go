@@ -1,18 +1,17 @@
 package label
 
 import (
-	bytes2 "bytes"
 	"errors"
-	"image"
-	"io"
 	"strings"
 
-	rl "github.com/gen2brain/raylib-go/raylib"
-
 	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/loader"
 	"github.com/OpenDiablo2/AbyssEngine/node"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer/atlas"
 	dc6 "github.com/OpenDiablo2/dc6/pkg"
 	tblfont "github.com/OpenDiablo2/tbl_font/pkg"
+	"github.com/rs/zerolog/log"
 )
 
 type LabelAlign int
@@ -52,25 +51,48 @@ func StringToLabelAlign(s string) (LabelAlign, error) {
 type Label struct {
 	*node.Node
 
-	initialized bool
-	hasTexture  bool
-	texture     rl.Texture2D
-	FontTable   *tblfont.FontTable
-	FontGfx     common.SequenceProvider
-	Palette     string
-	Caption     string
-	color       int
-	HAlign      LabelAlign
-	VAlign      LabelAlign
+	renderer         renderer.Renderer
+	resourceProvider common.ResourceProvider
+	resources        []common.ResourceHandle
+	fontPath         string
+	atlas            *atlas.Atlas
+	initialized      bool
+	glyphs           map[rune]*atlas.Region
+	glyphKeys        map[rune]atlas.GlyphKey
+	characters       []character
+	width            int
+	height           int
+	FontTable        *tblfont.FontTable
+	FontGfx          common.SequenceProvider
+	Palette          string
+	Caption          string
+	color            int
+	HAlign           LabelAlign
+	VAlign           LabelAlign
+}
+
+// character is a glyph of the caption, and where it is drawn from the top left corner of the text.
+type character struct {
+	glyph *atlas.Region
+	x     int
 }
 
-func New(loaderProvider common.LoaderProvider, fontPath, palette string) (*Label, error) {
+// New loads a label, whose glyphs are packed into the atlas as the caption needs them. Glyphs are shared with the other
+// labels that use the same font.
+func New(resourceProvider common.ResourceProvider, renderProvider renderer.Renderer, textureAtlas *atlas.Atlas,
+	fontPath, palette string) (*Label, error) {
 	result := &Label{
-		Node:        node.New(),
-		initialized: false,
-		HAlign:      LabelAlignStart,
-		VAlign:      LabelAlignStart,
-		color:       5,
+		Node:             node.New(),
+		renderer:         renderProvider,
+		atlas:            textureAtlas,
+		glyphs:           make(map[rune]*atlas.Region),
+		glyphKeys:        make(map[rune]atlas.GlyphKey),
+		resourceProvider: resourceProvider,
+		fontPath:         fontPath,
+		initialized:      false,
+		HAlign:           LabelAlignStart,
+		VAlign:           LabelAlignStart,
+		color:            5,
 	}
 
 	_, ok := common.PaletteTexture[palette]
@@ -79,140 +101,209 @@ func New(loaderProvider common.LoaderProvider, fontPath, palette string) (*Label
 	}
 	result.Palette = palette
 
-	fontTableStream, err := loaderProvider.Load(fontPath + ".tbl")
-	defer fontTableStream.Close()
-
-	if err != nil {
+	if err := result.loadFont(); err != nil {
 		return nil, err
 	}
 
-	// hack: mpq block stream is bugged
-	fontTableData, _ := io.ReadAll(fontTableStream)
-	fontTable, err := tblfont.Load(bytes2.NewReader(fontTableData))
-
-	if err != nil {
-		return nil, err
-	}
+	result.RenderCallback = result.render
+	result.UpdateCallback = result.update
+	result.ToLuaCallback = result.ToLua
+	result.BoundsCallback = result.bounds
+	result.Type = luaTypeExportName
+	result.ReloadCallback = result.reload
+	result.DestroyCallback = result.Destroy
 
-	result.FontTable = fontTable
+	return result, nil
+}
 
-	fontSpriteStream, err := loaderProvider.Load(fontPath + ".dc6")
-	defer fontSpriteStream.Close()
+func (l *Label) loadFont() error {
+	fontTable, err := l.resourceProvider.Acquire(l.fontPath+".tbl", common.ResourceTypeFontTable)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// hack: mpq block stream is bugged
-	fontSpriteData, _ := io.ReadAll(fontSpriteStream)
-	fontSprite, err := dc6.FromBytes(fontSpriteData)
+	fontSprite, err := l.resourceProvider.Acquire(l.fontPath+".dc6", common.ResourceTypeDC6)
 
 	if err != nil {
-		return nil, err
+		fontTable.Release()
+		return err
 	}
 
-	result.FontGfx = &common.DC6SequenceProvider{Sequences: fontSprite.Directions}
+	l.releaseResources()
 
-	result.RenderCallback = result.render
-	result.UpdateCallback = result.update
+	l.resources = []common.ResourceHandle{fontTable, fontSprite}
+	l.FontTable = fontTable.Value().(*tblfont.FontTable)
+	l.FontGfx = &common.DC6SequenceProvider{Sequences: fontSprite.Value().(*dc6.DC6).Directions}
 
-	return result, nil
+	return nil
 }
 
-func (l *Label) render() {
-	if !l.initialized || len(l.Caption) == 0 {
+// reload loads the font again if either of its files is the one that changed, and redraws the caption.
+func (l *Label) reload(changedPath string) {
+	changedPath = loader.NormalizePath(changedPath)
+	fontPath := loader.NormalizePath(l.fontPath)
+
+	if !strings.EqualFold(changedPath, fontPath+".tbl") && !strings.EqualFold(changedPath, fontPath+".dc6") {
 		return
 	}
 
-	tex := common.PaletteTexture[l.Palette]
-	if !tex.Init {
-		img := rl.NewImage(tex.Data, 256, int32(common.PaletteTransformsCount), 1, rl.UncompressedR8g8b8a8)
-		tex.Texture = rl.LoadTextureFromImage(img)
+	if err := l.loadFont(); err != nil {
+		log.Error().Err(err).Msgf("failed to reload font %s", l.fontPath)
+		return
+	}
 
-		tex.Init = true
+	l.releaseGlyphs()
+	l.initialized = false
+}
+
+func (l *Label) Destroy() {
+	l.ShouldRemove = true
+	l.Active = false
+
+	l.releaseGlyphs()
+	l.releaseResources()
+}
+
+// releaseGlyphs releases the glyphs the label acquired from the atlas, with the keys they were acquired with.
+func (l *Label) releaseGlyphs() {
+	for r, key := range l.glyphKeys {
+		l.atlas.ReleaseGlyph(key)
+		delete(l.glyphKeys, r)
+		delete(l.glyphs, r)
 	}
 
-	posX, posY := l.GetPosition()
+	l.characters = nil
+}
+
+func (l *Label) releaseResources() {
+	for idx := range l.resources {
+		l.resources[idx].Release()
+	}
+
+	l.resources = nil
+}
+
+// alignOffset returns where the text is drawn relative to the label's position, according to its alignment.
+func (l *Label) alignOffset() (X, Y int) {
+	posX, posY := 0, 0
 
 	switch l.HAlign {
 	case LabelAlignCenter:
-		posX -= int(l.texture.Width / 2)
+		posX -= l.width / 2
 	case LabelAlignEnd:
-		posX -= int(l.texture.Width)
+		posX -= l.width
 	}
 
 	switch l.VAlign {
 	case LabelAlignCenter:
-		posY -= int(l.texture.Height / 2)
+		posY -= l.height / 2
 	case LabelAlignEnd:
-		posY -= int(l.texture.Height)
+		posY -= l.height
 	}
 
-	rl.BeginShaderMode(common.PaletteShader)
-	rl.SetShaderValueTexture(common.PaletteShader, common.PaletteShaderLoc, tex.Texture)
-	rl.SetShaderValue(common.PaletteShader, common.PaletteShaderOffsetLoc, []float32{float32(l.color+common.PaletteTextShiftOffset) / float32(common.PaletteTransformsCount-1)}, rl.ShaderUniformFloat)
-	rl.DrawTexture(l.texture, int32(posX), int32(posY), rl.White)
-	rl.EndShaderMode()
+	return posX, posY
+}
 
+func (l *Label) bounds() (x, y, width, height float64, ok bool) {
+	if !l.initialized || len(l.Caption) == 0 || l.width == 0 || l.height == 0 {
+		return 0, 0, 0, 0, false
+	}
+
+	posX, posY := l.alignOffset()
+
+	return float64(posX), float64(posY), float64(l.width), float64(l.height), true
+}
+
+func (l *Label) render() {
+	if !l.initialized || len(l.Caption) == 0 {
+		return
+	}
+
+	tex := common.PaletteTexture[l.Palette]
+	if !tex.Init {
+		tex.Texture = l.renderer.LoadPaletteTexture(tex.Data, common.PaletteTransformsCount)
+
+		tex.Init = true
+	}
+
+	tint := l.WorldTint()
+
+	if tint.A == 0 {
+		return
+	}
+
+	posX, posY := l.alignOffset()
+	paletteOffset := float32(l.color+common.PaletteTextShiftOffset) / float32(common.PaletteTransformsCount-1)
+	transform := l.WorldTransform().Multiply(renderer.Translation(float64(posX), float64(posY)))
+
+	for _, c := range l.characters {
+		if c.glyph.Empty() {
+			continue
+		}
+
+		l.renderer.DrawIndexedTextureRegion(c.glyph.Texture(), tex.Texture, paletteOffset, c.glyph.Rect(),
+			transform.Multiply(renderer.Translation(float64(c.x), 0)), tint, renderer.BlendModeNone)
+	}
 }
 
 func (l *Label) update(elapsed float64) {
 	if !l.initialized && len(l.Caption) > 0 {
 		l.initialized = true
-		l.initializeTexture()
+		l.layoutCaption()
 	}
 }
 
-func (l *Label) initializeTexture() {
-	width := 0
-	height := 0
-
-	charOffsets := make([]image.Point, len(l.Caption))
+// layoutCaption lays out the glyphs of the caption, acquiring the ones the label has not used before from the atlas.
+func (l *Label) layoutCaption() {
+	l.characters = make([]character, 0, len(l.Caption))
+	l.width, l.height = 0, 0
 
 	for idx := range l.Caption {
-		charOffsets[idx] = image.Point{X: width, Y: 0}
-		glyph := l.FontTable.Glyphs[rune(l.Caption[idx])]
-		width += glyph.Width()
-		gHeight := glyph.Height()
-		if gHeight > height {
-			height = gHeight
-		}
-	}
+		r := rune(l.Caption[idx])
+		glyph := l.FontTable.Glyphs[r]
 
-	pixels := make([]byte, width*height)
+		if _, ok := l.glyphs[r]; !ok {
+			l.glyphKeys[r] = l.glyphKey(r)
+			l.glyphs[r] = l.atlas.AcquireGlyph(l.glyphKeys[r], func() ([]byte, int, int) { return l.loadGlyph(r) })
+		}
 
-	for idx := range l.Caption {
-		glyph := l.FontTable.Glyphs[rune(l.Caption[idx])]
-		frameIdx := glyph.FrameIndex()
-		glyphWidth := glyph.Width()
-		glyphHeight := glyph.Height()
+		l.characters = append(l.characters, character{glyph: l.glyphs[r], x: l.width})
+		l.width += glyph.Width()
 
-		if glyphWidth == 0 || glyphHeight == 0 {
-			continue
+		if glyph.Height() > l.height {
+			l.height = glyph.Height()
 		}
+	}
+}
 
-		glyphOriginY := (l.FontGfx.FrameHeight(0, frameIdx) - glyphHeight) - 1
+func (l *Label) glyphKey(r rune) atlas.GlyphKey {
+	return atlas.GlyphKey{Font: strings.ToLower(loader.NormalizePath(l.fontPath)), Rune: r}
+}
 
-		for y := 0; y < glyphHeight; y++ {
-			if y+glyphOriginY < 0 {
-				continue
-			}
+// loadGlyph returns the image of a glyph, to be packed into the atlas.
+func (l *Label) loadGlyph(r rune) (pixels []byte, width, height int) {
+	glyph := l.FontTable.Glyphs[r]
+	frameIdx := glyph.FrameIndex()
+	glyphWidth := glyph.Width()
+	glyphHeight := glyph.Height()
 
-			for x := 0; x < glyphWidth; x++ {
-				c := l.FontGfx.GetColorIndexAt(0, frameIdx, x, y+glyphOriginY)
-				idx := (charOffsets[idx].X + x) + ((charOffsets[idx].Y + y) * width)
-				pixels[idx] = c
-			}
-		}
+	if glyphWidth == 0 || glyphHeight == 0 {
+		return nil, 0, 0
 	}
 
-	img := rl.NewImage(pixels, int32(width), int32(height), 1, rl.UncompressedGrayscale)
+	pixels = make([]byte, glyphWidth*glyphHeight)
+	glyphOriginY := (l.FontGfx.FrameHeight(0, frameIdx) - glyphHeight) - 1
 
-	if !l.hasTexture {
-		l.hasTexture = true
-	} else {
-		rl.UnloadTexture(l.texture)
+	for y := 0; y < glyphHeight; y++ {
+		if y+glyphOriginY < 0 {
+			continue
+		}
+
+		for x := 0; x < glyphWidth; x++ {
+			pixels[x+(y*glyphWidth)] = l.FontGfx.GetColorIndexAt(0, frameIdx, x, y+glyphOriginY)
+		}
 	}
 
-	l.texture = rl.LoadTextureFromImage(img)
+	return pixels, glyphWidth, glyphHeight
 }
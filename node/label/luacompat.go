@@ -16,9 +16,23 @@ var LuaTypeExport = common.LuaTypeExport{
 		"caption":   luaGetSetCaption,
 		"position":  luaGetSetPosition,
 		"alignment": luaGetSetAlignment,
+		"destroy":   luaDestroy,
 	},
 }
 
+func luaDestroy(l *lua.LState) int {
+	label, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	label.Destroy()
+
+	return 0
+}
+
 func luaGetSetAlignment(l *lua.LState) int {
 	label, err := FromLua(l.ToUserData(1))
 
@@ -70,8 +84,8 @@ func luaGetSetPosition(l *lua.LState) int {
 	posX := l.ToNumber(2)
 	posY := l.ToNumber(3)
 
-	label.X = int(posX)
-	label.Y = int(posY)
+	label.X = float64(posX)
+	label.Y = float64(posY)
 
 	return 0
 }
@@ -2,6 +2,8 @@ package node
 
 import (
 	"fmt"
+	"image/color"
+	"strings"
 
 	"github.com/OpenDiablo2/AbyssEngine/common"
 	lua "github.com/yuin/gopher-lua"
@@ -14,6 +16,24 @@ var LuaTypeExport = common.LuaTypeExport{
 	Methods: map[string]lua.LGFunction{
 		"appendChild": luaAppendChild,
 		"removeChild": luaRemoveChild,
+		"zIndex":      luaGetSetZIndex,
+		"layer":       luaGetSetLayer,
+		"position":    luaGetSetPosition,
+		"scale":       luaGetSetScale,
+		"rotation":    luaGetSetRotation,
+		"pivot":       luaGetSetPivot,
+		"opacity":     luaGetSetOpacity,
+		"tint":        luaGetSetTint,
+		"id":          luaGetId,
+		"name":        luaGetSetName,
+		"tags":        luaGetTags,
+		"hasTag":      luaHasTag,
+		"addTag":      luaAddTag,
+		"removeTag":   luaRemoveTag,
+		"find":        luaFind,
+		"findByTag":   luaFindByTag,
+		"parent":      luaGetParent,
+		"children":    luaGetChildren,
 	},
 }
 
@@ -26,6 +46,16 @@ func (e *Node) ToLua(l *lua.LState) *lua.LUserData {
 	return result
 }
 
+// ToLuaValue returns the Lua value of what the node belongs to, such as a sprite, or of the node itself if it does not
+// belong to anything.
+func (e *Node) ToLuaValue(l *lua.LState) *lua.LUserData {
+	if e.ToLuaCallback != nil {
+		return e.ToLuaCallback(l)
+	}
+
+	return e.ToLua(l)
+}
+
 func FromLua(ud *lua.LUserData) (*Node, error) {
 	v, ok := ud.Value.(*Node)
 
@@ -36,6 +66,23 @@ func FromLua(ud *lua.LUserData) (*Node, error) {
 	return v, nil
 }
 
+// FromLuaValue returns the node of a Lua value that is a node, or that embeds one (such as a sprite or a label).
+func FromLuaValue(value lua.LValue) (*Node, error) {
+	ud, ok := value.(*lua.LUserData)
+
+	if !ok {
+		return nil, fmt.Errorf("expected a node")
+	}
+
+	v, ok := ud.Value.(interface{ GetNode() *Node })
+
+	if !ok {
+		return nil, fmt.Errorf("expected a node")
+	}
+
+	return v.GetNode(), nil
+}
+
 func luaRemoveChild(l *lua.LState) int {
 	if l.GetTop() != 2 {
 		l.ArgError(1, "argument expected")
@@ -85,3 +132,375 @@ func luaAppendChild(l *lua.LState) int {
 
 	return 0
 }
+
+func luaGetSetZIndex(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(self.ZIndex))
+		return 1
+	}
+
+	self.ZIndex = l.CheckInt(2)
+
+	return 0
+}
+
+func luaGetSetLayer(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LString(self.Layer.ToString()))
+		return 1
+	}
+
+	layer, err := StringToRenderLayer(l.CheckString(2))
+
+	if err != nil {
+		l.ArgError(2, err.Error())
+		return 0
+	}
+
+	self.Layer = layer
+
+	return 0
+}
+
+func luaGetSetPosition(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(self.X))
+		l.Push(lua.LNumber(self.Y))
+		return 2
+	}
+
+	self.X = float64(l.CheckNumber(2))
+	self.Y = float64(l.CheckNumber(3))
+
+	return 0
+}
+
+// luaGetSetScale gets or sets the horizontal and vertical scale. Setting a single value scales both.
+func luaGetSetScale(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(self.ScaleX))
+		l.Push(lua.LNumber(self.ScaleY))
+		return 2
+	}
+
+	self.ScaleX = float64(l.CheckNumber(2))
+	self.ScaleY = float64(l.OptNumber(3, lua.LNumber(self.ScaleX)))
+
+	return 0
+}
+
+func luaGetSetRotation(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(self.Rotation))
+		return 1
+	}
+
+	self.Rotation = float64(l.CheckNumber(2))
+
+	return 0
+}
+
+func luaGetSetPivot(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(self.PivotX))
+		l.Push(lua.LNumber(self.PivotY))
+		return 2
+	}
+
+	self.PivotX = float64(l.CheckNumber(2))
+	self.PivotY = float64(l.CheckNumber(3))
+
+	return 0
+}
+
+func luaGetSetOpacity(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(self.Opacity))
+		return 1
+	}
+
+	opacity := float64(l.CheckNumber(2))
+
+	if opacity < 0 || opacity > 1 {
+		l.ArgError(2, "opacity must be between 0 and 1")
+		return 0
+	}
+
+	self.Opacity = opacity
+
+	return 0
+}
+
+// luaGetSetTint gets or sets the red, green, blue and (optionally) alpha the node is multiplied by, from 0 to 255.
+func luaGetSetTint(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(self.Tint.R))
+		l.Push(lua.LNumber(self.Tint.G))
+		l.Push(lua.LNumber(self.Tint.B))
+		l.Push(lua.LNumber(self.Tint.A))
+		return 4
+	}
+
+	channels := [4]uint8{0, 0, 0, 255}
+
+	for idx := range channels {
+		if idx == 3 && l.GetTop() < 5 {
+			break
+		}
+
+		value := l.CheckInt(idx + 2)
+
+		if value < 0 || value > 255 {
+			l.ArgError(idx+2, "color channels must be between 0 and 255")
+			return 0
+		}
+
+		channels[idx] = uint8(value)
+	}
+
+	self.Tint = color.NRGBA{R: channels[0], G: channels[1], B: channels[2], A: channels[3]}
+
+	return 0
+}
+
+func luaGetId(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(lua.LString(self.Id.String()))
+
+	return 1
+}
+
+func luaGetSetName(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LString(self.Name))
+		return 1
+	}
+
+	name := l.CheckString(2)
+
+	if strings.Contains(name, "/") {
+		l.ArgError(2, "node names cannot contain a slash")
+		return 0
+	}
+
+	self.Name = name
+
+	return 0
+}
+
+func luaGetTags(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	result := l.NewTable()
+
+	for _, tag := range self.Tags {
+		result.Append(lua.LString(tag))
+	}
+
+	l.Push(result)
+
+	return 1
+}
+
+func luaHasTag(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(lua.LBool(self.HasTag(l.CheckString(2))))
+
+	return 1
+}
+
+// luaAddTag gives the node each of the tags passed to it.
+func luaAddTag(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() < 2 {
+		l.ArgError(1, "argument expected")
+		return 0
+	}
+
+	for idx := 2; idx <= l.GetTop(); idx++ {
+		self.AddTag(l.CheckString(idx))
+	}
+
+	return 0
+}
+
+func luaRemoveTag(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() < 2 {
+		l.ArgError(1, "argument expected")
+		return 0
+	}
+
+	for idx := 2; idx <= l.GetTop(); idx++ {
+		self.RemoveTag(l.CheckString(idx))
+	}
+
+	return 0
+}
+
+func luaFind(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	result := self.Find(l.CheckString(2))
+
+	if result == nil {
+		l.Push(lua.LNil)
+		return 1
+	}
+
+	l.Push(result.ToLuaValue(l))
+
+	return 1
+}
+
+func luaFindByTag(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(toLuaTable(l, self.FindByTag(l.CheckString(2))))
+
+	return 1
+}
+
+func luaGetParent(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if self.Parent == nil {
+		l.Push(lua.LNil)
+		return 1
+	}
+
+	l.Push(self.Parent.ToLuaValue(l))
+
+	return 1
+}
+
+func luaGetChildren(l *lua.LState) int {
+	self, ok := l.ToUserData(1).Value.(*Node)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(toLuaTable(l, self.Children))
+
+	return 1
+}
+
+// toLuaTable returns a Lua array of the values of nodes, see ToLuaValue.
+func toLuaTable(l *lua.LState, nodes []*Node) *lua.LTable {
+	result := l.CreateTable(len(nodes), 0)
+
+	for _, n := range nodes {
+		result.Append(n.ToLuaValue(l))
+	}
+
+	return result
+}
@@ -0,0 +1,244 @@
+package maprenderer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2ds1"
+	lua "github.com/yuin/gopher-lua"
+)
+
+var luaTypeExportName = "mapRenderer"
+var LuaTypeExport = common.LuaTypeExport{
+	Name: luaTypeExportName,
+	//ConstructorFunc: newLuaEntity,
+	Methods: map[string]lua.LGFunction{
+		"node":         luaGetNode,
+		"position":     luaGetSetPosition,
+		"visible":      luaGetSetVisible,
+		"active":       luaGetSetActive,
+		"size":         luaGetSize,
+		"viewport":     luaGetSetViewport,
+		"extent":       luaGetExtent,
+		"tileToWorld":  luaTileToWorld,
+		"worldToTile":  luaWorldToTile,
+		"layerVisible": luaGetSetLayerVisible,
+		"destroy":      luaDestroy,
+	},
+}
+
+func (m *MapRenderer) ToLua(l *lua.LState) *lua.LUserData {
+	result := l.NewUserData()
+	result.Value = m
+
+	l.SetMetatable(result, l.GetTypeMetatable(luaTypeExportName))
+
+	return result
+}
+
+func FromLua(ud *lua.LUserData) (*MapRenderer, error) {
+	v, ok := ud.Value.(*MapRenderer)
+
+	if !ok {
+		return nil, fmt.Errorf("failed to convert")
+	}
+
+	return v, nil
+}
+
+// stringToLayerGroup returns the layer group with the given name: floor, wall or shadow.
+func stringToLayerGroup(name string) (d2ds1.LayerGroupType, error) {
+	for _, group := range []d2ds1.LayerGroupType{d2ds1.FloorLayerGroup, d2ds1.WallLayerGroup,
+		d2ds1.ShadowLayerGroup} {
+		if strings.EqualFold(group.String(), name) {
+			return group, nil
+		}
+	}
+
+	return -1, fmt.Errorf("unknown layer %q", name)
+}
+
+func luaGetNode(l *lua.LState) int {
+	mapRenderer, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(mapRenderer.Node.ToLua(l))
+
+	return 1
+}
+
+func luaGetSetPosition(l *lua.LState) int {
+	mapRenderer, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(mapRenderer.X))
+		l.Push(lua.LNumber(mapRenderer.Y))
+		return 2
+	}
+
+	mapRenderer.X = float64(l.ToNumber(2))
+	mapRenderer.Y = float64(l.ToNumber(3))
+
+	return 0
+}
+
+func luaGetSetVisible(l *lua.LState) int {
+	mapRenderer, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LBool(mapRenderer.Visible))
+		return 1
+	}
+
+	mapRenderer.Visible = l.CheckBool(2)
+
+	return 0
+}
+
+func luaGetSetActive(l *lua.LState) int {
+	mapRenderer, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LBool(mapRenderer.Active))
+		return 1
+	}
+
+	mapRenderer.Active = l.CheckBool(2)
+
+	return 0
+}
+
+// luaGetSize returns the width and height of the map, in tiles.
+func luaGetSize(l *lua.LState) int {
+	mapRenderer, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	width, height := mapRenderer.Size()
+	l.Push(lua.LNumber(width))
+	l.Push(lua.LNumber(height))
+
+	return 2
+}
+
+// luaGetSetViewport gets or sets the part of the map that is drawn, as x, y, width and height in map pixels. The size
+// is optional; see MapRenderer.Viewport.
+func luaGetSetViewport(l *lua.LState) int {
+	mapRenderer, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		x, y, width, height := mapRenderer.Viewport()
+		l.Push(lua.LNumber(x))
+		l.Push(lua.LNumber(y))
+		l.Push(lua.LNumber(width))
+		l.Push(lua.LNumber(height))
+
+		return 4
+	}
+
+	mapRenderer.SetViewport(float64(l.CheckNumber(2)), float64(l.CheckNumber(3)), l.OptInt(4, 0), l.OptInt(5, 0))
+
+	return 0
+}
+
+// luaGetExtent returns the rectangle the whole map covers, as x, y, width and height; see MapRenderer.Extent.
+func luaGetExtent(l *lua.LState) int {
+	mapRenderer, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	x, y, width, height := mapRenderer.Extent()
+	l.Push(lua.LNumber(x))
+	l.Push(lua.LNumber(y))
+	l.Push(lua.LNumber(width))
+	l.Push(lua.LNumber(height))
+
+	return 4
+}
+
+func luaTileToWorld(l *lua.LState) int {
+	x, y := TileToWorld(float64(l.CheckNumber(2)), float64(l.CheckNumber(3)))
+	l.Push(lua.LNumber(x))
+	l.Push(lua.LNumber(y))
+
+	return 2
+}
+
+func luaWorldToTile(l *lua.LState) int {
+	tileX, tileY := WorldToTile(float64(l.CheckNumber(2)), float64(l.CheckNumber(3)))
+	l.Push(lua.LNumber(tileX))
+	l.Push(lua.LNumber(tileY))
+
+	return 2
+}
+
+// luaGetSetLayerVisible gets or sets whether the floors, walls or shadows of the map are drawn, e.g.
+// map:layerVisible("wall", false).
+func luaGetSetLayerVisible(l *lua.LState) int {
+	mapRenderer, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	group, err := stringToLayerGroup(l.CheckString(2))
+
+	if err != nil {
+		l.ArgError(2, err.Error())
+		return 0
+	}
+
+	if l.GetTop() == 2 {
+		l.Push(lua.LBool(mapRenderer.IsGroupVisible(group)))
+		return 1
+	}
+
+	mapRenderer.SetGroupVisible(group, l.CheckBool(3))
+
+	return 0
+}
+
+func luaDestroy(l *lua.LState) int {
+	mapRenderer, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	mapRenderer.Destroy()
+
+	return 0
+}
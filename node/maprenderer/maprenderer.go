@@ -0,0 +1,308 @@
+package maprenderer
+
+import (
+	"errors"
+	"math"
+	"path"
+	"strings"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/loader"
+	"github.com/OpenDiablo2/AbyssEngine/node"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer/atlas"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2ds1"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2dt1"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// tileWidth and tileHeight are the size of the diamond a tile covers on the screen.
+	tileWidth  = 160
+	tileHeight = 80
+)
+
+// MapRenderer draws a DS1 map with the tiles of its DT1 tilesets, in isometric order. Only the tiles inside the
+// viewport and on the surface are drawn, with the top left corner of the viewport at the position of the node.
+//
+// The map is drawn in three passes, like Diablo II does: the floors, shadows and lower walls first, then the upper
+// walls, then the roofs.
+type MapRenderer struct {
+	*node.Node
+
+	renderer         renderer.Renderer
+	atlas            *atlas.Atlas
+	resourceProvider common.ResourceProvider
+	filePath         string
+	dt1Paths         []string
+	palette          string
+	ds1              *d2ds1.DS1
+	resource         common.ResourceHandle
+	tilesets         []tileset
+	tiles            map[tileKey][]*d2dt1.Tile
+	images           map[imageKey]*tileImage
+	drawables        [passCount][]drawable
+	viewportX        float64
+	viewportY        float64
+	viewportWidth    int
+	viewportHeight   int
+	hiddenGroups     map[d2ds1.LayerGroupType]bool
+}
+
+type tileset struct {
+	filePath string
+	resource common.ResourceHandle
+}
+
+// New loads a DS1 map, and the DT1 tilesets it is drawn with. When no DT1 files are given, the ones listed in the DS1
+// file are used. Tiles are packed into the atlas as they come into view.
+func New(resourceProvider common.ResourceProvider, renderProvider renderer.Renderer, textureAtlas *atlas.Atlas,
+	filePath, palette string, dt1Paths ...string) (*MapRenderer, error) {
+	result := &MapRenderer{
+		Node:             node.New(),
+		renderer:         renderProvider,
+		atlas:            textureAtlas,
+		resourceProvider: resourceProvider,
+		filePath:         filePath,
+		dt1Paths:         dt1Paths,
+		palette:          palette,
+		hiddenGroups:     make(map[d2ds1.LayerGroupType]bool),
+	}
+
+	result.RenderCallback = result.render
+	result.ToLuaCallback = result.ToLua
+	result.BoundsCallback = result.bounds
+	result.Type = luaTypeExportName
+	result.ReloadCallback = result.reload
+	result.DestroyCallback = result.Destroy
+
+	if _, ok := common.PaletteTexture[palette]; !ok {
+		return nil, errors.New("map loaded with non-existent palette")
+	}
+
+	if err := result.load(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// load loads the DS1 file and its tilesets, replacing the ones that are loaded.
+func (m *MapRenderer) load() error {
+	handle, err := m.resourceProvider.Acquire(m.filePath, common.ResourceTypeDS1)
+
+	if err != nil {
+		return err
+	}
+
+	m.unload()
+
+	m.resource = handle
+	m.ds1 = handle.Value().(*d2ds1.DS1)
+	m.tiles = make(map[tileKey][]*d2dt1.Tile)
+	m.images = make(map[imageKey]*tileImage)
+
+	dt1Paths := m.dt1Paths
+
+	if len(dt1Paths) == 0 {
+		dt1Paths = make([]string, 0, len(m.ds1.Files))
+
+		for _, file := range m.ds1.Files {
+			dt1Paths = append(dt1Paths, dt1Path(file))
+		}
+	}
+
+	for _, dt1Path := range dt1Paths {
+		m.loadTileset(dt1Path)
+	}
+
+	m.buildDrawables()
+
+	return nil
+}
+
+// loadTileset adds the tiles of a DT1 file to the ones the map is drawn with. Tilesets that cannot be loaded are
+// skipped, and the tiles the map needs from them are not drawn.
+func (m *MapRenderer) loadTileset(filePath string) {
+	handle, err := m.resourceProvider.Acquire(filePath, common.ResourceTypeDT1)
+
+	if err != nil {
+		log.Warn().Err(err).Msgf("failed to load the tileset %s", filePath)
+		return
+	}
+
+	m.tilesets = append(m.tilesets, tileset{filePath: filePath, resource: handle})
+
+	dt1 := handle.Value().(*d2dt1.DT1)
+
+	for idx := range dt1.Tiles {
+		tile := &dt1.Tiles[idx]
+		key := tileKey{style: int(tile.Style), sequence: int(tile.Sequence), tileType: int(tile.Type)}
+		m.tiles[key] = append(m.tiles[key], tile)
+	}
+}
+
+// dt1Path returns where the engine finds a tileset listed in a DS1 file, e.g. /data/global/tiles/act1/town/floor.dt1
+// for "C:\D2\Data\Global\Tiles\ACT1\TOWN\Floor.tg1".
+func dt1Path(file string) string {
+	file = strings.ToLower(strings.ReplaceAll(file, "\\", "/"))
+	file = strings.TrimPrefix(file, "c:")
+	file = strings.TrimPrefix(file, "/d2")
+
+	if ext := path.Ext(file); ext != ".dt1" {
+		file = strings.TrimSuffix(file, ext) + ".dt1"
+	}
+
+	return path.Join("/", file)
+}
+
+func (m *MapRenderer) unload() {
+	for _, image := range m.images {
+		if image.region != nil {
+			m.atlas.Remove(image.region)
+		}
+	}
+
+	m.images = nil
+
+	for idx := range m.tilesets {
+		m.tilesets[idx].resource.Release()
+	}
+
+	m.tilesets = nil
+
+	if m.resource != nil {
+		m.resource.Release()
+		m.resource = nil
+	}
+}
+
+// reload loads the map again if its DS1 file or one of its tilesets changed.
+func (m *MapRenderer) reload(changedPath string) {
+	changedPath = loader.NormalizePath(changedPath)
+	changed := strings.EqualFold(changedPath, loader.NormalizePath(m.filePath))
+
+	for idx := range m.tilesets {
+		changed = changed || strings.EqualFold(changedPath, loader.NormalizePath(m.tilesets[idx].filePath))
+	}
+
+	if !changed {
+		return
+	}
+
+	if err := m.load(); err != nil {
+		log.Error().Err(err).Msgf("failed to reload %s", m.filePath)
+	}
+}
+
+func (m *MapRenderer) Destroy() {
+	m.ShouldRemove = true
+	m.Active = false
+
+	m.unload()
+}
+
+// Size returns the size of the map, in tiles.
+func (m *MapRenderer) Size() (width, height int) {
+	return m.ds1.Width(), m.ds1.Height()
+}
+
+// Viewport returns the part of the map that is drawn, in map pixels (see TileToWorld). The point at x,y is drawn at
+// the position of the node. A size of zero does not limit what is drawn, and the map is drawn as far as the surface
+// goes.
+func (m *MapRenderer) Viewport() (x, y float64, width, height int) {
+	return m.viewportX, m.viewportY, m.viewportWidth, m.viewportHeight
+}
+
+func (m *MapRenderer) SetViewport(x, y float64, width, height int) {
+	m.viewportX, m.viewportY = x, y
+	m.viewportWidth, m.viewportHeight = width, height
+}
+
+// transform returns the transform from map pixels to the surface.
+func (m *MapRenderer) transform() renderer.Transform {
+	return m.WorldTransform().Multiply(renderer.Translation(-m.viewportX, -m.viewportY))
+}
+
+// visibleRect returns the part of the map that is both in the viewport and on the surface, in map pixels. Under a
+// camera, this is the part of the map in view.
+func (m *MapRenderer) visibleRect() (left, top, right, bottom float64) {
+	left, top = m.viewportX, m.viewportY
+	right, bottom = math.Inf(1), math.Inf(1)
+
+	if m.viewportWidth > 0 {
+		right = left + float64(m.viewportWidth)
+	}
+
+	if m.viewportHeight > 0 {
+		bottom = top + float64(m.viewportHeight)
+	}
+
+	inverse, ok := m.transform().Invert()
+
+	if !ok {
+		return left, top, left, top
+	}
+
+	surfaceWidth, surfaceHeight := m.renderer.SurfaceSize()
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, corner := range [4][2]float64{{0, 0}, {float64(surfaceWidth), 0}, {0, float64(surfaceHeight)},
+		{float64(surfaceWidth), float64(surfaceHeight)}} {
+		x, y := inverse.Apply(corner[0], corner[1])
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+
+	left, top = math.Max(left, minX), math.Max(top, minY)
+	right, bottom = math.Min(right, maxX), math.Min(bottom, maxY)
+
+	return left, top, math.Max(left, right), math.Max(top, bottom)
+}
+
+// Extent returns the rectangle the whole map covers, in the coordinates of the parent of the node (the world, under a
+// camera).
+func (m *MapRenderer) Extent() (x, y, width, height float64) {
+	mapWidth, mapHeight := m.Size()
+	left, _ := TileToWorld(0, float64(mapHeight))
+	right, _ := TileToWorld(float64(mapWidth), 0)
+	_, bottom := TileToWorld(float64(mapWidth), float64(mapHeight))
+	transform := m.LocalTransform().Multiply(renderer.Translation(-m.viewportX, -m.viewportY))
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, corner := range [4][2]float64{{left, 0}, {right, 0}, {left, bottom}, {right, bottom}} {
+		cornerX, cornerY := transform.Apply(corner[0], corner[1])
+		minX, minY = math.Min(minX, cornerX), math.Min(minY, cornerY)
+		maxX, maxY = math.Max(maxX, cornerX), math.Max(maxY, cornerY)
+	}
+
+	return minX, minY, maxX - minX, maxY - minY
+}
+
+// TileToWorld returns where the top corner of a tile is, in map pixels. The top corner of the first tile is at 0,0,
+// and the x axis of the map goes down to the right on the screen.
+func TileToWorld(tileX, tileY float64) (x, y float64) {
+	return (tileX - tileY) * tileWidth / 2, (tileX + tileY) * tileHeight / 2
+}
+
+// WorldToTile returns the tile at a point, in map pixels. The fraction is where the point is in the tile.
+func WorldToTile(x, y float64) (tileX, tileY float64) {
+	return x/tileWidth + y/tileHeight, y/tileHeight - x/tileWidth
+}
+
+// SetGroupVisible shows or hides all the floors, walls (with the roofs), or shadows of the map.
+func (m *MapRenderer) SetGroupVisible(group d2ds1.LayerGroupType, visible bool) {
+	m.hiddenGroups[group] = !visible
+}
+
+func (m *MapRenderer) IsGroupVisible(group d2ds1.LayerGroupType) bool {
+	return !m.hiddenGroups[group]
+}
+
+func (m *MapRenderer) bounds() (x, y, width, height float64, ok bool) {
+	left, top, right, bottom := m.visibleRect()
+
+	return left - m.viewportX, top - m.viewportY, right - left, bottom - top, true
+}
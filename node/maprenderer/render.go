@@ -0,0 +1,68 @@
+package maprenderer
+
+import (
+	"image/color"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2ds1"
+)
+
+// shadowOpacity is how dark shadows are drawn, from 0 to 1.
+const shadowOpacity = 0.5
+
+// render draws the tiles that are visible, pass by pass.
+func (m *MapRenderer) render() {
+	if !m.Visible || !m.Active {
+		return
+	}
+
+	tex := common.PaletteTexture[m.palette]
+	if !tex.Init {
+		tex.Texture = m.renderer.LoadPaletteTexture(tex.Data, common.PaletteTransformsCount)
+
+		tex.Init = true
+	}
+
+	tint := m.WorldTint()
+
+	if tint.A == 0 {
+		return
+	}
+
+	shadowTint := color.NRGBA{A: uint8(float64(tint.A) * shadowOpacity)}
+	left, top, right, bottom := m.visibleRect()
+	transform := m.transform()
+
+	for p := range m.drawables {
+		for idx := range m.drawables[p] {
+			d := &m.drawables[p][idx]
+
+			if m.hiddenGroups[d.group] {
+				continue
+			}
+
+			if d.x >= right || d.y >= bottom || d.x+float64(d.image.width) <= left ||
+				d.y+float64(d.image.height) <= top {
+				continue
+			}
+
+			if d.image.region == nil {
+				m.initializeTexture(d.image)
+			}
+
+			if d.image.region.Empty() {
+				continue
+			}
+
+			drawTint := tint
+
+			if d.group == d2ds1.ShadowLayerGroup {
+				drawTint = shadowTint
+			}
+
+			m.renderer.DrawIndexedTextureRegion(d.image.region.Texture(), tex.Texture, 0, d.image.region.Rect(),
+				transform.Multiply(renderer.Translation(d.x, d.y)), drawTint, renderer.BlendModeNone)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package maprenderer
+
+import (
+	"math"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer/atlas"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2ds1"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2dt1"
+)
+
+// blockHeight is the height of the blocks tiles are made of.
+const blockHeight = 32
+
+// pass is one of the passes the map is drawn in, in order.
+type pass int
+
+const (
+	passLowerWalls pass = iota
+	passFloors
+	passShadows
+	passWalls
+	passRoofs
+	passCount
+)
+
+// tileKey is how DS1 maps refer to the tiles of their tilesets. Tilesets can have more than one tile with the same
+// key, which are variations picked by rarity.
+type tileKey struct {
+	style    int
+	sequence int
+	tileType int
+}
+
+// imageKey is a tile variation, which is drawn the same everywhere on the map.
+type imageKey struct {
+	tileKey
+	index int
+}
+
+// tileImage is a tile variation, decoded and packed into the atlas the first time it is drawn.
+type tileImage struct {
+	tiles   []*d2dt1.Tile
+	region  *atlas.Region
+	offsetX int
+	offsetY int
+	width   int
+	height  int
+	// yOffset is how far down the blocks of the tiles are moved, so that none of them are above the image.
+	yOffset int32
+}
+
+// drawable is a tile of one of the layers of the map, where it is drawn.
+type drawable struct {
+	image *tileImage
+	group d2ds1.LayerGroupType
+	x     float64
+	y     float64
+}
+
+// buildDrawables works out which tile variations are drawn where, and in which order. Tiles the tilesets do not have
+// are left out.
+func (m *MapRenderer) buildDrawables() {
+	for idx := range m.drawables {
+		m.drawables[idx] = m.drawables[idx][:0]
+	}
+
+	width, height := m.Size()
+
+	// Tiles are drawn in diagonal rows from the top of the map down, so that the ones further down on the screen are
+	// drawn over the ones behind them.
+	for diagonal := 0; diagonal < width+height-1; diagonal++ {
+		for tileX := 0; tileX < width; tileX++ {
+			tileY := diagonal - tileX
+
+			if tileY < 0 || tileY >= height {
+				continue
+			}
+
+			m.addTileDrawables(tileX, tileY)
+		}
+	}
+}
+
+// addTileDrawables adds the floors, walls and shadows of a tile of the map.
+func (m *MapRenderer) addTileDrawables(tileX, tileY int) {
+	for idx, layer := range m.ds1.Floors {
+		tile := layer.Tile(tileX, tileY)
+
+		if tile == nil || tile.Prop1 == 0 || tile.Hidden() {
+			continue
+		}
+
+		key := tileKey{style: int(tile.Style), sequence: int(tile.Sequence), tileType: int(d2enum.TileFloor)}
+		m.addDrawable(passFloors, d2ds1.FloorLayerGroup, key, tileX, tileY, idx)
+	}
+
+	for idx, layer := range m.ds1.Shadows {
+		tile := layer.Tile(tileX, tileY)
+
+		if tile == nil || tile.Prop1 == 0 || tile.Hidden() {
+			continue
+		}
+
+		key := tileKey{style: int(tile.Style), sequence: int(tile.Sequence), tileType: int(d2enum.TileShadow)}
+		m.addDrawable(passShadows, d2ds1.ShadowLayerGroup, key, tileX, tileY, idx)
+	}
+
+	for idx, layer := range m.ds1.Walls {
+		tile := layer.Tile(tileX, tileY)
+
+		if tile == nil || tile.Prop1 == 0 || tile.Hidden() {
+			continue
+		}
+
+		key := tileKey{style: int(tile.Style), sequence: int(tile.Sequence), tileType: int(tile.Type)}
+
+		switch {
+		case tile.Type.LowerWall():
+			m.addDrawable(passLowerWalls, d2ds1.WallLayerGroup, key, tileX, tileY, idx)
+		case tile.Type.UpperWall():
+			m.addDrawable(passWalls, d2ds1.WallLayerGroup, key, tileX, tileY, idx)
+		case tile.Type == d2enum.TileRoof:
+			m.addDrawable(passRoofs, d2ds1.WallLayerGroup, key, tileX, tileY, idx)
+		}
+	}
+}
+
+func (m *MapRenderer) addDrawable(p pass, group d2ds1.LayerGroupType, key tileKey, tileX, tileY, layer int) {
+	options := m.tiles[key]
+
+	if len(options) == 0 {
+		return
+	}
+
+	index := pickTile(options, tileX, tileY, layer)
+	image, ok := m.images[imageKey{tileKey: key, index: index}]
+
+	if !ok {
+		image = m.newTileImage(key, index)
+		m.images[imageKey{tileKey: key, index: index}] = image
+	}
+
+	if image.width == 0 || image.height == 0 {
+		return
+	}
+
+	x, y := TileToWorld(float64(tileX), float64(tileY))
+
+	m.drawables[p] = append(m.drawables[p], drawable{
+		image: image,
+		group: group,
+		x:     x + float64(image.offsetX),
+		y:     y + float64(image.offsetY),
+	})
+}
+
+// pickTile picks one of the variations of a tile by their rarity. The same variation is picked every time for the
+// same place on the map.
+func pickTile(options []*d2dt1.Tile, tileX, tileY, layer int) int {
+	total := 0
+
+	for _, tile := range options {
+		total += int(tile.RarityFrameIndex)
+	}
+
+	if total <= 0 {
+		return 0
+	}
+
+	roll := int(tileHash(tileX, tileY, layer) % uint32(total))
+
+	for idx, tile := range options {
+		roll -= int(tile.RarityFrameIndex)
+
+		if roll < 0 {
+			return idx
+		}
+	}
+
+	return 0
+}
+
+func tileHash(tileX, tileY, layer int) uint32 {
+	result := uint32(tileX)*73856093 ^ uint32(tileY)*19349663 ^ uint32(layer)*83492791
+	result ^= result >> 13
+	result *= 0x5bd1e995
+	result ^= result >> 15
+
+	return result
+}
+
+// newTileImage works out the size of a tile variation, and where it is drawn relative to the top corner of its tile.
+// Floors hang down from the top corner, walls and shadows stand on the bottom corner, and roofs are raised by their
+// height.
+func (m *MapRenderer) newTileImage(key tileKey, index int) *tileImage {
+	tile := m.tiles[key][index]
+	result := &tileImage{
+		tiles:   []*d2dt1.Tile{tile},
+		offsetX: -tileWidth / 2,
+		width:   int(math.Max(float64(tile.Width), tileWidth)),
+	}
+
+	// The left part of a north corner is drawn with its right part.
+	if key.tileType == int(d2enum.TileRightPartOfNorthCornerWall) {
+		leftKey := key
+		leftKey.tileType = int(d2enum.TileLeftPartOfNorthCornerWall)
+
+		if options := m.tiles[leftKey]; index < len(options) {
+			result.tiles = append(result.tiles, options[index])
+		}
+	}
+
+	minY, maxY := int32(0), int32(0)
+
+	for _, t := range result.tiles {
+		for idx := range t.Blocks {
+			if y := int32(t.Blocks[idx].Y); y < minY {
+				minY = y
+			}
+
+			if y := int32(t.Blocks[idx].Y) + blockHeight; y > maxY {
+				maxY = y
+			}
+		}
+	}
+
+	if len(tile.Blocks) == 0 {
+		return result
+	}
+
+	result.yOffset = -minY
+	result.height = int(math.Max(math.Abs(float64(tile.Height)), float64(maxY-minY)))
+
+	switch d2enum.TileType(key.tileType) {
+	case d2enum.TileFloor:
+		result.offsetY = int(minY)
+	case d2enum.TileRoof:
+		result.offsetY = -int(tile.RoofHeight)
+	default:
+		result.offsetY = int(minY) + tileHeight
+	}
+
+	return result
+}
+
+// initializeTexture decodes the blocks of a tile variation, and packs them into the atlas.
+func (m *MapRenderer) initializeTexture(image *tileImage) {
+	pixels := make([]byte, image.width*image.height)
+
+	for _, tile := range image.tiles {
+		d2dt1.DecodeTileGfxData(tile.Blocks, &pixels, image.yOffset, int32(image.width))
+	}
+
+	image.region = m.atlas.Add(pixels, image.width, image.height)
+}
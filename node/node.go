@@ -2,47 +2,132 @@ package node
 
 import (
 	"errors"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/segmentio/ksuid"
+	lua "github.com/yuin/gopher-lua"
 )
 
 type Node struct {
-	Id             ksuid.KSUID
-	ShouldRemove   bool
-	Parent         *Node
-	Children       []*Node
-	Active         bool
-	Visible        bool
-	X              int
-	Y              int
-	RenderCallback func()
-	UpdateCallback func(elapsed float64)
+	Id              ksuid.KSUID
+	Name            string
+	Tags            []string
+	Type            string
+	ShouldRemove    bool
+	Parent          *Node
+	Children        []*Node
+	Active          bool
+	Visible         bool
+	Interactive     bool
+	X               float64
+	Y               float64
+	ScaleX          float64
+	ScaleY          float64
+	Rotation        float64
+	PivotX          float64
+	PivotY          float64
+	Opacity         float64
+	Tint            color.NRGBA
+	ZIndex          int
+	Layer           RenderLayer
+	RenderCallback  func()
+	UpdateCallback  func(elapsed float64)
+	ReloadCallback  func(path string)
+	DestroyCallback func()
+	// HitTestCallback reports whether a point is inside the node, and MouseCallback handles the mouse events sent to
+	// it. A node needs both to take mouse input.
+	HitTestCallback func(x, y int) bool
+	MouseCallback   func(event *MouseEvent)
+	// LocalTransformCallback replaces the transform made from the position, scale, rotation and pivot, for nodes such
+	// as cameras that move their children some other way.
+	LocalTransformCallback func() renderer.Transform
+	// BoundsCallback returns the rectangle the node draws in, in its own coordinates. See WorldBounds.
+	BoundsCallback func() (x, y, width, height float64, ok bool)
+	// ToLuaCallback returns the Lua value of what the node belongs to, such as a sprite, so that nodes found in the
+	// tree can be used as what they are. See ToLuaValue.
+	ToLuaCallback func(l *lua.LState) *lua.LUserData
 }
 
 func New() *Node {
 	result := &Node{
-		Id:       ksuid.New(),
-		Parent:   nil,
-		Children: make([]*Node, 0),
-		Active:   true,
-		Visible:  true,
-		X:        0,
-		Y:        0,
+		Id:          ksuid.New(),
+		Parent:      nil,
+		Children:    make([]*Node, 0),
+		Tags:        make([]string, 0),
+		Type:        "node",
+		Active:      true,
+		Visible:     true,
+		Interactive: true,
+		X:           0,
+		Y:           0,
+		ScaleX:      1,
+		ScaleY:      1,
+		Opacity:     1,
+		Tint:        color.NRGBA{R: 255, G: 255, B: 255, A: 255},
 	}
 
 	return result
 }
 
+// GetNode returns the node itself. Types that embed a node, such as sprites, have it too, so that any of them can be
+// used where a node is expected.
+func (e *Node) GetNode() *Node {
+	return e
+}
+
+// GetPosition returns where the node's origin is on the surface, rounded down to whole pixels.
 func (e *Node) GetPosition() (X, Y int) {
+	x, y := e.WorldTransform().Apply(0, 0)
+
+	return int(math.Floor(x)), int(math.Floor(y))
+}
+
+// LocalTransform returns the transform from the node's coordinates to its parent's. The node is scaled and then
+// rotated (clockwise, in degrees) around its pivot, and then moved by its position.
+func (e *Node) LocalTransform() renderer.Transform {
+	if e.LocalTransformCallback != nil {
+		return e.LocalTransformCallback()
+	}
+
+	result := renderer.Translation(e.X+e.PivotX, e.Y+e.PivotY)
+	result = result.Multiply(renderer.Rotation(e.Rotation))
+	result = result.Multiply(renderer.Scaling(e.ScaleX, e.ScaleY))
+
+	return result.Multiply(renderer.Translation(-e.PivotX, -e.PivotY))
+}
+
+// WorldTransform returns the transform from the node's coordinates to the surface, made up of its own transform and
+// those of its parents.
+func (e *Node) WorldTransform() renderer.Transform {
 	if e.Parent == nil {
-		return e.X, e.Y
+		return e.LocalTransform()
 	}
 
-	x, y := e.Parent.GetPosition()
+	return e.Parent.WorldTransform().Multiply(e.LocalTransform())
+}
+
+// WorldTint returns the color the node is drawn with: its tint and opacity multiplied by those of its parents.
+func (e *Node) WorldTint() color.NRGBA {
+	result := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	opacity := 1.0
+
+	for current := e; current != nil; current = current.Parent {
+		result.R = uint8(uint16(result.R) * uint16(current.Tint.R) / 255)
+		result.G = uint8(uint16(result.G) * uint16(current.Tint.G) / 255)
+		result.B = uint8(uint16(result.B) * uint16(current.Tint.B) / 255)
+		result.A = uint8(uint16(result.A) * uint16(current.Tint.A) / 255)
+		opacity *= math.Max(0, math.Min(1, current.Opacity))
+	}
 
-	return e.X + x, e.Y + y
+	result.A = uint8(math.Round(float64(result.A) * opacity))
+
+	return result
 }
 
 func (e *Node) AddChild(entity *Node) error {
@@ -107,21 +192,87 @@ func (e *Node) FindChild(id ksuid.KSUID) *Node {
 	return nil
 }
 
+// Reload tells the node and all of its children that the file at the given path has changed.
+func (e *Node) Reload(path string) {
+	if e.ReloadCallback != nil {
+		e.ReloadCallback(path)
+	}
+
+	for idx := range e.Children {
+		e.Children[idx].Reload(path)
+	}
+}
+
+// DestroyTree destroys the node and all of its children, releasing the textures and resources they hold.
+func (e *Node) DestroyTree() {
+	for idx := range e.Children {
+		e.Children[idx].DestroyTree()
+	}
+
+	if e.DestroyCallback != nil {
+		e.DestroyCallback()
+	}
+}
+
+// EffectiveLayer returns the render layer the node is drawn in, following the parents of nodes that inherit theirs.
+func (e *Node) EffectiveLayer() RenderLayer {
+	for current := e; current != nil; current = current.Parent {
+		if current.Layer != RenderLayerInherit {
+			return current.Layer
+		}
+	}
+
+	return RenderLayerWorld
+}
+
+// childLayer returns the render layer of a child, given the layer of its parent.
+func childLayer(child *Node, parentLayer RenderLayer) RenderLayer {
+	if child.Layer == RenderLayerInherit {
+		return parentLayer
+	}
+
+	return child.Layer
+}
+
+// drawOrder returns the children in the order they are drawn, sorted by ZIndex and then by the order they were added.
+func (e *Node) drawOrder() []*Node {
+	if sort.SliceIsSorted(e.Children, func(a, b int) bool { return e.Children[a].ZIndex < e.Children[b].ZIndex }) {
+		return e.Children
+	}
+
+	result := make([]*Node, len(e.Children))
+	copy(result, e.Children)
+
+	sort.SliceStable(result, func(a, b int) bool { return result[a].ZIndex < result[b].ZIndex })
+
+	return result
+}
+
+// Render draws the node and its children, one render layer after the other.
 func (e *Node) Render() {
+	layer := e.EffectiveLayer()
+
+	for _, renderLayer := range RenderLayers {
+		e.renderLayer(renderLayer, layer)
+	}
+}
+
+// RenderLayer draws the parts of the node and its children that are in the given render layer.
+func (e *Node) RenderLayer(renderLayer RenderLayer) {
+	e.renderLayer(renderLayer, e.EffectiveLayer())
+}
+
+func (e *Node) renderLayer(renderLayer, layer RenderLayer) {
 	if !e.Visible || !e.Active {
 		return
 	}
 
-	if e.RenderCallback != nil {
+	if layer == renderLayer && e.RenderCallback != nil {
 		e.RenderCallback()
 	}
 
-	for idx := range e.Children {
-		if !e.Children[idx].Active || !e.Children[idx].Visible {
-			continue
-		}
-
-		e.Children[idx].Render()
+	for _, child := range e.drawOrder() {
+		child.renderLayer(renderLayer, childLayer(child, layer))
 	}
 }
 
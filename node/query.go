@@ -0,0 +1,88 @@
+package node
+
+import (
+	"strings"
+)
+
+// HasTag reports whether the node has been given the tag.
+func (e *Node) HasTag(tag string) bool {
+	for idx := range e.Tags {
+		if e.Tags[idx] == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddTag gives the node a tag, unless it already has it.
+func (e *Node) AddTag(tag string) {
+	if e.HasTag(tag) {
+		return
+	}
+
+	e.Tags = append(e.Tags, tag)
+}
+
+func (e *Node) RemoveTag(tag string) {
+	for idx := range e.Tags {
+		if e.Tags[idx] != tag {
+			continue
+		}
+
+		e.Tags = append(e.Tags[:idx], e.Tags[idx+1:]...)
+
+		return
+	}
+}
+
+// FindChildByName returns the first direct child with the given name, or nil if there is none.
+func (e *Node) FindChildByName(name string) *Node {
+	for idx := range e.Children {
+		if e.Children[idx].Name == name {
+			return e.Children[idx]
+		}
+	}
+
+	return nil
+}
+
+// Find follows a path of child names separated by slashes, such as "menu/buttons/ok", and returns the node at the end
+// of it, or nil if there is none. A ".." in the path goes up to the parent.
+func (e *Node) Find(path string) *Node {
+	result := e
+
+	for _, name := range strings.Split(path, "/") {
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			result = result.Parent
+		default:
+			result = result.FindChildByName(name)
+		}
+
+		if result == nil {
+			return nil
+		}
+	}
+
+	return result
+}
+
+// FindByTag returns the nodes below this one that have the tag, in the order they were added to the tree.
+func (e *Node) FindByTag(tag string) []*Node {
+	return e.findByTag(tag, make([]*Node, 0))
+}
+
+func (e *Node) findByTag(tag string, result []*Node) []*Node {
+	for idx := range e.Children {
+		if e.Children[idx].HasTag(tag) {
+			result = append(result, e.Children[idx])
+		}
+
+		result = e.Children[idx].findByTag(tag, result)
+	}
+
+	return result
+}
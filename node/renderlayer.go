@@ -0,0 +1,56 @@
+package node
+
+import (
+	"errors"
+	"strings"
+)
+
+// RenderLayer is one of the global layers the node tree is drawn in. Every node in a layer is drawn after every node
+// in the layers below it, wherever the nodes are in the tree.
+type RenderLayer int
+
+const (
+	// RenderLayerInherit puts a node in the same layer as its parent. Nodes with no parent are in the world layer.
+	RenderLayerInherit RenderLayer = iota
+	RenderLayerWorld
+	RenderLayerUI
+	RenderLayerTooltips
+	RenderLayerCursor
+)
+
+// RenderLayers are the layers in the order they are drawn.
+var RenderLayers = []RenderLayer{RenderLayerWorld, RenderLayerUI, RenderLayerTooltips, RenderLayerCursor}
+
+func (l RenderLayer) ToString() string {
+	switch l {
+	case RenderLayerInherit:
+		return "inherit"
+	case RenderLayerWorld:
+		return "world"
+	case RenderLayerUI:
+		return "ui"
+	case RenderLayerTooltips:
+		return "tooltips"
+	case RenderLayerCursor:
+		return "cursor"
+	}
+
+	return "inherit"
+}
+
+func StringToRenderLayer(s string) (RenderLayer, error) {
+	switch strings.ToLower(s) {
+	case "inherit", "":
+		return RenderLayerInherit, nil
+	case "world":
+		return RenderLayerWorld, nil
+	case "ui":
+		return RenderLayerUI, nil
+	case "tooltips":
+		return RenderLayerTooltips, nil
+	case "cursor":
+		return RenderLayerCursor, nil
+	}
+
+	return RenderLayerInherit, errors.New("invalid render layer")
+}
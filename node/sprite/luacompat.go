@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/input"
 	lua "github.com/yuin/gopher-lua"
 )
 
@@ -16,18 +17,25 @@ var LuaTypeExport = common.LuaTypeExport{
 		"cellSize":               luaGetSetCellSize,
 		"active":                 luaGetSetActive,
 		"visible":                luaGetSetVisible,
+		"blocksMouse":            luaGetSetBlocksMouse,
 		"position":               luaGetSetPosition,
 		"currentSequence":        luaGetSetCurrentSequence,
 		"currentFrame":           luaGetSetCurrentFrame,
 		"sequenceCount":          luaGetSequenceCount,
 		"frameCount":             luaGetFrameCount,
 		"destroy":                luaDestroy,
-		"mouseButtonDownHandler": luaGetSetMouseButtonDownHandler,
-		"mouseButtonUpHandler":   luaGetSetMouseButtonUpHandler,
-		"mouseOverHandler":       luaGetSetMouseOverHandler,
-		"mouseLeaveHandler":      luaGetSetMouseLeaveHandler,
+		"mouseButtonDownHandler": luaGetSetMouseHandler(func(s *Sprite) *mouseHandler { return &s.onMouseButtonDown }),
+		"mouseButtonUpHandler":   luaGetSetMouseHandler(func(s *Sprite) *mouseHandler { return &s.onMouseButtonUp }),
+		"mouseOverHandler":       luaGetSetMouseHandler(func(s *Sprite) *mouseHandler { return &s.onMouseOver }),
+		"mouseLeaveHandler":      luaGetSetMouseHandler(func(s *Sprite) *mouseHandler { return &s.onMouseLeave }),
+		"doubleClickHandler":     luaGetSetMouseHandler(func(s *Sprite) *mouseHandler { return &s.onDoubleClick }),
+		"dragStartHandler":       luaGetSetMouseHandler(func(s *Sprite) *mouseHandler { return &s.onDragStart }),
+		"dragHandler":            luaGetSetMouseHandler(func(s *Sprite) *mouseHandler { return &s.onDrag }),
+		"dragEndHandler":         luaGetSetMouseHandler(func(s *Sprite) *mouseHandler { return &s.onDragEnd }),
+		"mouseWheelHandler":      luaGetSetMouseHandler(func(s *Sprite) *mouseHandler { return &s.onMouseWheel }),
 		"playForward":            luaGetPlayForward,
 		"blendMode":              luaGetSetBlendMode,
+		"paletteShift":           luaGetSetPaletteShift,
 	},
 }
 
@@ -56,82 +64,52 @@ func luaGetSetBlendMode(l *lua.LState) int {
 	return 0
 }
 
-func luaGetPlayForward(l *lua.LState) int {
-	sprite, err := FromLua(l.ToUserData(1))
+// luaGetSetMouseHandler returns a function that gets, sets or (when set to nil) clears one of the mouse handlers of
+// a sprite. Handlers are called with the sprite and a table describing the mouse event, see input.MouseEvent.ToLua.
+func luaGetSetMouseHandler(handlerOf func(s *Sprite) *mouseHandler) lua.LGFunction {
+	return func(l *lua.LState) int {
+		sprite, err := FromLua(l.ToUserData(1))
 
-	if err != nil {
-		l.RaiseError("failed to convert")
-		return 0
-	}
+		if err != nil {
+			l.RaiseError("failed to convert")
+			return 0
+		}
 
-	sprite.PlayForward()
+		handler := handlerOf(sprite)
 
-	return 0
-}
+		if l.GetTop() == 1 {
+			l.Push(l.NewFunction(func(l *lua.LState) int {
+				if *handler != nil {
+					(*handler)(input.MouseEvent{})
+				}
 
-func luaGetSetMouseOverHandler(l *lua.LState) int {
-	sprite, err := FromLua(l.ToUserData(1))
+				return 0
+			}))
 
-	if err != nil {
-		l.RaiseError("failed to convert")
-		return 0
-	}
+			return 1
+		}
 
-	if l.GetTop() == 1 {
-		l.Push(l.NewFunction(func(l *lua.LState) int {
-			sprite.onMouseOver()
+		if l.Get(2) == lua.LNil {
+			*handler = nil
 			return 0
-		}))
-
-		return 1
-	}
-
-	luaFunc := l.CheckFunction(2)
-	sprite.onMouseOver = func() {
-		if err := l.CallByParam(lua.P{
-			Fn:      luaFunc,
-			NRet:    1,
-			Protect: true,
-		}, sprite.ToLua(l)); err != nil {
-			panic(err)
 		}
-	}
-
-	return 0
-}
 
-func luaGetSetMouseLeaveHandler(l *lua.LState) int {
-	sprite, err := FromLua(l.ToUserData(1))
+		luaFunc := l.CheckFunction(2)
+		*handler = func(event input.MouseEvent) {
+			if err := l.CallByParam(lua.P{
+				Fn:      luaFunc,
+				NRet:    1,
+				Protect: true,
+			}, sprite.ToLua(l), event.ToLua(l)); err != nil {
+				panic(err)
+			}
+		}
 
-	if err != nil {
-		l.RaiseError("failed to convert")
 		return 0
 	}
-
-	if l.GetTop() == 1 {
-		l.Push(l.NewFunction(func(l *lua.LState) int {
-			sprite.onMouseLeave()
-			return 0
-		}))
-
-		return 1
-	}
-
-	luaFunc := l.CheckFunction(2)
-	sprite.onMouseLeave = func() {
-		if err := l.CallByParam(lua.P{
-			Fn:      luaFunc,
-			NRet:    1,
-			Protect: true,
-		}, sprite.ToLua(l)); err != nil {
-			panic(err)
-		}
-	}
-
-	return 0
 }
 
-func luaDestroy(l *lua.LState) int {
+func luaGetPlayForward(l *lua.LState) int {
 	sprite, err := FromLua(l.ToUserData(1))
 
 	if err != nil {
@@ -139,12 +117,12 @@ func luaDestroy(l *lua.LState) int {
 		return 0
 	}
 
-	sprite.Destroy()
+	sprite.PlayForward()
 
 	return 0
 }
 
-func luaGetSetMouseButtonUpHandler(l *lua.LState) int {
+func luaDestroy(l *lua.LState) int {
 	sprite, err := FromLua(l.ToUserData(1))
 
 	if err != nil {
@@ -152,25 +130,7 @@ func luaGetSetMouseButtonUpHandler(l *lua.LState) int {
 		return 0
 	}
 
-	if l.GetTop() == 1 {
-		l.Push(l.NewFunction(func(l *lua.LState) int {
-			sprite.onMouseButtonUp()
-			return 0
-		}))
-
-		return 1
-	}
-
-	luaFunc := l.CheckFunction(2)
-	sprite.onMouseButtonUp = func() {
-		if err := l.CallByParam(lua.P{
-			Fn:      luaFunc,
-			NRet:    1,
-			Protect: true,
-		}, sprite.ToLua(l)); err != nil {
-			panic(err)
-		}
-	}
+	sprite.Destroy()
 
 	return 0
 }
@@ -299,8 +259,8 @@ func luaGetSetPosition(l *lua.LState) int {
 	posX := l.ToNumber(2)
 	posY := l.ToNumber(3)
 
-	sprite.X = int(posX)
-	sprite.Y = int(posY)
+	sprite.X = float64(posX)
+	sprite.Y = float64(posY)
 
 	return 0
 }
@@ -328,7 +288,7 @@ func luaGetSetCellSize(l *lua.LState) int {
 	return 0
 }
 
-func luaGetSetMouseButtonDownHandler(l *lua.LState) int {
+func luaGetSetVisible(l *lua.LState) int {
 	sprite, err := FromLua(l.ToUserData(1))
 
 	if err != nil {
@@ -337,29 +297,17 @@ func luaGetSetMouseButtonDownHandler(l *lua.LState) int {
 	}
 
 	if l.GetTop() == 1 {
-		l.Push(l.NewFunction(func(l *lua.LState) int {
-			sprite.onMouseButtonDown()
-			return 0
-		}))
-
+		l.Push(lua.LBool(sprite.Visible))
 		return 1
 	}
 
-	luaFunc := l.CheckFunction(2)
-	sprite.onMouseButtonDown = func() {
-		if err := l.CallByParam(lua.P{
-			Fn:      luaFunc,
-			NRet:    1,
-			Protect: true,
-		}, sprite.ToLua(l)); err != nil {
-			panic(err)
-		}
-	}
+	newValue := l.CheckBool(2)
+	sprite.Visible = newValue
 
 	return 0
 }
 
-func luaGetSetVisible(l *lua.LState) int {
+func luaGetSetBlocksMouse(l *lua.LState) int {
 	sprite, err := FromLua(l.ToUserData(1))
 
 	if err != nil {
@@ -368,12 +316,11 @@ func luaGetSetVisible(l *lua.LState) int {
 	}
 
 	if l.GetTop() == 1 {
-		l.Push(lua.LBool(sprite.Visible))
+		l.Push(lua.LBool(sprite.BlocksMouse))
 		return 1
 	}
 
-	newValue := l.CheckBool(2)
-	sprite.Visible = newValue
+	sprite.BlocksMouse = l.CheckBool(2)
 
 	return 0
 }
@@ -396,3 +343,21 @@ func luaGetSetActive(l *lua.LState) int {
 
 	return 0
 }
+
+func luaGetSetPaletteShift(l *lua.LState) int {
+	sprite, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.GetTop() == 1 {
+		l.Push(lua.LNumber(sprite.paletteShift))
+		return 1
+	}
+
+	sprite.SetPaletteShift(float64(l.CheckNumber(2)))
+
+	return 0
+}
@@ -2,109 +2,111 @@ package sprite
 
 import (
 	"errors"
+	"math"
 	"strings"
 
 	"github.com/OpenDiablo2/AbyssEngine/common"
-	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
 )
 
-type blendMode int
-
-const (
-	blendModeNone blendMode = iota
-	blendModeAlpha
-	blendModeAdditive
-	blendModeMultiplied
-	blendModeAddColors
-	blendModeSubtractColors
-)
-
-var blendModeLookup = []rl.BlendMode{
-	-1,
-	rl.BlendAlpha,
-	rl.BlendAdditive,
-	rl.BlendMultiplied,
-	rl.BlendAddColors,
-	rl.BlendSubtractColors,
-}
-
-func blendModeToString(mode blendMode) string {
+func blendModeToString(mode renderer.BlendMode) string {
 	switch mode {
-	case blendModeNone:
+	case renderer.BlendModeNone:
 		return ""
-	case blendModeAlpha:
+	case renderer.BlendModeAlpha:
 		return "alpha"
-	case blendModeAdditive:
+	case renderer.BlendModeAdditive:
 		return "add"
-	case blendModeMultiplied:
+	case renderer.BlendModeMultiplied:
 		return "multiply"
-	case blendModeAddColors:
+	case renderer.BlendModeAddColors:
 		return "addcolors"
-	case blendModeSubtractColors:
+	case renderer.BlendModeSubtractColors:
 		return "subcolors"
 	default:
 		return ""
 	}
 }
 
-func stringToBlendMode(mode string) (blendMode, error) {
+func stringToBlendMode(mode string) (renderer.BlendMode, error) {
 	switch strings.ToLower(mode) {
 	case "":
-		return blendModeNone, nil
+		return renderer.BlendModeNone, nil
 	case "alpha":
-		return blendModeAlpha, nil
+		return renderer.BlendModeAlpha, nil
 	case "add":
-		return blendModeAdditive, nil
+		return renderer.BlendModeAdditive, nil
 	case "multiply":
-		return blendModeMultiplied, nil
+		return renderer.BlendModeMultiplied, nil
 	case "addcolors":
-		return blendModeAddColors, nil
+		return renderer.BlendModeAddColors, nil
 	case "subcolors":
-		return blendModeSubtractColors, nil
+		return renderer.BlendModeSubtractColors, nil
 	default:
 		return -1, errors.New("invalid blend mode")
 	}
 }
 
 func (s *Sprite) render() {
-	if s.textures[s.CurrentFrame].ID == 0 || !s.Visible || !s.Active {
+	region := s.regions[s.CurrentFrame]
+
+	if region == nil || region.Empty() || !s.Visible || !s.Active {
 		return
 	}
 
 	tex := common.PaletteTexture[s.palette]
 	if !tex.Init {
-		img := rl.NewImage(tex.Data, 256, int32(common.PaletteTransformsCount), 1, rl.UncompressedR8g8b8a8)
-		tex.Texture = rl.LoadTextureFromImage(img)
+		tex.Texture = s.renderer.LoadPaletteTexture(tex.Data, common.PaletteTransformsCount)
 
 		tex.Init = true
 	}
 
-	posX, posY := s.GetPosition()
+	tint := s.WorldTint()
+
+	if tint.A == 0 {
+		return
+	}
+
+	posX, posY := s.frameOffset()
+	transform := s.WorldTransform().Multiply(renderer.Translation(float64(posX), float64(posY)))
+
+	s.renderer.DrawIndexedTextureRegion(region.Texture(), tex.Texture, s.paletteOffset(), region.Rect(), transform,
+		tint, s.blendMode)
+}
 
-	posX += s.Sequences.GetFrameOffsetX(s.CurrentSequence(), s.CurrentFrame)
+// frameOffset returns where the current frame is drawn, relative to the sprite's position.
+func (s *Sprite) frameOffset() (X, Y int) {
+	posX := s.Sequences.GetFrameOffsetX(s.CurrentSequence(), s.CurrentFrame)
+	posY := s.Sequences.GetFrameOffsetY(s.CurrentSequence(), s.CurrentFrame)
 
 	if s.CellSizeX == 1 && s.CellSizeY == 1 {
 		posY -= s.Sequences.FrameHeight(s.CurrentSequence(), s.CurrentFrame)
 	}
 
-	posY += s.Sequences.GetFrameOffsetY(s.CurrentSequence(), s.CurrentFrame)
-
-	rl.BeginShaderMode(common.PaletteShader)
-	rl.SetShaderValueTexture(common.PaletteShader, common.PaletteShaderLoc, tex.Texture)
+	return posX, posY
+}
 
-	rl.SetShaderValue(common.PaletteShader, common.PaletteShaderOffsetLoc, []float32{float32(s.paletteShift)}, rl.ShaderUniformFloat)
+func (s *Sprite) bounds() (x, y, width, height float64, ok bool) {
+	region := s.regions[s.CurrentFrame]
 
-	if blendModeLookup[s.blendMode] != -1 {
-		rl.BeginBlendMode(blendModeLookup[s.blendMode])
+	if region == nil {
+		return 0, 0, 0, 0, false
 	}
 
-	rl.DrawTexture(s.textures[s.CurrentFrame], int32(posX), int32(posY), rl.White)
+	posX, posY := s.frameOffset()
 
-	if blendModeLookup[s.blendMode] != -1 {
-		rl.EndBlendMode()
+	return float64(posX), float64(posY), float64(region.Width()), float64(region.Height()), true
+}
+
+// paletteOffset returns where the palette transform of the sprite is in the palette texture, from 0 to 1.
+func (s *Sprite) paletteOffset() float32 {
+	if common.PaletteTransformsCount < 2 {
+		return 0
 	}
 
-	rl.EndShaderMode()
+	shift := math.Max(0, math.Min(math.Round(s.paletteShift), float64(common.PaletteTransformsCount-1)))
+
+	return float32(shift / float64(common.PaletteTransformsCount-1))
 }
 
 func (s *Sprite) initializeTexture() {
@@ -148,7 +150,5 @@ func (s *Sprite) initializeTexture() {
 		targetStartY += s.Sequences.FrameHeight(s.CurrentSequence(), cellOffsetY*s.CellSizeX)
 	}
 
-	img := rl.NewImage(pixels, int32(width), int32(height), 1, rl.UncompressedGrayscale)
-
-	s.textures[s.CurrentFrame] = rl.LoadTextureFromImage(img)
+	s.regions[s.CurrentFrame] = s.atlas.Add(pixels, width, height)
 }
@@ -2,32 +2,43 @@ package sprite
 
 import (
 	"errors"
-	"io/ioutil"
 	"path"
 	"strings"
 
 	"github.com/OpenDiablo2/AbyssEngine/common"
+	"github.com/OpenDiablo2/AbyssEngine/input"
+	"github.com/OpenDiablo2/AbyssEngine/loader"
 	"github.com/OpenDiablo2/AbyssEngine/node"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer/atlas"
 	dc6 "github.com/OpenDiablo2/dc6/pkg"
 	dcc "github.com/OpenDiablo2/dcc/pkg"
-	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/rs/zerolog/log"
 )
 
+// mouseHandler is called with a mouse event that happened over (or was captured by) a sprite.
+type mouseHandler func(event input.MouseEvent)
+
 type Sprite struct {
 	*node.Node
 
-	mousePosProvider  common.MousePositionProvider
-	Sequences         common.SequenceProvider
-	palette           string
-	currentSequence   int
-	CurrentFrame      int
-	Visible           bool
+	renderer         renderer.Renderer
+	atlas            *atlas.Atlas
+	resourceProvider common.ResourceProvider
+	resource         common.ResourceHandle
+	filePath         string
+	mouse            common.MouseProvider
+	Sequences        common.SequenceProvider
+	palette          string
+	currentSequence  int
+	CurrentFrame     int
+	Visible          bool
+	// BlocksMouse makes the sprite take mouse input even without mouse handlers, so that the nodes behind it don't.
+	BlocksMouse       bool
 	CellSizeX         int
 	CellSizeY         int
-	isPressed         bool
 	isMouseOver       bool
-	canPress          bool
-	textures          []rl.Texture2D
+	regions           []*atlas.Region
 	lastFrameTime     float64
 	playedCount       int
 	playMode          playMode
@@ -36,28 +47,36 @@ type Sprite struct {
 	subStartingFrame  int
 	subEndingFrame    int
 	playLoop          bool
-	blendMode         blendMode
-	paletteShift      int
-	onMouseButtonDown func()
-	onMouseButtonUp   func()
-	onMouseOver       func()
-	onMouseLeave      func()
+	blendMode         renderer.BlendMode
+	paletteShift      float64
+	onMouseButtonDown mouseHandler
+	onMouseButtonUp   mouseHandler
+	onMouseOver       mouseHandler
+	onMouseLeave      mouseHandler
+	onDoubleClick     mouseHandler
+	onDragStart       mouseHandler
+	onDrag            mouseHandler
+	onDragEnd         mouseHandler
+	onMouseWheel      mouseHandler
 }
 
-func New(loaderProvider common.LoaderProvider, mousePosProvider common.MousePositionProvider,
-	filePath, palette string) (*Sprite, error) {
+// New loads a sprite, whose frames are packed into the atlas as they are drawn.
+func New(resourceProvider common.ResourceProvider, renderProvider renderer.Renderer, textureAtlas *atlas.Atlas,
+	mouse common.MouseProvider, filePath, palette string) (*Sprite, error) {
 	result := &Sprite{
 		Node:             node.New(),
-		mousePosProvider: mousePosProvider,
+		renderer:         renderProvider,
+		atlas:            textureAtlas,
+		resourceProvider: resourceProvider,
+		filePath:         filePath,
+		mouse:            mouse,
 		Visible:          true,
 		currentSequence:  0,
 		CurrentFrame:     0,
 		CellSizeX:        1,
 		CellSizeY:        1,
-		textures:         make([]rl.Texture2D, 0),
-		isPressed:        false,
+		regions:          make([]*atlas.Region, 0),
 		isMouseOver:      false,
-		canPress:         true,
 		playMode:         playModePause,
 		playLength:       defaultPlayLength,
 		playedCount:      0,
@@ -71,58 +90,82 @@ func New(loaderProvider common.LoaderProvider, mousePosProvider common.MousePosi
 
 	result.RenderCallback = result.render
 	result.UpdateCallback = result.update
-
-	fileExt := strings.ToLower(path.Ext(filePath))
-
-	fileStream, err := loaderProvider.Load(filePath)
-	defer fileStream.Close()
-
-	if err != nil {
-		return nil, err
-	}
+	result.ToLuaCallback = result.ToLua
+	result.BoundsCallback = result.bounds
+	result.Type = luaTypeExportName
+	result.ReloadCallback = result.reload
+	result.DestroyCallback = result.Destroy
+	result.HitTestCallback = result.hitTest
+	result.MouseCallback = result.handleMouse
 
 	_, ok := common.PaletteTexture[palette]
 	if !ok {
 		return nil, errors.New("sprite loaded with non-existent palette")
 	}
 
-	switch fileExt {
-	case ".dcc":
-		bytes, err := ioutil.ReadAll(fileStream)
+	if err := result.loadSequences(); err != nil {
+		return nil, err
+	}
 
-		if err != nil {
-			return nil, err
-		}
+	result.regions = make([]*atlas.Region, result.Sequences.FrameCount(result.CurrentSequence()))
+	return result, nil
+}
 
-		dccRes, err := dcc.FromBytes(bytes)
+func (s *Sprite) loadSequences() error {
+	var handle common.ResourceHandle
+	var err error
 
-		if err != nil {
-			return nil, err
+	switch strings.ToLower(path.Ext(s.filePath)) {
+	case ".dcc":
+		if handle, err = s.resourceProvider.Acquire(s.filePath, common.ResourceTypeDCC); err != nil {
+			return err
 		}
 
-		result.Sequences = &common.DCCSequenceProvider{Sequences: dccRes.Directions()}
+		s.Sequences = &common.DCCSequenceProvider{Sequences: handle.Value().(*dcc.DCC).Directions()}
 
 	case ".dc6":
-		bytes, err := ioutil.ReadAll(fileStream)
-
-		if err != nil {
-			return nil, err
+		if handle, err = s.resourceProvider.Acquire(s.filePath, common.ResourceTypeDC6); err != nil {
+			return err
 		}
 
-		dc6Res, err := dc6.FromBytes(bytes)
+		s.Sequences = &common.DC6SequenceProvider{Sequences: handle.Value().(*dc6.DC6).Directions}
 
-		if err != nil {
-			return nil, err
-		}
+	default:
+		return errors.New("unsupported file format")
+	}
 
-		result.Sequences = &common.DC6SequenceProvider{Sequences: dc6Res.Directions}
+	if s.resource != nil {
+		s.resource.Release()
+	}
 
-	default:
-		return nil, errors.New("unsupported file format")
+	s.resource = handle
+
+	return nil
+}
+
+// reload loads the sprite's file again if it is the one that changed, keeping the current sequence and frame where
+// the new file still has them.
+func (s *Sprite) reload(changedPath string) {
+	if !strings.EqualFold(loader.NormalizePath(changedPath), loader.NormalizePath(s.filePath)) {
+		return
 	}
 
-	result.textures = make([]rl.Texture2D, result.Sequences.FrameCount(result.CurrentSequence()))
-	return result, nil
+	if err := s.loadSequences(); err != nil {
+		log.Error().Err(err).Msgf("failed to reload %s", s.filePath)
+		return
+	}
+
+	s.releaseRegions()
+
+	if s.currentSequence >= s.Sequences.SequenceCount() {
+		s.currentSequence = 0
+	}
+
+	if s.CurrentFrame >= s.Sequences.FrameCount(s.currentSequence) {
+		s.CurrentFrame = 0
+	}
+
+	s.regions = make([]*atlas.Region, s.Sequences.FrameCount(s.CurrentSequence()))
 }
 
 func (s *Sprite) CurrentSequence() int {
@@ -134,24 +177,56 @@ func (s *Sprite) SetSequence(seqId int) {
 		return
 	}
 
-	for texIdx := range s.textures {
-		rl.UnloadTexture(s.textures[texIdx])
-	}
+	s.releaseRegions()
 
 	s.currentSequence = seqId
-	s.textures = make([]rl.Texture2D, s.Sequences.FrameCount(s.CurrentSequence()))
+	s.regions = make([]*atlas.Region, s.Sequences.FrameCount(s.CurrentSequence()))
 }
 
 func (s *Sprite) setPalette(palette string) {
 	s.palette = palette
 }
 
+// PaletteShift returns the palette transform the sprite is drawn with.
+func (s *Sprite) PaletteShift() float64 {
+	return s.paletteShift
+}
+
+// SetPaletteShift sets the palette transform the sprite is drawn with. Fractions are rounded to the nearest transform,
+// so that the shift can be animated.
+func (s *Sprite) SetPaletteShift(shift float64) {
+	s.paletteShift = shift
+}
+
+// SetMouseButtonDownHandler sets the function called when a mouse button goes down over the sprite.
+func (s *Sprite) SetMouseButtonDownHandler(handler func(event input.MouseEvent)) {
+	s.onMouseButtonDown = handler
+}
+
+// SetMouseButtonUpHandler sets the function called when a mouse button that went down over the sprite goes up over it.
+func (s *Sprite) SetMouseButtonUpHandler(handler func(event input.MouseEvent)) {
+	s.onMouseButtonUp = handler
+}
+
 func (s *Sprite) Destroy() {
 	s.ShouldRemove = true
 	s.Active = false
 
-	for idx := range s.textures {
-		rl.UnloadTexture(s.textures[idx])
+	s.releaseRegions()
+
+	if s.resource != nil {
+		s.resource.Release()
+		s.resource = nil
 	}
+}
 
+func (s *Sprite) releaseRegions() {
+	for idx := range s.regions {
+		if s.regions[idx] == nil {
+			continue
+		}
+
+		s.atlas.Remove(s.regions[idx])
+		s.regions[idx] = nil
+	}
 }
@@ -1,56 +1,113 @@
 package sprite
 
-import rl "github.com/gen2brain/raylib-go/raylib"
+import (
+	"github.com/OpenDiablo2/AbyssEngine/input"
+	"github.com/OpenDiablo2/AbyssEngine/node"
+)
 
 func (s *Sprite) update(elapsed float64) {
-	if s.onMouseButtonUp != nil || s.onMouseButtonDown != nil || s.onMouseOver != nil || s.onMouseLeave != nil {
-		mx, my := s.mousePosProvider.GetMousePosition()
-		posX, posY := s.GetPosition()
-		mouseIsOver := mx >= posX && my >= posY && mx < (posX+int(s.textures[s.CurrentFrame].Width)) && my < (posY+int(s.textures[s.CurrentFrame].Height))
+	s.animate(elapsed)
 
-		if rl.IsMouseButtonDown(rl.MouseLeftButton) {
-			if !s.isPressed {
-				if s.canPress && mouseIsOver {
+	if s.regions[s.CurrentFrame] == nil {
+		s.initializeTexture()
+	}
+}
 
-					s.isPressed = true
+// handlesButtons reports whether the sprite has a handler that needs it to capture the mouse.
+func (s *Sprite) handlesButtons() bool {
+	return s.onMouseButtonDown != nil || s.onMouseButtonUp != nil || s.onDoubleClick != nil ||
+		s.onDragStart != nil || s.onDrag != nil || s.onDragEnd != nil
+}
 
-					if s.onMouseButtonDown != nil {
-						s.onMouseButtonDown()
-					}
-				} else {
-					s.canPress = false
-				}
-			}
+// takesMouse reports whether the sprite takes mouse input, which it does if it blocks the mouse or has a handler.
+func (s *Sprite) takesMouse() bool {
+	return s.BlocksMouse || s.handlesButtons() || s.onMouseOver != nil || s.onMouseLeave != nil ||
+		s.onMouseWheel != nil
+}
 
-		} else {
-			if s.isPressed {
-				s.isPressed = false
-
-				if mouseIsOver {
-					if s.onMouseButtonUp != nil {
-						s.onMouseButtonUp()
-					}
-				}
-			}
-			s.canPress = true
+// hitTest reports whether the point is inside the current frame, where it is drawn. Sprites that don't take mouse
+// input let the mouse through to the nodes behind them.
+func (s *Sprite) hitTest(x, y int) bool {
+	if !s.Visible || !s.takesMouse() {
+		return false
+	}
+
+	left, top, width, height, ok := s.bounds()
+
+	if !ok {
+		return false
+	}
+
+	inverse, ok := s.WorldTransform().Invert()
+
+	if !ok {
+		return false
+	}
+
+	localX, localY := inverse.Apply(float64(x), float64(y))
+
+	return localX >= left && localY >= top && localX < left+width && localY < top+height
+}
+
+// handleMouse runs the mouse handlers for an event sent to the sprite. A sprite captures the mouse when a button goes
+// down over it, and then gets the button up, double click and drag events until all buttons are released. Button up
+// and double click events are only handled while the mouse is over the sprite, so they act as clicks. Events the
+// sprite has a handler for are not sent on to its parents.
+func (s *Sprite) handleMouse(event *node.MouseEvent) {
+	var handler mouseHandler
+
+	switch event.Type {
+	case input.MouseEventOver:
+		s.isMouseOver = true
+		handler = s.onMouseOver
+	case input.MouseEventLeave:
+		s.isMouseOver = false
+		handler = s.onMouseLeave
+	case input.MouseEventDown:
+		if !s.handlesButtons() || !s.mouse.Capture(s.Node) {
+			return
 		}
 
-		if mouseIsOver && !s.isMouseOver {
-			s.isMouseOver = true
-			if s.onMouseOver != nil {
-				s.onMouseOver()
-			}
-		} else if !mouseIsOver && s.isMouseOver {
-			s.isMouseOver = false
-			if s.onMouseLeave != nil {
-				s.onMouseLeave()
-			}
+		handler = s.onMouseButtonDown
+		event.StopPropagation()
+	case input.MouseEventUp, input.MouseEventClick, input.MouseEventDoubleClick:
+		if !s.mouse.HasCapture(s.Node) {
+			return
 		}
-	}
 
-	s.animate(elapsed)
+		event.StopPropagation()
 
-	if s.textures[s.CurrentFrame].ID == 0 {
-		s.initializeTexture()
+		if !s.isMouseOver {
+			return
+		}
+
+		if event.Type == input.MouseEventUp {
+			handler = s.onMouseButtonUp
+		} else if event.Type == input.MouseEventDoubleClick {
+			handler = s.onDoubleClick
+		}
+	case input.MouseEventDragStart, input.MouseEventDrag, input.MouseEventDragEnd:
+		if !s.mouse.HasCapture(s.Node) {
+			return
+		}
+
+		event.StopPropagation()
+
+		if event.Type == input.MouseEventDragStart {
+			handler = s.onDragStart
+		} else if event.Type == input.MouseEventDrag {
+			handler = s.onDrag
+		} else {
+			handler = s.onDragEnd
+		}
+	case input.MouseEventWheel:
+		handler = s.onMouseWheel
+	}
+
+	if handler == nil {
+		return
 	}
+
+	handler(event.MouseEvent)
+	event.StopPropagation()
 }
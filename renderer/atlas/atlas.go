@@ -0,0 +1,280 @@
+package atlas
+
+import (
+	"image"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+)
+
+const (
+	// DefaultPageSize is the width and height of the atlas pages, in pixels.
+	DefaultPageSize = 1024
+	// padding is the gap left around each image, so that its neighbours do not bleed into it when it is scaled.
+	padding = 1
+)
+
+// Atlas packs palette-indexed images, such as sprite frames, font glyphs and map tiles, into shared grayscale pages,
+// so that they are drawn from a few large textures instead of a small texture each. Images are packed into shelves:
+// rows as tall as the tallest image in them, filled from left to right.
+//
+// The space of a removed image is cleared and kept in a free list, merged with the free space next to it, and reused
+// before the shelves grow. A page is emptied once all of its images are removed. Images larger than a page get a page
+// of their own, which is unloaded with them.
+type Atlas struct {
+	renderer renderer.Renderer
+	pageSize int
+	pages    []*page
+	glyphs   map[GlyphKey]*glyph
+}
+
+type page struct {
+	texture   renderer.Texture
+	shelves   []shelf
+	shelfTop  int
+	free      []image.Rectangle
+	regions   int
+	dedicated bool
+}
+
+type shelf struct {
+	y      int
+	height int
+	x      int
+}
+
+// Stats counts the pages of an atlas, and the images packed into them.
+type Stats struct {
+	Pages   int `json:"pages"`
+	Regions int `json:"regions"`
+	Glyphs  int `json:"glyphs"`
+}
+
+func New(renderProvider renderer.Renderer, pageSize int) *Atlas {
+	result := &Atlas{
+		renderer: renderProvider,
+		pageSize: pageSize,
+		pages:    make([]*page, 0),
+		glyphs:   make(map[GlyphKey]*glyph),
+	}
+
+	return result
+}
+
+// Add packs an image into the atlas, and returns the region it is in.
+func (a *Atlas) Add(pixels []byte, width, height int) *Region {
+	if width <= 0 || height <= 0 {
+		return &Region{}
+	}
+
+	if width+padding > a.pageSize || height+padding > a.pageSize {
+		p := &page{
+			texture:   a.renderer.LoadIndexedTexture(pixels[:width*height], width, height),
+			regions:   1,
+			dedicated: true,
+		}
+
+		a.pages = append(a.pages, p)
+
+		return &Region{page: p, rect: image.Rect(0, 0, width, height)}
+	}
+
+	var slot image.Rectangle
+	var target *page
+
+	for _, p := range a.pages {
+		if p.dedicated {
+			continue
+		}
+
+		if s, ok := a.allocate(p, width, height); ok {
+			slot, target = s, p
+			break
+		}
+	}
+
+	if target == nil {
+		target = &page{
+			texture: a.renderer.LoadIndexedTexture(make([]byte, a.pageSize*a.pageSize), a.pageSize, a.pageSize),
+		}
+
+		a.pages = append(a.pages, target)
+		slot, _ = a.allocate(target, width, height)
+	}
+
+	rect := image.Rect(slot.Min.X, slot.Min.Y, slot.Min.X+width, slot.Min.Y+height)
+
+	a.renderer.UpdateIndexedTexture(target.texture, rect.Min.X, rect.Min.Y, width, height, pixels)
+	target.regions++
+
+	return &Region{page: target, rect: rect, slot: slot}
+}
+
+// allocate finds room for an image and its padding on a page: in the smallest free space it fits in, on the lowest
+// shelf it fits on, or on a new shelf.
+func (a *Atlas) allocate(p *page, width, height int) (image.Rectangle, bool) {
+	if result, ok := allocateFree(p, width+padding, height+padding); ok {
+		return result, true
+	}
+
+	best := -1
+
+	for idx := range p.shelves {
+		s := &p.shelves[idx]
+
+		if s.height < height+padding || s.x+width+padding > a.pageSize {
+			continue
+		}
+
+		if best == -1 || s.height < p.shelves[best].height {
+			best = idx
+		}
+	}
+
+	if best == -1 {
+		if p.shelfTop+height+padding > a.pageSize {
+			return image.Rectangle{}, false
+		}
+
+		p.shelves = append(p.shelves, shelf{y: p.shelfTop, height: height + padding})
+		p.shelfTop += height + padding
+		best = len(p.shelves) - 1
+	}
+
+	s := &p.shelves[best]
+	result := image.Rect(s.x, s.y, s.x+width+padding, s.y+s.height)
+	s.x += width + padding
+
+	return result, true
+}
+
+// allocateFree takes room from the smallest free space on a page that is large enough. What is left of the space to
+// the right of and below the room stays free.
+func allocateFree(p *page, width, height int) (image.Rectangle, bool) {
+	best := -1
+
+	for idx, free := range p.free {
+		if free.Dx() < width || free.Dy() < height {
+			continue
+		}
+
+		if best == -1 || free.Dx()*free.Dy() < p.free[best].Dx()*p.free[best].Dy() {
+			best = idx
+		}
+	}
+
+	if best == -1 {
+		return image.Rectangle{}, false
+	}
+
+	free := p.free[best]
+	p.free = append(p.free[:best], p.free[best+1:]...)
+	result := image.Rect(free.Min.X, free.Min.Y, free.Min.X+width, free.Min.Y+height)
+
+	if right := image.Rect(result.Max.X, free.Min.Y, free.Max.X, result.Max.Y); !right.Empty() {
+		p.free = append(p.free, right)
+	}
+
+	if below := image.Rect(free.Min.X, result.Max.Y, free.Max.X, free.Max.Y); !below.Empty() {
+		p.free = append(p.free, below)
+	}
+
+	return result, true
+}
+
+// release clears the space of a removed image, and adds it to the free space of its page, merged with any free space
+// that shares a whole side with it.
+func (a *Atlas) release(p *page, slot image.Rectangle) {
+	a.renderer.UpdateIndexedTexture(p.texture, slot.Min.X, slot.Min.Y, slot.Dx(), slot.Dy(),
+		make([]byte, slot.Dx()*slot.Dy()))
+
+	for merged := true; merged; {
+		merged = false
+
+		for idx, free := range p.free {
+			if !sharesSide(free, slot) {
+				continue
+			}
+
+			slot = slot.Union(free)
+			p.free = append(p.free[:idx], p.free[idx+1:]...)
+			merged = true
+
+			break
+		}
+	}
+
+	p.free = append(p.free, slot)
+}
+
+// sharesSide reports whether two rectangles are next to each other, along the whole of a side of both.
+func sharesSide(a, b image.Rectangle) bool {
+	if a.Min.Y == b.Min.Y && a.Max.Y == b.Max.Y {
+		return a.Max.X == b.Min.X || b.Max.X == a.Min.X
+	}
+
+	if a.Min.X == b.Min.X && a.Max.X == b.Max.X {
+		return a.Max.Y == b.Min.Y || b.Max.Y == a.Min.Y
+	}
+
+	return false
+}
+
+// Remove frees the region of an image. The region must not be drawn after it is removed.
+func (a *Atlas) Remove(region *Region) {
+	p := region.page
+
+	if p == nil {
+		return
+	}
+
+	region.page = nil
+	p.regions--
+
+	if !p.dedicated {
+		a.release(p, region.slot)
+	}
+
+	if p.regions > 0 {
+		return
+	}
+
+	if !p.dedicated {
+		p.shelves = p.shelves[:0]
+		p.shelfTop = 0
+		p.free = p.free[:0]
+
+		return
+	}
+
+	a.renderer.UnloadTexture(p.texture)
+
+	for idx := range a.pages {
+		if a.pages[idx] == p {
+			a.pages = append(a.pages[:idx], a.pages[idx+1:]...)
+			break
+		}
+	}
+}
+
+// Destroy unloads all of the pages. The regions of the atlas must not be drawn after it is destroyed.
+func (a *Atlas) Destroy() {
+	for _, p := range a.pages {
+		a.renderer.UnloadTexture(p.texture)
+	}
+
+	a.pages = a.pages[:0]
+
+	for key := range a.glyphs {
+		delete(a.glyphs, key)
+	}
+}
+
+func (a *Atlas) Stats() Stats {
+	result := Stats{Pages: len(a.pages), Glyphs: len(a.glyphs)}
+
+	for _, p := range a.pages {
+		result.Regions += p.regions
+	}
+
+	return result
+}
@@ -0,0 +1,132 @@
+package atlas
+
+import (
+	"image"
+	"testing"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer/headlessrenderer"
+)
+
+const testPageSize = 64
+
+func filled(width, height int) []byte {
+	result := make([]byte, width*height)
+
+	for idx := range result {
+		result[idx] = 1
+	}
+
+	return result
+}
+
+func TestRemovedSpaceIsReused(t *testing.T) {
+	a := New(headlessrenderer.New(8, 8, 0), testPageSize)
+
+	// fill the page with 4x4 images, 12 of them on each of 12 shelves (5 pixels each, with padding)
+	regions := make([]*Region, 0)
+
+	for idx := 0; idx < 12*12; idx++ {
+		regions = append(regions, a.Add(filled(4, 4), 4, 4))
+	}
+
+	if stats := a.Stats(); stats.Pages != 1 {
+		t.Fatalf("the images take %d pages, want 1", stats.Pages)
+	}
+
+	// free two images next to each other, which merge into room for a wider one
+	first, second := regions[13], regions[14]
+	a.Remove(first)
+	a.Remove(second)
+
+	wide := a.Add(filled(9, 4), 9, 4)
+
+	if wide.Texture() != regions[0].Texture() || wide.Rect() != image.Rect(first.rect.Min.X, first.rect.Min.Y,
+		first.rect.Min.X+9, first.rect.Min.Y+4) {
+		t.Errorf("the wide image is at %v, want it where the removed images were", wide.Rect())
+	}
+
+	if stats := a.Stats(); stats.Pages != 1 || stats.Regions != 12*12-1 {
+		t.Errorf("stats are %+v", stats)
+	}
+
+	// no space is left for another image, so it goes on a new page
+	if extra := a.Add(filled(4, 4), 4, 4); extra.Texture() == regions[0].Texture() {
+		t.Errorf("an image was packed into %v, which is not free", extra.Rect())
+	}
+}
+
+func TestRemovedSpaceIsCleared(t *testing.T) {
+	r := headlessrenderer.New(8, 8, 0)
+	a := New(r, testPageSize)
+
+	kept := a.Add(filled(4, 4), 4, 4)
+	removed := a.Add(filled(4, 4), 4, 4)
+	a.Remove(removed)
+
+	// a smaller image in the same space must not be drawn next to what was there before
+	small := a.Add(filled(2, 2), 2, 2)
+
+	if small.Rect().Min != removed.rect.Min {
+		t.Fatalf("the small image is at %v, want %v", small.Rect(), removed.rect)
+	}
+
+	pixels := pagePixels(t, r, kept)
+
+	for y := 0; y < 5; y++ {
+		for x := small.Rect().Min.X; x < small.Rect().Min.X+5; x++ {
+			want := byte(0)
+
+			if x < small.Rect().Max.X && y < small.Rect().Max.Y {
+				want = 1
+			}
+
+			if got := pixels[x+(y*testPageSize)]; got != want {
+				t.Fatalf("pixel %d,%d is %d, want %d", x, y, got, want)
+			}
+		}
+	}
+}
+
+// pagePixels returns the indexed pixels of the page a region is on.
+func pagePixels(t *testing.T, r *headlessrenderer.HeadlessRenderer, region *Region) []byte {
+	t.Helper()
+
+	pixels, ok := r.IndexedPixels(region.Texture())
+
+	if !ok {
+		t.Fatal("the page is not an indexed texture")
+	}
+
+	return pixels
+}
+
+func TestGlyphsAreShared(t *testing.T) {
+	a := New(headlessrenderer.New(8, 8, 0), testPageSize)
+	key := GlyphKey{Font: "/data/font8", Rune: 'a'}
+	loads := 0
+
+	load := func() ([]byte, int, int) {
+		loads++
+		return filled(3, 5), 3, 5
+	}
+
+	first := a.AcquireGlyph(key, load)
+	second := a.AcquireGlyph(key, load)
+	other := a.AcquireGlyph(GlyphKey{Font: "/data/font16", Rune: 'a'}, load)
+
+	if first != second || first == other || loads != 2 {
+		t.Fatalf("glyphs were loaded %d times", loads)
+	}
+
+	a.ReleaseGlyph(key)
+
+	if first.Empty() || a.Stats().Glyphs != 2 {
+		t.Fatal("a glyph that is still used was removed")
+	}
+
+	a.ReleaseGlyph(key)
+
+	if !first.Empty() || a.Stats().Glyphs != 1 || a.Stats().Regions != 1 {
+		t.Fatalf("a glyph that is no longer used was kept, stats are %+v", a.Stats())
+	}
+}
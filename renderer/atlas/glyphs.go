@@ -0,0 +1,51 @@
+package atlas
+
+// GlyphKey is a character of a font. Glyphs are packed as palette indices, so labels that draw the same text with the
+// same font share the glyphs of the atlas whatever their palette.
+type GlyphKey struct {
+	Font string
+	Rune rune
+}
+
+// glyph is a glyph packed into the atlas, and the number of times it was acquired and not released.
+type glyph struct {
+	region   *Region
+	refCount int
+}
+
+// GlyphLoader returns the image of a glyph, to be packed into the atlas.
+type GlyphLoader func() (pixels []byte, width, height int)
+
+// AcquireGlyph returns the region of a glyph, calling load to pack it into the atlas if it is not in it yet. Every
+// call must be matched by a call to ReleaseGlyph.
+func (a *Atlas) AcquireGlyph(key GlyphKey, load GlyphLoader) *Region {
+	if g, ok := a.glyphs[key]; ok {
+		g.refCount++
+
+		return g.region
+	}
+
+	pixels, width, height := load()
+	g := &glyph{region: a.Add(pixels, width, height), refCount: 1}
+	a.glyphs[key] = g
+
+	return g.region
+}
+
+// ReleaseGlyph drops a reference to a glyph, removing it from the atlas once nothing uses it.
+func (a *Atlas) ReleaseGlyph(key GlyphKey) {
+	g, ok := a.glyphs[key]
+
+	if !ok {
+		return
+	}
+
+	g.refCount--
+
+	if g.refCount > 0 {
+		return
+	}
+
+	a.Remove(g.region)
+	delete(a.glyphs, key)
+}
@@ -0,0 +1,56 @@
+package atlas
+
+import (
+	"image"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+)
+
+// Region is the rectangle of an atlas page that holds an image. The region of an empty image has no page, and is not
+// drawn.
+type Region struct {
+	page *page
+	rect image.Rectangle
+	// slot is the space the image takes on its page, with its padding, which is freed when it is removed.
+	slot image.Rectangle
+}
+
+// Texture returns the page the image is on, or nil for an empty image.
+func (r *Region) Texture() renderer.Texture {
+	if r.page == nil {
+		return nil
+	}
+
+	return r.page.texture
+}
+
+// Rect returns where the image is on its page, in pixels.
+func (r *Region) Rect() image.Rectangle {
+	return r.rect
+}
+
+// UV returns where the image is on its page, in texture coordinates from 0 to 1.
+func (r *Region) UV() (u0, v0, u1, v1 float32) {
+	tex := r.Texture()
+
+	if tex == nil {
+		return 0, 0, 0, 0
+	}
+
+	width, height := float32(tex.Width()), float32(tex.Height())
+
+	return float32(r.rect.Min.X) / width, float32(r.rect.Min.Y) / height, float32(r.rect.Max.X) / width,
+		float32(r.rect.Max.Y) / height
+}
+
+func (r *Region) Width() int {
+	return r.rect.Dx()
+}
+
+func (r *Region) Height() int {
+	return r.rect.Dy()
+}
+
+func (r *Region) Empty() bool {
+	return r.page == nil || r.rect.Empty()
+}
@@ -0,0 +1,12 @@
+package renderer
+
+type BlendMode int
+
+const (
+	BlendModeNone BlendMode = iota
+	BlendModeAlpha
+	BlendModeAdditive
+	BlendModeMultiplied
+	BlendModeAddColors
+	BlendModeSubtractColors
+)
@@ -0,0 +1,178 @@
+package headlessrenderer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	"github.com/OpenDiablo2/AbyssEngine/renderer/atlas"
+)
+
+const (
+	sceneSprites = 500
+	sceneColumns = 25
+	frameSize    = 8
+)
+
+// scene is a grid of sprites, whose frames are packed into an atlas like those of sprite nodes. Every other sprite is
+// drawn with the second palette transform, so that the frame is drawn with two different states.
+type scene struct {
+	renderer *HeadlessRenderer
+	palette  renderer.Texture
+	frames   []*atlas.Region
+}
+
+func newScene() *scene {
+	r := New(sceneColumns*frameSize, (sceneSprites/sceneColumns)*frameSize, 0)
+	a := atlas.New(r, atlas.DefaultPageSize)
+	result := &scene{renderer: r, palette: r.LoadPaletteTexture(testPalette(), transformCount)}
+
+	for idx := 0; idx < 4; idx++ {
+		pixels := make([]byte, frameSize*frameSize)
+
+		for p := range pixels {
+			pixels[p] = byte(1 + idx*10 + p%7)
+		}
+
+		result.frames = append(result.frames, a.Add(pixels, frameSize, frameSize))
+	}
+
+	return result
+}
+
+// draw draws a frame of the scene, and returns the number of draw calls it took to draw the sprites.
+func (s *scene) draw() int {
+	s.renderer.BeginSurface()
+
+	for idx := 0; idx < sceneSprites; idx++ {
+		frame := s.frames[idx%len(s.frames)]
+		x, y := (idx%sceneColumns)*frameSize, (idx/sceneColumns)*frameSize
+
+		s.renderer.DrawIndexedTextureRegion(frame.Texture(), s.palette, float32(idx%2), frame.Rect(),
+			renderer.Translation(float64(x), float64(y)), color.White, renderer.BlendModeNone)
+	}
+
+	s.renderer.EndSurface()
+	s.renderer.BeginScreen()
+	s.renderer.DrawSurface(0, 0, float32(s.renderer.surface.Rect.Dx()), float32(s.renderer.surface.Rect.Dy()))
+	s.renderer.EndScreen()
+
+	// the surface is drawn onto the screen with one more draw call
+	return s.renderer.Stats().DrawCalls - 1
+}
+
+func TestBatchingSpriteScene(t *testing.T) {
+	s := newScene()
+
+	if drawCalls := s.draw(); drawCalls != 2 {
+		t.Errorf("the batched scene took %d draw calls, want one for each palette transform", drawCalls)
+	}
+
+	batched := image.NewRGBA(s.renderer.Surface().Rect)
+	copy(batched.Pix, s.renderer.Surface().Pix)
+
+	s.renderer.SetBatching(false)
+
+	if drawCalls := s.draw(); drawCalls != sceneSprites {
+		t.Errorf("the scene took %d draw calls without batching, want one for each sprite", drawCalls)
+	}
+
+	if !imagesEqual(batched, s.renderer.Surface()) {
+		t.Error("the batched scene does not look the same as the scene drawn without batching")
+	}
+}
+
+func TestBatchingKeepsDrawOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		lastX     float64
+		drawCalls int
+	}{
+		// the last sprite is drawn over the other texture, so it cannot join the batch of the first one
+		{name: "overlapping", lastX: 4, drawCalls: 3},
+		{name: "apart", lastX: 16, drawCalls: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := New(surfaceSize, surfaceSize, 0)
+			palette := r.LoadPaletteTexture(testPalette(), transformCount)
+			red := r.LoadIndexedTexture(solid(3), 8, 8)
+			green := r.LoadIndexedTexture(solid(4), 8, 8)
+			draw := func(tex renderer.Texture, x float64) {
+				r.DrawIndexedTextureRegion(tex, palette, 0, image.Rect(0, 0, 8, 8), renderer.Translation(x, 0),
+					color.White, renderer.BlendModeNone)
+			}
+
+			r.BeginSurface()
+			draw(red, 0)
+			draw(green, 2)
+			draw(red, test.lastX)
+			r.EndSurface()
+			r.BeginScreen()
+			r.EndScreen()
+
+			if drawCalls := r.Stats().DrawCalls; drawCalls != test.drawCalls {
+				t.Errorf("took %d draw calls, want %d", drawCalls, test.drawCalls)
+			}
+
+			// where the second red sprite is drawn over the green one, red must be on top
+			if got, want := r.Surface().RGBAAt(int(test.lastX), 0), r.Surface().RGBAAt(0, 0); got != want {
+				t.Errorf("the last sprite is drawn as %v, want %v", got, want)
+			}
+
+			if got := r.Surface().RGBAAt(3, 0); test.lastX > 3 && got == r.Surface().RGBAAt(0, 0) {
+				t.Errorf("the green sprite is hidden by the first red one")
+			}
+		})
+	}
+}
+
+func BenchmarkSpriteScene(b *testing.B) {
+	for _, batching := range []bool{true, false} {
+		name := "batched"
+
+		if !batching {
+			name = "unbatched"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			s := newScene()
+			s.renderer.SetBatching(batching)
+
+			drawCalls := 0
+
+			for idx := 0; idx < b.N; idx++ {
+				drawCalls = s.draw()
+			}
+
+			b.ReportMetric(float64(drawCalls), "drawcalls/frame")
+		})
+	}
+}
+
+// solid returns the pixels of an 8x8 image of a single palette index.
+func solid(index byte) []byte {
+	result := make([]byte, 64)
+
+	for idx := range result {
+		result[idx] = index
+	}
+
+	return result
+}
+
+func imagesEqual(a, b *image.RGBA) bool {
+	if !a.Rect.Eq(b.Rect) {
+		return false
+	}
+
+	for idx := range a.Pix {
+		if a.Pix[idx] != b.Pix[idx] {
+			return false
+		}
+	}
+
+	return true
+}
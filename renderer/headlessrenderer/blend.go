@@ -0,0 +1,79 @@
+package headlessrenderer
+
+import (
+	"image/color"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+)
+
+func blend(dst, src color.RGBA, blendMode renderer.BlendMode) color.RGBA {
+	switch blendMode {
+	case renderer.BlendModeAdditive:
+		return color.RGBA{
+			R: clampAdd(dst.R, mul(src.R, src.A)),
+			G: clampAdd(dst.G, mul(src.G, src.A)),
+			B: clampAdd(dst.B, mul(src.B, src.A)),
+			A: dst.A,
+		}
+	case renderer.BlendModeMultiplied:
+		if src.A == 0 {
+			return dst
+		}
+
+		return color.RGBA{R: mul(dst.R, src.R), G: mul(dst.G, src.G), B: mul(dst.B, src.B), A: dst.A}
+	case renderer.BlendModeAddColors:
+		return color.RGBA{
+			R: clampAdd(dst.R, src.R),
+			G: clampAdd(dst.G, src.G),
+			B: clampAdd(dst.B, src.B),
+			A: dst.A,
+		}
+	case renderer.BlendModeSubtractColors:
+		return color.RGBA{
+			R: clampSub(dst.R, src.R),
+			G: clampSub(dst.G, src.G),
+			B: clampSub(dst.B, src.B),
+			A: dst.A,
+		}
+	default:
+		return alphaBlend(dst, src)
+	}
+}
+
+func alphaBlend(dst, src color.RGBA) color.RGBA {
+	switch src.A {
+	case 0:
+		return dst
+	case 255:
+		return src
+	}
+
+	inv := 255 - src.A
+
+	return color.RGBA{
+		R: mul(src.R, src.A) + mul(dst.R, inv),
+		G: mul(src.G, src.A) + mul(dst.G, inv),
+		B: mul(src.B, src.A) + mul(dst.B, inv),
+		A: src.A + mul(dst.A, inv),
+	}
+}
+
+func mul(a, b uint8) uint8 {
+	return uint8((uint16(a) * uint16(b)) / 255)
+}
+
+func clampAdd(a, b uint8) uint8 {
+	if int(a)+int(b) > 255 {
+		return 255
+	}
+
+	return a + b
+}
+
+func clampSub(a, b uint8) uint8 {
+	if b > a {
+		return 0
+	}
+
+	return a - b
+}
@@ -0,0 +1,535 @@
+package headlessrenderer
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strings"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+)
+
+const frameTime = 1.0 / 60.0
+
+type texture struct {
+	width   int
+	height  int
+	indexed []byte
+	rgba    *image.RGBA
+}
+
+func (t *texture) Width() int {
+	return t.width
+}
+
+func (t *texture) Height() int {
+	return t.height
+}
+
+// bytes returns the memory taken by the pixels of the texture.
+func (t *texture) bytes() int64 {
+	if t.rgba != nil {
+		return int64(len(t.rgba.Pix))
+	}
+
+	return int64(len(t.indexed))
+}
+
+// HeadlessRenderer is a software renderer that draws into in-memory images instead of a window. Frames advance at a
+// fixed 60 frames per second, and mouse input is whatever was last set with SetMousePosition and SetMouseButtonDown.
+// Keys are held down with SetKeyDown. Like a windowed renderer, key presses, typed text and the mouse wheel are polled
+// when a frame is presented: keys passed to PressKey, text passed to TypeText and wheel movement passed to
+// MoveMouseWheel are reported for the whole frame after the next call to EndScreen.
+type HeadlessRenderer struct {
+	surface      *image.RGBA
+	screen       *image.RGBA
+	target       *image.RGBA
+	maxFrames    int
+	fullscreen   bool
+	frameCount   int
+	mouseX       int
+	mouseY       int
+	mouseButtons map[renderer.MouseButton]bool
+	queuedWheel  float32
+	mouseWheel   float32
+	keysDown     map[renderer.Key]bool
+	queuedKeys   map[renderer.Key]bool
+	pressedKeys  map[renderer.Key]bool
+	queuedChars  []rune
+	chars        []rune
+	clipboard    string
+	queue        renderer.Queue
+	batching     bool
+	drawCalls    int
+	quads        int
+	stats        renderer.Stats
+}
+
+// New creates a headless renderer with a screen and virtual surface of the given size. If maxFrames is greater than
+// zero, ShouldClose reports true once that many frames have been presented.
+func New(width, height, maxFrames int) *HeadlessRenderer {
+	result := &HeadlessRenderer{
+		surface:      image.NewRGBA(image.Rect(0, 0, width, height)),
+		screen:       image.NewRGBA(image.Rect(0, 0, width, height)),
+		maxFrames:    maxFrames,
+		mouseButtons: make(map[renderer.MouseButton]bool),
+		keysDown:     make(map[renderer.Key]bool),
+		queuedKeys:   make(map[renderer.Key]bool),
+		pressedKeys:  make(map[renderer.Key]bool),
+		batching:     true,
+	}
+
+	result.target = result.surface
+
+	return result
+}
+
+// Surface returns the virtual render surface as of the last frame drawn.
+func (r *HeadlessRenderer) Surface() *image.RGBA {
+	return r.surface
+}
+
+// Screen returns the screen image as of the last frame presented.
+func (r *HeadlessRenderer) Screen() *image.RGBA {
+	return r.screen
+}
+
+func (r *HeadlessRenderer) FrameCount() int {
+	return r.frameCount
+}
+
+func (r *HeadlessRenderer) SetMousePosition(x, y int) {
+	r.mouseX = x
+	r.mouseY = y
+}
+
+func (r *HeadlessRenderer) SetMouseButtonDown(button renderer.MouseButton, down bool) {
+	r.mouseButtons[button] = down
+}
+
+func (r *HeadlessRenderer) MoveMouseWheel(delta float32) {
+	r.queuedWheel += delta
+}
+
+func (r *HeadlessRenderer) SetKeyDown(key renderer.Key, down bool) {
+	r.keysDown[key] = down
+}
+
+func (r *HeadlessRenderer) PressKey(key renderer.Key) {
+	r.queuedKeys[key] = true
+}
+
+// TypeText queues the characters of the text, as if they were typed.
+func (r *HeadlessRenderer) TypeText(text string) {
+	r.queuedChars = append(r.queuedChars, []rune(text)...)
+}
+
+// IndexedPixels returns the pixels of an indexed texture, one palette index each, row by row.
+func (r *HeadlessRenderer) IndexedPixels(tex renderer.Texture) ([]byte, bool) {
+	t, ok := tex.(*texture)
+
+	if !ok || t.indexed == nil {
+		return nil, false
+	}
+
+	return t.indexed, true
+}
+
+// ClipboardText returns the text last passed to SetClipboardText.
+func (r *HeadlessRenderer) ClipboardText() string {
+	return r.clipboard
+}
+
+func (r *HeadlessRenderer) Name() string {
+	return "Headless Renderer"
+}
+
+func (r *HeadlessRenderer) Close() {
+}
+
+func (r *HeadlessRenderer) ShouldClose() bool {
+	return r.maxFrames > 0 && r.frameCount >= r.maxFrames
+}
+
+func (r *HeadlessRenderer) FrameTime() float64 {
+	return frameTime
+}
+
+func (r *HeadlessRenderer) FPS() int {
+	return int(1.0 / frameTime)
+}
+
+func (r *HeadlessRenderer) ScreenSize() (width, height int) {
+	return r.screen.Rect.Dx(), r.screen.Rect.Dy()
+}
+
+func (r *HeadlessRenderer) SetWindowSize(width, height int) {
+	r.screen = image.NewRGBA(image.Rect(0, 0, width, height))
+	r.target = r.surface
+}
+
+func (r *HeadlessRenderer) IsFullscreen() bool {
+	return r.fullscreen
+}
+
+// SetFullscreen only records the setting, the screen keeps its size.
+func (r *HeadlessRenderer) SetFullscreen(fullscreen bool) {
+	r.fullscreen = fullscreen
+}
+
+func (r *HeadlessRenderer) SurfaceSize() (width, height int) {
+	return r.surface.Rect.Dx(), r.surface.Rect.Dy()
+}
+
+func (r *HeadlessRenderer) SetSurfaceSize(width, height int) {
+	r.surface = image.NewRGBA(image.Rect(0, 0, width, height))
+	r.target = r.surface
+}
+
+func (r *HeadlessRenderer) MousePosition() (X, Y int) {
+	return r.mouseX, r.mouseY
+}
+
+func (r *HeadlessRenderer) IsMouseButtonDown(button renderer.MouseButton) bool {
+	return r.mouseButtons[button]
+}
+
+func (r *HeadlessRenderer) MouseWheelMove() float32 {
+	return r.mouseWheel
+}
+
+func (r *HeadlessRenderer) IsKeyDown(key renderer.Key) bool {
+	return r.keysDown[key]
+}
+
+func (r *HeadlessRenderer) IsKeyPressed(key renderer.Key) bool {
+	return r.pressedKeys[key]
+}
+
+func (r *HeadlessRenderer) CharPressed() rune {
+	if len(r.chars) == 0 {
+		return 0
+	}
+
+	result := r.chars[0]
+	r.chars = r.chars[1:]
+
+	return result
+}
+
+func (r *HeadlessRenderer) SetClipboardText(text string) {
+	r.clipboard = text
+}
+
+func (r *HeadlessRenderer) BeginSurface() {
+	r.target = r.surface
+	clearImage(r.target)
+}
+
+func (r *HeadlessRenderer) EndSurface() {
+	r.flush()
+}
+
+func (r *HeadlessRenderer) BeginScreen() {
+	r.target = r.screen
+	clearImage(r.target)
+}
+
+func (r *HeadlessRenderer) DrawSurface(x, y, width, height float32) {
+	r.flush()
+	r.drawCalls++
+	r.quads++
+
+	srcWidth := r.surface.Rect.Dx()
+	srcHeight := r.surface.Rect.Dy()
+
+	if width <= 0 || height <= 0 || srcWidth == 0 || srcHeight == 0 {
+		return
+	}
+
+	for dy := 0; dy < int(height); dy++ {
+		sy := dy * srcHeight / int(height)
+
+		for dx := 0; dx < int(width); dx++ {
+			sx := dx * srcWidth / int(width)
+			r.target.SetRGBA(int(x)+dx, int(y)+dy, r.surface.RGBAAt(sx, sy))
+		}
+	}
+}
+
+func (r *HeadlessRenderer) EndScreen() {
+	r.flush()
+	r.frameCount++
+
+	r.stats.DrawCalls = r.drawCalls
+	r.stats.Quads = r.quads
+	r.drawCalls = 0
+	r.quads = 0
+
+	r.pressedKeys = r.queuedKeys
+	r.queuedKeys = make(map[renderer.Key]bool)
+	r.chars = r.queuedChars
+	r.queuedChars = nil
+	r.mouseWheel = r.queuedWheel
+	r.queuedWheel = 0
+}
+
+func (r *HeadlessRenderer) LoadTexture(fileType string, data []byte) (renderer.Texture, error) {
+	var img image.Image
+	var err error
+
+	switch strings.ToLower(fileType) {
+	case ".png":
+		img, err = png.Decode(bytes.NewReader(data))
+	default:
+		img, _, err = image.Decode(bytes.NewReader(data))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(rgba, rgba.Rect, img, img.Bounds().Min, draw.Src)
+
+	return r.newTexture(&texture{width: rgba.Rect.Dx(), height: rgba.Rect.Dy(), rgba: rgba}), nil
+}
+
+func (r *HeadlessRenderer) LoadIndexedTexture(pixels []byte, width, height int) renderer.Texture {
+	indexed := make([]byte, len(pixels))
+	copy(indexed, pixels)
+
+	return r.newTexture(&texture{width: width, height: height, indexed: indexed})
+}
+
+func (r *HeadlessRenderer) UpdateIndexedTexture(tex renderer.Texture, x, y, width, height int, pixels []byte) {
+	r.flush()
+
+	t := tex.(*texture)
+
+	if t.indexed == nil {
+		return
+	}
+
+	for row := 0; row < height; row++ {
+		copy(t.indexed[x+((y+row)*t.width):x+width+((y+row)*t.width)], pixels[row*width:(row+1)*width])
+	}
+}
+
+func (r *HeadlessRenderer) LoadPaletteTexture(colors []byte, transformCount int) renderer.Texture {
+	rgba := image.NewRGBA(image.Rect(0, 0, 256, transformCount))
+	copy(rgba.Pix, colors)
+
+	return r.newTexture(&texture{width: 256, height: transformCount, rgba: rgba})
+}
+
+// newTexture counts a loaded texture in the stats.
+func (r *HeadlessRenderer) newTexture(t *texture) *texture {
+	r.stats.Textures++
+	r.stats.TextureBytes += t.bytes()
+
+	return t
+}
+
+func (r *HeadlessRenderer) UnloadTexture(tex renderer.Texture) {
+	t, ok := tex.(*texture)
+
+	if !ok || (t.indexed == nil && t.rgba == nil) {
+		return
+	}
+
+	r.flush()
+
+	r.stats.Textures--
+	r.stats.TextureBytes -= t.bytes()
+
+	t.indexed = nil
+	t.rgba = nil
+}
+
+func (r *HeadlessRenderer) DrawTexture(tex renderer.Texture, x, y int) {
+	r.flush()
+	r.drawCalls++
+	r.quads++
+
+	t := tex.(*texture)
+
+	if t.rgba == nil {
+		return
+	}
+
+	draw.Draw(r.target, image.Rect(x, y, x+t.width, y+t.height), t.rgba, image.Point{}, draw.Over)
+}
+
+// DrawIndexedTexture mirrors the palette fragment shader: each index selects a column of the palette texture, and the
+// palette offset selects the row (transform) to sample from.
+func (r *HeadlessRenderer) DrawIndexedTexture(tex, palette renderer.Texture, paletteOffset float32, x, y int,
+	blendMode renderer.BlendMode) {
+	r.flush()
+	r.drawCalls++
+	r.quads++
+
+	t := tex.(*texture)
+	p := palette.(*texture)
+
+	if t.indexed == nil || p.rgba == nil {
+		return
+	}
+
+	row := int(paletteOffset * float32(p.height))
+
+	if row < 0 {
+		row = 0
+	} else if row >= p.height {
+		row = p.height - 1
+	}
+
+	bounds := r.target.Rect
+
+	for ty := 0; ty < t.height; ty++ {
+		dy := y + ty
+
+		if dy < bounds.Min.Y || dy >= bounds.Max.Y {
+			continue
+		}
+
+		for tx := 0; tx < t.width; tx++ {
+			dx := x + tx
+
+			if dx < bounds.Min.X || dx >= bounds.Max.X {
+				continue
+			}
+
+			src := p.rgba.RGBAAt(int(t.indexed[tx+(ty*t.width)]), row)
+			r.target.SetRGBA(dx, dy, blend(r.target.RGBAAt(dx, dy), src, blendMode))
+		}
+	}
+}
+
+// DrawIndexedTextureTransformed samples the texture (nearest neighbour) at the center of every surface pixel covered by
+// the transformed texture.
+func (r *HeadlessRenderer) DrawIndexedTextureTransformed(tex, palette renderer.Texture, paletteOffset float32,
+	transform renderer.Transform, tint color.Color, blendMode renderer.BlendMode) {
+	r.DrawIndexedTextureRegion(tex, palette, paletteOffset, image.Rect(0, 0, tex.Width(), tex.Height()), transform,
+		tint, blendMode)
+}
+
+// DrawIndexedTextureRegion queues the draw if batching is enabled, and draws it right away otherwise.
+func (r *HeadlessRenderer) DrawIndexedTextureRegion(tex, palette renderer.Texture, paletteOffset float32,
+	source image.Rectangle, transform renderer.Transform, tint color.Color, blendMode renderer.BlendMode) {
+	command := renderer.DrawCommand{
+		Texture:       tex,
+		Palette:       palette,
+		PaletteOffset: paletteOffset,
+		Source:        source,
+		Transform:     transform,
+		Tint:          tint,
+		BlendMode:     blendMode,
+	}
+
+	if r.batching {
+		r.queue.Add(command)
+		return
+	}
+
+	r.drawCalls++
+	r.drawCommand(&command)
+}
+
+func (r *HeadlessRenderer) SetBatching(enabled bool) {
+	r.flush()
+	r.batching = enabled
+}
+
+// flush draws the queued batches, each counted as a single draw call.
+func (r *HeadlessRenderer) flush() {
+	r.queue.Flush(func(batch *renderer.Batch) {
+		r.drawCalls++
+
+		for idx := range batch.Commands {
+			r.drawCommand(&batch.Commands[idx])
+		}
+	})
+}
+
+func (r *HeadlessRenderer) drawCommand(command *renderer.DrawCommand) {
+	r.quads++
+
+	t := command.Texture.(*texture)
+	p := command.Palette.(*texture)
+	transform := command.Transform
+
+	if t.indexed == nil || p.rgba == nil {
+		return
+	}
+
+	source := command.Source.Intersect(image.Rect(0, 0, t.width, t.height))
+	inverse, ok := transform.Invert()
+
+	if !ok || source.Empty() {
+		return
+	}
+
+	row := int(command.PaletteOffset * float32(p.height))
+
+	if row < 0 {
+		row = 0
+	} else if row >= p.height {
+		row = p.height - 1
+	}
+
+	c := color.NRGBAModel.Convert(command.Tint).(color.NRGBA)
+	width, height := source.Dx(), source.Dy()
+	bounds := transform.Bounds(width, height).Intersect(r.target.Rect)
+
+	for dy := bounds.Min.Y; dy < bounds.Max.Y; dy++ {
+		for dx := bounds.Min.X; dx < bounds.Max.X; dx++ {
+			fx, fy := inverse.Apply(float64(dx)+0.5, float64(dy)+0.5)
+			tx, ty := int(math.Floor(fx)), int(math.Floor(fy))
+
+			if tx < 0 || ty < 0 || tx >= width || ty >= height {
+				continue
+			}
+
+			src := p.rgba.RGBAAt(int(t.indexed[source.Min.X+tx+((source.Min.Y+ty)*t.width)]), row)
+			src = color.RGBA{R: mul(src.R, c.R), G: mul(src.G, c.G), B: mul(src.B, c.B), A: mul(src.A, c.A)}
+			r.target.SetRGBA(dx, dy, blend(r.target.RGBAAt(dx, dy), src, command.BlendMode))
+		}
+	}
+}
+
+// DrawText is a no-op, the headless renderer does not rasterize the system font.
+func (r *HeadlessRenderer) DrawText(_ string, _, _ int, _ color.Color) {
+}
+
+func (r *HeadlessRenderer) DrawRectangle(x, y, width, height int, tint color.Color) {
+	r.flush()
+	r.drawCalls++
+
+	draw.Draw(r.target, image.Rect(x, y, x+width, y+height), image.NewUniform(tint), image.Point{}, draw.Over)
+}
+
+func (r *HeadlessRenderer) DrawRectangleLines(x, y, width, height int, tint color.Color) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	r.DrawRectangle(x, y, width, 1, tint)
+	r.DrawRectangle(x, y+height-1, width, 1, tint)
+	r.DrawRectangle(x, y+1, 1, height-2, tint)
+	r.DrawRectangle(x+width-1, y+1, 1, height-2, tint)
+}
+
+func (r *HeadlessRenderer) Stats() renderer.Stats {
+	result := r.stats
+	result.Batching = r.batching
+
+	return result
+}
+
+func clearImage(img *image.RGBA) {
+	draw.Draw(img, img.Rect, image.NewUniform(color.RGBA{A: 255}), image.Point{}, draw.Src)
+}
@@ -0,0 +1,180 @@
+package headlessrenderer
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata")
+
+const (
+	surfaceSize    = 32
+	transformCount = 2
+)
+
+// testPalette returns a palette with two transforms. In the first, index 0 is transparent and the other indices are
+// shades of red, green and blue. The second transform swaps red and blue.
+func testPalette() []byte {
+	colors := make([]byte, 256*4*transformCount)
+
+	for idx := 1; idx < 256; idx++ {
+		shade := byte(64 + (idx/3)*3)
+		c := [4]byte{0, 0, 0, 255}
+		c[idx%3] = shade
+
+		copy(colors[idx*4:], c[:])
+		copy(colors[(256+idx)*4:], []byte{c[2], c[1], c[0], 255})
+	}
+
+	return colors
+}
+
+// testSprite returns a 6x4 indexed image with a transparent column on the right.
+func testSprite() (pixels []byte, width, height int) {
+	width, height = 6, 4
+	pixels = make([]byte, width*height)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width-1; x++ {
+			pixels[x+(y*width)] = byte(1 + x + (y * width))
+		}
+	}
+
+	return pixels, width, height
+}
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		draw func(r *HeadlessRenderer, sprite, palette renderer.Texture)
+	}{
+		{
+			name: "sprite",
+			draw: func(r *HeadlessRenderer, sprite, palette renderer.Texture) {
+				r.DrawIndexedTexture(sprite, palette, 0, 1, 1, renderer.BlendModeNone)
+				r.DrawIndexedTextureTransformed(sprite, palette, 0,
+					renderer.Translation(10, 8).Multiply(renderer.Scaling(2, 3)), color.White, renderer.BlendModeNone)
+				r.DrawIndexedTextureRegion(sprite, palette, 0, image.Rect(1, 1, 4, 3),
+					renderer.Translation(24, 24).Multiply(renderer.Rotation(90)), color.White, renderer.BlendModeNone)
+			},
+		},
+		{
+			name: "palette",
+			draw: func(r *HeadlessRenderer, sprite, palette renderer.Texture) {
+				r.DrawIndexedTexture(sprite, palette, 0, 1, 1, renderer.BlendModeNone)
+				r.DrawIndexedTexture(sprite, palette, 1, 1, 8, renderer.BlendModeNone)
+				r.DrawIndexedTextureTransformed(sprite, palette, 1, renderer.Translation(10, 16),
+					color.NRGBA{R: 255, G: 128, B: 255, A: 255}, renderer.BlendModeNone)
+			},
+		},
+		{
+			name: "blend",
+			draw: func(r *HeadlessRenderer, sprite, palette renderer.Texture) {
+				r.DrawRectangle(0, 0, surfaceSize, surfaceSize, color.RGBA{R: 96, G: 96, B: 96, A: 255})
+
+				modes := []renderer.BlendMode{
+					renderer.BlendModeNone,
+					renderer.BlendModeAlpha,
+					renderer.BlendModeAdditive,
+					renderer.BlendModeMultiplied,
+					renderer.BlendModeAddColors,
+					renderer.BlendModeSubtractColors,
+				}
+
+				for idx, mode := range modes {
+					r.DrawIndexedTextureTransformed(sprite, palette, 0,
+						renderer.Translation(float64(1+(idx%3)*10), float64(1+(idx/3)*10)).Multiply(renderer.Scaling(1, 2)),
+						color.NRGBA{R: 255, G: 255, B: 255, A: 160}, mode)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := New(surfaceSize, surfaceSize, 0)
+			pixels, width, height := testSprite()
+			sprite := r.LoadIndexedTexture(pixels, width, height)
+			palette := r.LoadPaletteTexture(testPalette(), transformCount)
+
+			r.BeginSurface()
+			test.draw(r, sprite, palette)
+			r.EndSurface()
+
+			checkGolden(t, filepath.Join("testdata", test.name+".png"), r.Surface())
+		})
+	}
+}
+
+// checkGolden compares an image with a golden image, or rewrites the golden image if the tests run with -update.
+func checkGolden(t *testing.T, path string, img *image.RGBA) {
+	t.Helper()
+
+	if *update {
+		var buf bytes.Buffer
+
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open the golden image (run with -update to create it): %v", err)
+	}
+
+	defer file.Close()
+
+	golden, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !golden.Bounds().Eq(img.Bounds()) {
+		t.Fatalf("image is %v, the golden image is %v", img.Bounds(), golden.Bounds())
+	}
+
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			want := color.RGBAModel.Convert(golden.At(x, y)).(color.RGBA)
+
+			if got := img.RGBAAt(x, y); got != want {
+				t.Fatalf("pixel (%d, %d) is %v, the golden image has %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestStatsCountTextures(t *testing.T) {
+	r := New(surfaceSize, surfaceSize, 0)
+	pixels, width, height := testSprite()
+
+	sprite := r.LoadIndexedTexture(pixels, width, height)
+	palette := r.LoadPaletteTexture(testPalette(), transformCount)
+
+	if stats := r.Stats(); stats.Textures != 2 || stats.TextureBytes != int64(len(pixels)+256*4*transformCount) {
+		t.Fatalf("stats after loading are %+v", stats)
+	}
+
+	r.UnloadTexture(sprite)
+	r.UnloadTexture(sprite)
+	r.UnloadTexture(palette)
+
+	if stats := r.Stats(); stats.Textures != 0 || stats.TextureBytes != 0 {
+		t.Fatalf("stats after unloading are %+v", stats)
+	}
+}
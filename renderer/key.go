@@ -0,0 +1,262 @@
+package renderer
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Key is a keyboard key. The values match the GLFW key codes, which raylib also uses.
+type Key int
+
+const (
+	KeyNone Key = 0
+
+	KeySpace        Key = 32
+	KeyApostrophe   Key = 39
+	KeyComma        Key = 44
+	KeyMinus        Key = 45
+	KeyPeriod       Key = 46
+	KeySlash        Key = 47
+	KeyZero         Key = 48
+	KeyOne          Key = 49
+	KeyTwo          Key = 50
+	KeyThree        Key = 51
+	KeyFour         Key = 52
+	KeyFive         Key = 53
+	KeySix          Key = 54
+	KeySeven        Key = 55
+	KeyEight        Key = 56
+	KeyNine         Key = 57
+	KeySemicolon    Key = 59
+	KeyEqual        Key = 61
+	KeyA            Key = 65
+	KeyB            Key = 66
+	KeyC            Key = 67
+	KeyD            Key = 68
+	KeyE            Key = 69
+	KeyF            Key = 70
+	KeyG            Key = 71
+	KeyH            Key = 72
+	KeyI            Key = 73
+	KeyJ            Key = 74
+	KeyK            Key = 75
+	KeyL            Key = 76
+	KeyM            Key = 77
+	KeyN            Key = 78
+	KeyO            Key = 79
+	KeyP            Key = 80
+	KeyQ            Key = 81
+	KeyR            Key = 82
+	KeyS            Key = 83
+	KeyT            Key = 84
+	KeyU            Key = 85
+	KeyV            Key = 86
+	KeyW            Key = 87
+	KeyX            Key = 88
+	KeyY            Key = 89
+	KeyZ            Key = 90
+	KeyLeftBracket  Key = 91
+	KeyBackslash    Key = 92
+	KeyRightBracket Key = 93
+	KeyGrave        Key = 96
+
+	KeyEscape       Key = 256
+	KeyEnter        Key = 257
+	KeyTab          Key = 258
+	KeyBackspace    Key = 259
+	KeyInsert       Key = 260
+	KeyDelete       Key = 261
+	KeyRight        Key = 262
+	KeyLeft         Key = 263
+	KeyDown         Key = 264
+	KeyUp           Key = 265
+	KeyPageUp       Key = 266
+	KeyPageDown     Key = 267
+	KeyHome         Key = 268
+	KeyEnd          Key = 269
+	KeyCapsLock     Key = 280
+	KeyScrollLock   Key = 281
+	KeyNumLock      Key = 282
+	KeyPrintScreen  Key = 283
+	KeyPause        Key = 284
+	KeyF1           Key = 290
+	KeyF2           Key = 291
+	KeyF3           Key = 292
+	KeyF4           Key = 293
+	KeyF5           Key = 294
+	KeyF6           Key = 295
+	KeyF7           Key = 296
+	KeyF8           Key = 297
+	KeyF9           Key = 298
+	KeyF10          Key = 299
+	KeyF11          Key = 300
+	KeyF12          Key = 301
+	KeyKp0          Key = 320
+	KeyKp1          Key = 321
+	KeyKp2          Key = 322
+	KeyKp3          Key = 323
+	KeyKp4          Key = 324
+	KeyKp5          Key = 325
+	KeyKp6          Key = 326
+	KeyKp7          Key = 327
+	KeyKp8          Key = 328
+	KeyKp9          Key = 329
+	KeyKpDecimal    Key = 330
+	KeyKpDivide     Key = 331
+	KeyKpMultiply   Key = 332
+	KeyKpSubtract   Key = 333
+	KeyKpAdd        Key = 334
+	KeyKpEnter      Key = 335
+	KeyKpEqual      Key = 336
+	KeyLeftShift    Key = 340
+	KeyLeftControl  Key = 341
+	KeyLeftAlt      Key = 342
+	KeyLeftSuper    Key = 343
+	KeyRightShift   Key = 344
+	KeyRightControl Key = 345
+	KeyRightAlt     Key = 346
+	KeyRightSuper   Key = 347
+	KeyMenu         Key = 348
+)
+
+var keyNames = map[Key]string{
+	KeySpace:        "space",
+	KeyApostrophe:   "apostrophe",
+	KeyComma:        "comma",
+	KeyMinus:        "minus",
+	KeyPeriod:       "period",
+	KeySlash:        "slash",
+	KeyZero:         "0",
+	KeyOne:          "1",
+	KeyTwo:          "2",
+	KeyThree:        "3",
+	KeyFour:         "4",
+	KeyFive:         "5",
+	KeySix:          "6",
+	KeySeven:        "7",
+	KeyEight:        "8",
+	KeyNine:         "9",
+	KeySemicolon:    "semicolon",
+	KeyEqual:        "equal",
+	KeyA:            "a",
+	KeyB:            "b",
+	KeyC:            "c",
+	KeyD:            "d",
+	KeyE:            "e",
+	KeyF:            "f",
+	KeyG:            "g",
+	KeyH:            "h",
+	KeyI:            "i",
+	KeyJ:            "j",
+	KeyK:            "k",
+	KeyL:            "l",
+	KeyM:            "m",
+	KeyN:            "n",
+	KeyO:            "o",
+	KeyP:            "p",
+	KeyQ:            "q",
+	KeyR:            "r",
+	KeyS:            "s",
+	KeyT:            "t",
+	KeyU:            "u",
+	KeyV:            "v",
+	KeyW:            "w",
+	KeyX:            "x",
+	KeyY:            "y",
+	KeyZ:            "z",
+	KeyLeftBracket:  "leftbracket",
+	KeyBackslash:    "backslash",
+	KeyRightBracket: "rightbracket",
+	KeyGrave:        "grave",
+	KeyEscape:       "escape",
+	KeyEnter:        "enter",
+	KeyTab:          "tab",
+	KeyBackspace:    "backspace",
+	KeyInsert:       "insert",
+	KeyDelete:       "delete",
+	KeyRight:        "right",
+	KeyLeft:         "left",
+	KeyDown:         "down",
+	KeyUp:           "up",
+	KeyPageUp:       "pageup",
+	KeyPageDown:     "pagedown",
+	KeyHome:         "home",
+	KeyEnd:          "end",
+	KeyCapsLock:     "capslock",
+	KeyScrollLock:   "scrolllock",
+	KeyNumLock:      "numlock",
+	KeyPrintScreen:  "printscreen",
+	KeyPause:        "pause",
+	KeyF1:           "f1",
+	KeyF2:           "f2",
+	KeyF3:           "f3",
+	KeyF4:           "f4",
+	KeyF5:           "f5",
+	KeyF6:           "f6",
+	KeyF7:           "f7",
+	KeyF8:           "f8",
+	KeyF9:           "f9",
+	KeyF10:          "f10",
+	KeyF11:          "f11",
+	KeyF12:          "f12",
+	KeyKp0:          "kp0",
+	KeyKp1:          "kp1",
+	KeyKp2:          "kp2",
+	KeyKp3:          "kp3",
+	KeyKp4:          "kp4",
+	KeyKp5:          "kp5",
+	KeyKp6:          "kp6",
+	KeyKp7:          "kp7",
+	KeyKp8:          "kp8",
+	KeyKp9:          "kp9",
+	KeyKpDecimal:    "kpdecimal",
+	KeyKpDivide:     "kpdivide",
+	KeyKpMultiply:   "kpmultiply",
+	KeyKpSubtract:   "kpsubtract",
+	KeyKpAdd:        "kpadd",
+	KeyKpEnter:      "kpenter",
+	KeyKpEqual:      "kpequal",
+	KeyLeftShift:    "leftshift",
+	KeyLeftControl:  "leftcontrol",
+	KeyLeftAlt:      "leftalt",
+	KeyLeftSuper:    "leftsuper",
+	KeyRightShift:   "rightshift",
+	KeyRightControl: "rightcontrol",
+	KeyRightAlt:     "rightalt",
+	KeyRightSuper:   "rightsuper",
+	KeyMenu:         "menu",
+}
+
+// Keys lists every key that has a name, in key code order.
+var Keys = make([]Key, 0, len(keyNames))
+
+func init() {
+	for key := range keyNames {
+		Keys = append(Keys, key)
+	}
+
+	sort.Slice(Keys, func(i, j int) bool { return Keys[i] < Keys[j] })
+}
+
+// ToString returns the name of the key, e.g. "a", "f1" or "escape".
+func (k Key) ToString() string {
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+
+	return "none"
+}
+
+// StringToKey returns the key with the given name, ignoring case.
+func StringToKey(s string) (Key, error) {
+	s = strings.ToLower(s)
+
+	for key, name := range keyNames {
+		if name == s {
+			return key, nil
+		}
+	}
+
+	return KeyNone, errors.New("unknown key name")
+}
@@ -0,0 +1,43 @@
+package renderer
+
+import (
+	"errors"
+	"strings"
+)
+
+type MouseButton int
+
+const (
+	MouseButtonLeft MouseButton = iota
+	MouseButtonRight
+	MouseButtonMiddle
+)
+
+// MouseButtons lists every mouse button.
+var MouseButtons = []MouseButton{MouseButtonLeft, MouseButtonRight, MouseButtonMiddle}
+
+func (b MouseButton) ToString() string {
+	switch b {
+	case MouseButtonLeft:
+		return "left"
+	case MouseButtonRight:
+		return "right"
+	case MouseButtonMiddle:
+		return "middle"
+	}
+
+	return "left"
+}
+
+func StringToMouseButton(s string) (MouseButton, error) {
+	switch strings.ToLower(s) {
+	case "left":
+		return MouseButtonLeft, nil
+	case "right":
+		return MouseButtonRight, nil
+	case "middle":
+		return MouseButtonMiddle, nil
+	}
+
+	return MouseButtonLeft, errors.New("unknown mouse button")
+}
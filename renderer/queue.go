@@ -0,0 +1,143 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+)
+
+const (
+	// maxBatchLookback is how many batches back a queued draw looks for one it can join.
+	maxBatchLookback = 32
+	// maxOverlapChecks is how many draws of a batch a queued draw is checked against when it is inside the bounds of
+	// the batch. Larger batches are taken to overlap it.
+	maxOverlapChecks = 256
+)
+
+// DrawCommand is a queued draw of the source rectangle of an indexed texture, see DrawIndexedTextureRegion.
+type DrawCommand struct {
+	Texture       Texture
+	Palette       Texture
+	PaletteOffset float32
+	Source        image.Rectangle
+	Transform     Transform
+	Tint          color.Color
+	BlendMode     BlendMode
+}
+
+// Batch is a run of queued draws that share their texture (atlas page), palette, palette offset and blend mode, so
+// that they are submitted without changing the shader state in between.
+type Batch struct {
+	Commands []DrawCommand
+	bounds   image.Rectangle
+	// commandBounds are the bounds of each of the commands, which are checked when a draw is inside the bounds of the
+	// whole batch.
+	commandBounds []image.Rectangle
+}
+
+func (b *Batch) Texture() Texture {
+	return b.Commands[0].Texture
+}
+
+func (b *Batch) Palette() Texture {
+	return b.Commands[0].Palette
+}
+
+func (b *Batch) PaletteOffset() float32 {
+	return b.Commands[0].PaletteOffset
+}
+
+func (b *Batch) BlendMode() BlendMode {
+	return b.Commands[0].BlendMode
+}
+
+// accepts reports whether a draw has the same state as the batch.
+func (b *Batch) accepts(command *DrawCommand) bool {
+	first := &b.Commands[0]
+
+	return first.Texture == command.Texture && first.Palette == command.Palette &&
+		first.PaletteOffset == command.PaletteOffset && first.BlendMode == command.BlendMode
+}
+
+// overlaps reports whether a draw in the given bounds would be drawn over any of the draws of the batch.
+func (b *Batch) overlaps(bounds image.Rectangle) bool {
+	if !b.bounds.Overlaps(bounds) {
+		return false
+	}
+
+	if len(b.commandBounds) > maxOverlapChecks {
+		return true
+	}
+
+	for _, commandBounds := range b.commandBounds {
+		if commandBounds.Overlaps(bounds) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Queue collects the draws of indexed textures of a frame, and groups them into batches by their state. A draw joins
+// the latest batch with the same state, unless it overlaps a draw of a batch queued after that one, as it must then be
+// drawn after it. Draws that do not overlap can therefore be drawn in a different order than they were queued in, which
+// does not change the result.
+type Queue struct {
+	batches []Batch
+	count   int
+}
+
+// Add queues a draw.
+func (q *Queue) Add(command DrawCommand) {
+	bounds := command.Transform.Bounds(command.Source.Dx(), command.Source.Dy())
+	target := -1
+
+	for idx := q.count - 1; idx >= 0 && idx >= q.count-maxBatchLookback; idx-- {
+		batch := &q.batches[idx]
+
+		if batch.accepts(&command) {
+			target = idx
+			break
+		}
+
+		if batch.overlaps(bounds) {
+			break
+		}
+	}
+
+	if target == -1 {
+		if q.count == len(q.batches) {
+			q.batches = append(q.batches, Batch{})
+		}
+
+		target = q.count
+		q.count++
+		q.batches[target].Commands = q.batches[target].Commands[:0]
+		q.batches[target].bounds = image.Rectangle{}
+		q.batches[target].commandBounds = q.batches[target].commandBounds[:0]
+	}
+
+	batch := &q.batches[target]
+	batch.Commands = append(batch.Commands, command)
+	batch.bounds = batch.bounds.Union(bounds)
+	batch.commandBounds = append(batch.commandBounds, bounds)
+}
+
+// Len returns the number of batches queued.
+func (q *Queue) Len() int {
+	return q.count
+}
+
+// Flush passes the queued batches to submit in the order they are drawn in, and empties the queue. The batches must
+// not be kept after submit returns.
+func (q *Queue) Flush(submit func(batch *Batch)) {
+	for idx := 0; idx < q.count; idx++ {
+		submit(&q.batches[idx])
+
+		// the commands are cleared so that the queue does not keep the textures
+		for c := range q.batches[idx].Commands {
+			q.batches[idx].Commands[c] = DrawCommand{}
+		}
+	}
+
+	q.count = 0
+}
@@ -0,0 +1,12 @@
+package raylibrenderer
+
+// GetCharPressed is part of raylib, but not of the Go bindings.
+
+/*
+int GetCharPressed(void);
+*/
+import "C"
+
+func (r *RaylibRenderer) CharPressed() rune {
+	return rune(C.GetCharPressed())
+}
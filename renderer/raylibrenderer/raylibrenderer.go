@@ -0,0 +1,437 @@
+package raylibrenderer
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"unsafe"
+
+	"github.com/OpenDiablo2/AbyssEngine/media"
+	"github.com/OpenDiablo2/AbyssEngine/renderer"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const systemFontSize = 18
+
+var blendModeLookup = []rl.BlendMode{
+	-1,
+	rl.BlendAlpha,
+	rl.BlendAdditive,
+	rl.BlendMultiplied,
+	rl.BlendAddColors,
+	rl.BlendSubtractColors,
+}
+
+var mouseButtonLookup = []int32{
+	rl.MouseLeftButton,
+	rl.MouseRightButton,
+	rl.MouseMiddleButton,
+}
+
+type texture struct {
+	rl.Texture2D
+	bytes int64
+}
+
+func (t *texture) Width() int {
+	return int(t.Texture2D.Width)
+}
+
+func (t *texture) Height() int {
+	return int(t.Texture2D.Height)
+}
+
+type RaylibRenderer struct {
+	renderSurface          rl.RenderTexture2D
+	systemFont             rl.Font
+	paletteShader          rl.Shader
+	paletteShaderLoc       int32
+	paletteShaderOffsetLoc int32
+	queue                  renderer.Queue
+	batching               bool
+	drawCalls              int
+	quads                  int
+	stats                  renderer.Stats
+}
+
+// New opens a window of the given size, with a virtual render surface of the given surface size.
+func New(title string, width, height, surfaceWidth, surfaceHeight int) *RaylibRenderer {
+	rl.SetTraceLogCallback(func(logLevel int, s string) {
+		[]func() *zerolog.Event{
+			log.Trace,
+			log.Debug,
+			log.Info,
+			log.Warn,
+			log.Error,
+			log.Fatal,
+		}[logLevel-1]().Msg(s)
+	})
+
+	rl.SetConfigFlags(rl.FlagWindowResizable | rl.FlagVsyncHint)
+	rl.InitWindow(int32(width), int32(height), title)
+	rl.SetTargetFPS(60)
+	rl.HideCursor()
+
+	result := &RaylibRenderer{
+		renderSurface: rl.LoadRenderTexture(int32(surfaceWidth), int32(surfaceHeight)),
+		systemFont: rl.LoadFontFromMemory(".ttf", media.FontDiabloHeavy, int32(len(media.FontDiabloHeavy)),
+			systemFontSize, nil, 0),
+		batching: true,
+	}
+
+	rl.GenTextureMipmaps(&result.systemFont.Texture)
+	rl.SetTextureFilter(result.systemFont.Texture, rl.FilterAnisotropic16x)
+
+	result.paletteShader = rl.LoadShaderFromMemory(media.StandardVertexShader, media.PaletteFragmentShader)
+	result.paletteShaderLoc = rl.GetShaderLocation(result.paletteShader, "palette")
+	result.paletteShaderOffsetLoc = rl.GetShaderLocation(result.paletteShader, "paletteOffset")
+
+	return result
+}
+
+func (r *RaylibRenderer) Name() string {
+	return "Raylib Renderer"
+}
+
+func (r *RaylibRenderer) Close() {
+	rl.UnloadShader(r.paletteShader)
+	rl.UnloadFont(r.systemFont)
+	rl.UnloadRenderTexture(r.renderSurface)
+	rl.CloseWindow()
+}
+
+func (r *RaylibRenderer) ShouldClose() bool {
+	return rl.WindowShouldClose()
+}
+
+func (r *RaylibRenderer) FrameTime() float64 {
+	return float64(rl.GetFrameTime())
+}
+
+func (r *RaylibRenderer) FPS() int {
+	return int(rl.GetFPS())
+}
+
+func (r *RaylibRenderer) ScreenSize() (width, height int) {
+	return rl.GetScreenWidth(), rl.GetScreenHeight()
+}
+
+func (r *RaylibRenderer) SetWindowSize(width, height int) {
+	rl.SetWindowSize(width, height)
+}
+
+func (r *RaylibRenderer) IsFullscreen() bool {
+	return rl.IsWindowFullscreen()
+}
+
+func (r *RaylibRenderer) SetFullscreen(fullscreen bool) {
+	if rl.IsWindowFullscreen() != fullscreen {
+		rl.ToggleFullscreen()
+	}
+}
+
+func (r *RaylibRenderer) SurfaceSize() (width, height int) {
+	return int(r.renderSurface.Texture.Width), int(r.renderSurface.Texture.Height)
+}
+
+func (r *RaylibRenderer) SetSurfaceSize(width, height int) {
+	if width == int(r.renderSurface.Texture.Width) && height == int(r.renderSurface.Texture.Height) {
+		return
+	}
+
+	rl.UnloadRenderTexture(r.renderSurface)
+	r.renderSurface = rl.LoadRenderTexture(int32(width), int32(height))
+}
+
+func (r *RaylibRenderer) MousePosition() (X, Y int) {
+	return int(rl.GetMouseX()), int(rl.GetMouseY())
+}
+
+func (r *RaylibRenderer) IsMouseButtonDown(button renderer.MouseButton) bool {
+	return rl.IsMouseButtonDown(mouseButtonLookup[button])
+}
+
+func (r *RaylibRenderer) MouseWheelMove() float32 {
+	return float32(rl.GetMouseWheelMove())
+}
+
+func (r *RaylibRenderer) IsKeyDown(key renderer.Key) bool {
+	return rl.IsKeyDown(int32(key))
+}
+
+func (r *RaylibRenderer) IsKeyPressed(key renderer.Key) bool {
+	return rl.IsKeyPressed(int32(key))
+}
+
+func (r *RaylibRenderer) SetClipboardText(text string) {
+	rl.SetClipboardText(text)
+}
+
+func (r *RaylibRenderer) BeginSurface() {
+	rl.BeginTextureMode(r.renderSurface)
+	rl.ClearBackground(rl.Black)
+}
+
+func (r *RaylibRenderer) EndSurface() {
+	r.flush()
+	rl.EndTextureMode()
+}
+
+func (r *RaylibRenderer) BeginScreen() {
+	rl.BeginDrawing()
+	rl.ClearBackground(rl.Black)
+}
+
+func (r *RaylibRenderer) DrawSurface(x, y, width, height float32) {
+	r.flush()
+	r.drawCalls++
+	r.quads++
+
+	rl.DrawTexturePro(r.renderSurface.Texture,
+		rl.Rectangle{Width: float32(r.renderSurface.Texture.Width), Height: float32(-r.renderSurface.Texture.Height)},
+		rl.Rectangle{X: x, Y: y, Width: width, Height: height},
+		rl.Vector2{}, 0.0, rl.White)
+}
+
+func (r *RaylibRenderer) EndScreen() {
+	r.flush()
+	rl.EndDrawing()
+
+	r.stats.DrawCalls = r.drawCalls
+	r.stats.Quads = r.quads
+	r.drawCalls = 0
+	r.quads = 0
+}
+
+func (r *RaylibRenderer) LoadTexture(fileType string, data []byte) (renderer.Texture, error) {
+	img := rl.LoadImageFromMemory(fileType, data, int32(len(data)))
+
+	if img == nil || img.Width == 0 {
+		return nil, errors.New("failed to decode image")
+	}
+
+	defer rl.UnloadImage(img)
+
+	return r.newTexture(rl.LoadTextureFromImage(img), 4), nil
+}
+
+func (r *RaylibRenderer) LoadIndexedTexture(pixels []byte, width, height int) renderer.Texture {
+	img := rl.NewImage(pixels, int32(width), int32(height), 1, rl.UncompressedGrayscale)
+
+	return r.newTexture(rl.LoadTextureFromImage(img), 1)
+}
+
+// UpdateIndexedTexture uploads the pixels with UpdateTextureRec, which takes them as colors but reads them in the
+// format of the texture, one byte per pixel.
+func (r *RaylibRenderer) UpdateIndexedTexture(tex renderer.Texture, x, y, width, height int, pixels []byte) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	r.flush()
+
+	// the colors are four bytes each, so the pixels are padded to whole colors
+	colors := make([]rl.Color, (width*height+3)/4)
+	data := unsafe.Slice((*byte)(unsafe.Pointer(&colors[0])), len(colors)*4)
+	copy(data, pixels[:width*height])
+
+	rl.UpdateTextureRec(tex.(*texture).Texture2D, rl.Rectangle{X: float32(x), Y: float32(y), Width: float32(width),
+		Height: float32(height)}, colors)
+}
+
+func (r *RaylibRenderer) LoadPaletteTexture(colors []byte, transformCount int) renderer.Texture {
+	img := rl.NewImage(colors, 256, int32(transformCount), 1, rl.UncompressedR8g8b8a8)
+
+	return r.newTexture(rl.LoadTextureFromImage(img), 4)
+}
+
+// newTexture wraps a loaded texture, counting it in the stats with the given number of bytes per pixel.
+func (r *RaylibRenderer) newTexture(tex rl.Texture2D, bytesPerPixel int64) *texture {
+	result := &texture{
+		Texture2D: tex,
+		bytes:     int64(tex.Width) * int64(tex.Height) * bytesPerPixel,
+	}
+
+	r.stats.Textures++
+	r.stats.TextureBytes += result.bytes
+
+	return result
+}
+
+func (r *RaylibRenderer) UnloadTexture(tex renderer.Texture) {
+	t, ok := tex.(*texture)
+
+	if !ok || t.ID == 0 {
+		return
+	}
+
+	r.flush()
+
+	rl.UnloadTexture(t.Texture2D)
+	t.ID = 0
+
+	r.stats.Textures--
+	r.stats.TextureBytes -= t.bytes
+}
+
+func (r *RaylibRenderer) DrawTexture(tex renderer.Texture, x, y int) {
+	r.flush()
+	r.drawCalls++
+	r.quads++
+
+	rl.DrawTexture(tex.(*texture).Texture2D, int32(x), int32(y), rl.White)
+}
+
+func (r *RaylibRenderer) DrawIndexedTexture(tex, palette renderer.Texture, paletteOffset float32, x, y int,
+	blendMode renderer.BlendMode) {
+	r.flush()
+	r.drawCalls++
+	r.quads++
+
+	r.beginPalette(palette, paletteOffset, blendMode)
+	rl.DrawTexture(tex.(*texture).Texture2D, int32(x), int32(y), rl.White)
+	r.endPalette(blendMode)
+}
+
+// DrawIndexedTextureTransformed draws the texture with DrawTexturePro, which can scale and rotate but not skew. A
+// negative vertical scale flips the texture, with the destination placed above the origin.
+func (r *RaylibRenderer) DrawIndexedTextureTransformed(tex, palette renderer.Texture, paletteOffset float32,
+	transform renderer.Transform, tint color.Color, blendMode renderer.BlendMode) {
+	r.DrawIndexedTextureRegion(tex, palette, paletteOffset, image.Rect(0, 0, tex.Width(), tex.Height()), transform,
+		tint, blendMode)
+}
+
+// DrawIndexedTextureRegion queues the draw if batching is enabled, and draws it right away otherwise.
+func (r *RaylibRenderer) DrawIndexedTextureRegion(tex, palette renderer.Texture, paletteOffset float32,
+	source image.Rectangle, transform renderer.Transform, tint color.Color, blendMode renderer.BlendMode) {
+	command := renderer.DrawCommand{
+		Texture:       tex,
+		Palette:       palette,
+		PaletteOffset: paletteOffset,
+		Source:        source,
+		Transform:     transform,
+		Tint:          tint,
+		BlendMode:     blendMode,
+	}
+
+	if r.batching {
+		r.queue.Add(command)
+		return
+	}
+
+	r.drawCalls++
+	r.beginPalette(palette, paletteOffset, blendMode)
+	r.drawCommand(&command)
+	r.endPalette(blendMode)
+}
+
+func (r *RaylibRenderer) SetBatching(enabled bool) {
+	r.flush()
+	r.batching = enabled
+}
+
+// flush draws the queued batches. Raylib collects the quads of a batch into a single draw call, as they use the same
+// texture and shader state.
+func (r *RaylibRenderer) flush() {
+	r.queue.Flush(func(batch *renderer.Batch) {
+		r.drawCalls++
+		r.beginPalette(batch.Palette(), batch.PaletteOffset(), batch.BlendMode())
+
+		for idx := range batch.Commands {
+			r.drawCommand(&batch.Commands[idx])
+		}
+
+		r.endPalette(batch.BlendMode())
+	})
+}
+
+// beginPalette starts drawing indexed textures with the palette shader.
+func (r *RaylibRenderer) beginPalette(palette renderer.Texture, paletteOffset float32, blendMode renderer.BlendMode) {
+	rl.BeginShaderMode(r.paletteShader)
+	rl.SetShaderValueTexture(r.paletteShader, r.paletteShaderLoc, palette.(*texture).Texture2D)
+	rl.SetShaderValue(r.paletteShader, r.paletteShaderOffsetLoc, []float32{paletteOffset}, rl.ShaderUniformFloat)
+
+	if blendModeLookup[blendMode] != -1 {
+		rl.BeginBlendMode(blendModeLookup[blendMode])
+	}
+}
+
+func (r *RaylibRenderer) endPalette(blendMode renderer.BlendMode) {
+	if blendModeLookup[blendMode] != -1 {
+		rl.EndBlendMode()
+	}
+
+	rl.EndShaderMode()
+}
+
+// drawCommand draws the source rectangle of a texture with DrawTexturePro, between beginPalette and endPalette.
+func (r *RaylibRenderer) drawCommand(command *renderer.DrawCommand) {
+	r.quads++
+
+	t := command.Texture.(*texture)
+	sourceRect := command.Source
+	scaleX, scaleY, rotation, x, y := command.Transform.Decompose()
+
+	source := rl.Rectangle{
+		X:      float32(sourceRect.Min.X),
+		Y:      float32(sourceRect.Min.Y),
+		Width:  float32(sourceRect.Dx()),
+		Height: float32(sourceRect.Dy()),
+	}
+	dest := rl.Rectangle{
+		X:      float32(x),
+		Y:      float32(y),
+		Width:  float32(sourceRect.Dx()) * float32(scaleX),
+		Height: float32(sourceRect.Dy()) * float32(math.Abs(scaleY)),
+	}
+	origin := rl.Vector2{}
+
+	if scaleY < 0 {
+		source.Height = -source.Height
+		origin.Y = dest.Height
+	}
+
+	c := color.NRGBAModel.Convert(command.Tint).(color.NRGBA)
+
+	rl.DrawTexturePro(t.Texture2D, source, dest, origin, float32(rotation), rl.NewColor(c.R, c.G, c.B, c.A))
+}
+
+func (r *RaylibRenderer) DrawText(text string, x, y int, tint color.Color) {
+	r.flush()
+	r.drawCalls++
+
+	cr, cg, cb, ca := tint.RGBA()
+
+	rl.DrawTextEx(r.systemFont, text, rl.Vector2{X: float32(x), Y: float32(y)}, systemFontSize, 0,
+		rl.NewColor(uint8(cr>>8), uint8(cg>>8), uint8(cb>>8), uint8(ca>>8)))
+}
+
+func (r *RaylibRenderer) DrawRectangle(x, y, width, height int, tint color.Color) {
+	r.flush()
+	r.drawCalls++
+
+	cr, cg, cb, ca := tint.RGBA()
+
+	rl.DrawRectangle(int32(x), int32(y), int32(width), int32(height),
+		rl.NewColor(uint8(cr>>8), uint8(cg>>8), uint8(cb>>8), uint8(ca>>8)))
+}
+
+func (r *RaylibRenderer) DrawRectangleLines(x, y, width, height int, tint color.Color) {
+	r.flush()
+	r.drawCalls++
+
+	cr, cg, cb, ca := tint.RGBA()
+
+	rl.DrawRectangleLines(int32(x), int32(y), int32(width), int32(height),
+		rl.NewColor(uint8(cr>>8), uint8(cg>>8), uint8(cb>>8), uint8(ca>>8)))
+}
+
+func (r *RaylibRenderer) Stats() renderer.Stats {
+	result := r.stats
+	result.Batching = r.batching
+
+	return result
+}
@@ -0,0 +1,78 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+)
+
+// Texture is a backend specific image that has been uploaded for drawing.
+type Texture interface {
+	Width() int
+	Height() int
+}
+
+// Renderer is the platform backend used by the engine. It owns the window (if there is one), input polling, and all
+// drawing operations performed by the engine and its nodes.
+type Renderer interface {
+	Name() string
+	Close()
+	ShouldClose() bool
+	FrameTime() float64
+	FPS() int
+	ScreenSize() (width, height int)
+	SetWindowSize(width, height int)
+	IsFullscreen() bool
+	SetFullscreen(fullscreen bool)
+	// SurfaceSize returns the size of the virtual render surface.
+	SurfaceSize() (width, height int)
+	// SetSurfaceSize recreates the virtual render surface with a new size. It must not be called while drawing.
+	SetSurfaceSize(width, height int)
+	MousePosition() (X, Y int)
+	IsMouseButtonDown(button MouseButton) bool
+	// MouseWheelMove returns how far the mouse wheel moved since the previous frame, positive is away from the user.
+	MouseWheelMove() float32
+	IsKeyDown(key Key) bool
+	// IsKeyPressed reports whether the key was pressed since the previous frame.
+	IsKeyPressed(key Key) bool
+	// CharPressed returns the next character typed since the previous frame, or 0 once every character was returned.
+	// Characters come from the keyboard layout and input method of the system, not from key codes.
+	CharPressed() rune
+	SetClipboardText(text string)
+
+	// BeginSurface starts drawing onto the virtual render surface, clearing it to black.
+	BeginSurface()
+	EndSurface()
+
+	// BeginScreen starts drawing onto the screen, clearing it to black.
+	BeginScreen()
+	// DrawSurface draws the virtual render surface scaled onto the destination rectangle of the screen.
+	DrawSurface(x, y, width, height float32)
+	EndScreen()
+
+	LoadTexture(fileType string, data []byte) (Texture, error)
+	LoadIndexedTexture(pixels []byte, width, height int) Texture
+	// UpdateIndexedTexture replaces the pixels of a rectangle of an indexed texture.
+	UpdateIndexedTexture(texture Texture, x, y, width, height int, pixels []byte)
+	LoadPaletteTexture(colors []byte, transformCount int) Texture
+	UnloadTexture(texture Texture)
+
+	DrawTexture(texture Texture, x, y int)
+	DrawIndexedTexture(texture, palette Texture, paletteOffset float32, x, y int, blendMode BlendMode)
+	// DrawIndexedTextureTransformed draws an indexed texture with the transform applied to its pixel coordinates, and
+	// its colors (including alpha) multiplied by the tint.
+	DrawIndexedTextureTransformed(texture, palette Texture, paletteOffset float32, transform Transform, tint color.Color,
+		blendMode BlendMode)
+	// DrawIndexedTextureRegion draws the source rectangle of an indexed texture, such as an atlas region, like
+	// DrawIndexedTextureTransformed. The transform applies to the pixel coordinates of the rectangle.
+	DrawIndexedTextureRegion(texture, palette Texture, paletteOffset float32, source image.Rectangle,
+		transform Transform, tint color.Color, blendMode BlendMode)
+	// SetBatching sets whether draws of indexed textures are queued and submitted in batches, which are drawn before
+	// any other draw, and at the end of the surface and screen. It is enabled by default.
+	SetBatching(enabled bool)
+	DrawText(text string, x, y int, tint color.Color)
+	DrawRectangle(x, y, width, height int, tint color.Color)
+	// DrawRectangleLines draws the outline of a rectangle, one pixel wide.
+	DrawRectangleLines(x, y, width, height int, tint color.Color)
+
+	Stats() Stats
+}
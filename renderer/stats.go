@@ -0,0 +1,14 @@
+package renderer
+
+// Stats counts the resources a renderer holds, and the work it did to draw the last frame.
+type Stats struct {
+	// Textures is the number of textures loaded and not yet unloaded, and TextureBytes the memory their pixels take.
+	Textures     int   `json:"textures"`
+	TextureBytes int64 `json:"textureBytes"`
+	// DrawCalls is the number of draw calls submitted in the last frame, and Quads the number of textured quads
+	// drawn. With batching, the draws in a batch are submitted as a single draw call.
+	DrawCalls int `json:"drawCalls"`
+	Quads     int `json:"quads"`
+	// Batching reports whether draws of indexed textures are batched.
+	Batching bool `json:"batching"`
+}
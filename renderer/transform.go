@@ -0,0 +1,98 @@
+package renderer
+
+import (
+	"image"
+	"math"
+)
+
+// Transform is a 2D affine transform. A point (x, y) is transformed to (A*x + C*y + Tx, B*x + D*y + Ty).
+type Transform struct {
+	A, B, C, D float64
+	Tx, Ty     float64
+}
+
+// IdentityTransform leaves points where they are.
+var IdentityTransform = Transform{A: 1, D: 1}
+
+func Translation(x, y float64) Transform {
+	return Transform{A: 1, D: 1, Tx: x, Ty: y}
+}
+
+func Scaling(x, y float64) Transform {
+	return Transform{A: x, D: y}
+}
+
+// Rotation returns a transform that rotates points clockwise (on a surface where y goes down) by the given degrees.
+func Rotation(degrees float64) Transform {
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+
+	return Transform{A: cos, B: sin, C: -sin, D: cos}
+}
+
+// Multiply returns the transform that applies other first, and then t.
+func (t Transform) Multiply(other Transform) Transform {
+	return Transform{
+		A:  t.A*other.A + t.C*other.B,
+		B:  t.B*other.A + t.D*other.B,
+		C:  t.A*other.C + t.C*other.D,
+		D:  t.B*other.C + t.D*other.D,
+		Tx: t.A*other.Tx + t.C*other.Ty + t.Tx,
+		Ty: t.B*other.Tx + t.D*other.Ty + t.Ty,
+	}
+}
+
+func (t Transform) Apply(x, y float64) (float64, float64) {
+	return t.A*x + t.C*y + t.Tx, t.B*x + t.D*y + t.Ty
+}
+
+// Invert returns the transform that undoes t. It fails if t collapses points onto a line (e.g. a scale of zero).
+func (t Transform) Invert() (Transform, bool) {
+	det := t.A*t.D - t.B*t.C
+
+	if det == 0 {
+		return Transform{}, false
+	}
+
+	result := Transform{
+		A: t.D / det,
+		B: -t.B / det,
+		C: -t.C / det,
+		D: t.A / det,
+	}
+
+	result.Tx = -(result.A*t.Tx + result.C*t.Ty)
+	result.Ty = -(result.B*t.Tx + result.D*t.Ty)
+
+	return result, true
+}
+
+// Decompose splits the transform into a scale, followed by a clockwise rotation in degrees, followed by a
+// translation. Skew, which only comes from rotating a node inside a parent with an uneven scale, is lost.
+func (t Transform) Decompose() (scaleX, scaleY, rotation, x, y float64) {
+	scaleX = math.Hypot(t.A, t.B)
+
+	if scaleX == 0 {
+		return 0, 0, 0, t.Tx, t.Ty
+	}
+
+	rotation = math.Atan2(t.B, t.A) * 180 / math.Pi
+	scaleY = (t.A*t.D - t.B*t.C) / scaleX
+
+	return scaleX, scaleY, rotation, t.Tx, t.Ty
+}
+
+// Bounds returns the pixels that may be covered by a rectangle of the given size at the origin, once it is transformed.
+func (t Transform) Bounds(width, height int) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	corners := [][2]float64{{0, 0}, {float64(width), 0}, {0, float64(height)}, {float64(width), float64(height)}}
+
+	for _, corner := range corners {
+		x, y := t.Apply(corner[0], corner[1])
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
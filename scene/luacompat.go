@@ -0,0 +1,97 @@
+package scene
+
+import (
+	"fmt"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	lua "github.com/yuin/gopher-lua"
+)
+
+var luaTypeExportName = "scene"
+var LuaTypeExport = common.LuaTypeExport{
+	Name: luaTypeExportName,
+	//ConstructorFunc: newLuaEntity,
+	Methods: map[string]lua.LGFunction{
+		"name":    luaGetName,
+		"node":    luaGetNode,
+		"overlay": luaGetOverlay,
+		"onStack": luaIsOnStack,
+	},
+}
+
+// ToLua returns the Lua value of the scene. It is created once, so that callbacks get the same value as the script
+// that created the scene.
+func (s *Scene) ToLua(l *lua.LState) *lua.LUserData {
+	if s.userData != nil {
+		return s.userData
+	}
+
+	s.userData = l.NewUserData()
+	s.userData.Value = s
+
+	l.SetMetatable(s.userData, l.GetTypeMetatable(luaTypeExportName))
+
+	return s.userData
+}
+
+func FromLua(ud *lua.LUserData) (*Scene, error) {
+	v, ok := ud.Value.(*Scene)
+
+	if !ok {
+		return nil, fmt.Errorf("failed to convert")
+	}
+
+	return v, nil
+}
+
+func luaGetName(l *lua.LState) int {
+	s, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(lua.LString(s.Name))
+
+	return 1
+}
+
+func luaGetNode(l *lua.LState) int {
+	s, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(s.Node.ToLua(l))
+
+	return 1
+}
+
+func luaGetOverlay(l *lua.LState) int {
+	s, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(lua.LBool(s.Overlay))
+
+	return 1
+}
+
+func luaIsOnStack(l *lua.LState) int {
+	s, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(lua.LBool(s.IsOnStack()))
+
+	return 1
+}
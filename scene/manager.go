@@ -0,0 +1,304 @@
+package scene
+
+import (
+	"errors"
+
+	"github.com/OpenDiablo2/AbyssEngine/node"
+	"github.com/OpenDiablo2/AbyssEngine/tween"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Manager keeps the stack of scenes. The scene on top is the one being played; the scenes below it are paused, and
+// are hidden unless every scene above them is an overlay. Scene nodes are added to the root node, so nodes added to the
+// root by scripts directly stay on screen whatever the scene.
+type Manager struct {
+	root          *node.Node
+	tweens        *tween.Manager
+	runLua        func(fn *lua.LFunction, args ...lua.LValue)
+	surfaceWidth  func() int
+	stack         []*Scene
+	transition    tween.Animation
+	endTransition func()
+}
+
+// NewManager creates a scene stack on the root node. Transitions are played by tweens, the Lua callbacks of scenes
+// are run with runLua, and slides move scenes by the surface width.
+func NewManager(root *node.Node, tweens *tween.Manager, runLua func(fn *lua.LFunction, args ...lua.LValue),
+	surfaceWidth func() int) *Manager {
+	result := &Manager{
+		root:         root,
+		tweens:       tweens,
+		runLua:       runLua,
+		surfaceWidth: surfaceWidth,
+		stack:        make([]*Scene, 0),
+	}
+
+	return result
+}
+
+// Reset empties the stack without running any callbacks, for when the root node is thrown away.
+func (m *Manager) Reset(root *node.Node) {
+	for _, s := range m.stack {
+		s.onStack = false
+	}
+
+	m.root = root
+	m.stack = make([]*Scene, 0)
+	m.transition = nil
+	m.endTransition = nil
+}
+
+// Top returns the scene on top of the stack, or nil if the stack is empty.
+func (m *Manager) Top() *Scene {
+	if len(m.stack) == 0 {
+		return nil
+	}
+
+	return m.stack[len(m.stack)-1]
+}
+
+// Stack returns the scenes from the bottom of the stack to the top.
+func (m *Manager) Stack() []*Scene {
+	return m.stack
+}
+
+// Push pauses the scene on top, and enters a new scene above it.
+func (m *Manager) Push(s *Scene, transition Transition, duration float64) error {
+	if s.onStack {
+		return errors.New("scene is already on the stack")
+	}
+
+	m.finishTransition()
+
+	if err := m.root.AddChild(s.Node); err != nil {
+		return err
+	}
+
+	previous := m.Top()
+
+	if previous != nil {
+		previous.Node.Interactive = false
+		m.call(previous, previous.OnPause)
+	}
+
+	m.stack = append(m.stack, s)
+	s.onStack = true
+	m.call(s, s.OnEnter)
+
+	m.play(transition, duration, s, previous, false, nil)
+
+	return nil
+}
+
+// Pop exits the scene on top, and resumes the one below it.
+func (m *Manager) Pop(transition Transition, duration float64) error {
+	if len(m.stack) == 0 {
+		return errors.New("the scene stack is empty")
+	}
+
+	m.finishTransition()
+
+	popped := m.Top()
+	m.stack = m.stack[:len(m.stack)-1]
+	popped.onStack = false
+	popped.Node.Interactive = false
+	m.call(popped, popped.OnExit)
+
+	next := m.Top()
+
+	if next != nil {
+		m.call(next, next.OnResume)
+	}
+
+	m.play(transition, duration, next, popped, true, func() {
+		m.unload(popped)
+	})
+
+	return nil
+}
+
+// Replace exits the scene on top, and enters a new scene in its place. With an empty stack, it pushes the scene.
+func (m *Manager) Replace(s *Scene, transition Transition, duration float64) error {
+	if len(m.stack) == 0 {
+		return m.Push(s, transition, duration)
+	}
+
+	if s.onStack {
+		return errors.New("scene is already on the stack")
+	}
+
+	m.finishTransition()
+
+	if err := m.root.AddChild(s.Node); err != nil {
+		return err
+	}
+
+	replaced := m.Top()
+	replaced.onStack = false
+	replaced.Node.Interactive = false
+	m.call(replaced, replaced.OnExit)
+
+	m.stack[len(m.stack)-1] = s
+	s.onStack = true
+	m.call(s, s.OnEnter)
+
+	m.play(transition, duration, s, replaced, false, func() {
+		m.unload(replaced)
+	})
+
+	return nil
+}
+
+// play animates the transition from the outgoing scene to the incoming one (either may be nil), and then runs done if
+// there is one. Backwards transitions are for popping, where the outgoing scene is the one on top.
+func (m *Manager) play(transition Transition, duration float64, incoming, outgoing *Scene, backwards bool,
+	done func()) {
+	if incoming != nil {
+		incoming.Node.Interactive = false
+	}
+
+	// the scenes that come into view are shown for the transition, those that go out of view are hidden after it
+	m.updateVisibility(false)
+
+	m.endTransition = func() {
+		for _, s := range []*Scene{incoming, outgoing} {
+			if s != nil {
+				s.Node.X, s.Node.Opacity = 0, 1
+			}
+		}
+
+		if done != nil {
+			done()
+		}
+
+		m.updateVisibility(true)
+
+		if top := m.Top(); top != nil {
+			top.Node.Interactive = true
+		}
+	}
+
+	animations := make([]tween.Animation, 0, 2)
+
+	switch transition {
+	case TransitionFade:
+		if backwards {
+			animations = append(animations, m.tween(outgoing, "opacity", 1, 0, duration))
+		} else {
+			animations = append(animations, m.tween(incoming, "opacity", 0, 1, duration))
+		}
+	case TransitionSlide:
+		width := float64(m.surfaceWidth())
+
+		// a scene under an overlay stays where it is, as it is in view either way
+		if backwards {
+			animations = append(animations, m.tween(outgoing, "x", 0, width, duration))
+
+			if !outgoing.Overlay {
+				animations = append(animations, m.tween(incoming, "x", -width, 0, duration))
+			}
+		} else {
+			animations = append(animations, m.tween(incoming, "x", width, 0, duration))
+
+			if !incoming.Overlay {
+				animations = append(animations, m.tween(outgoing, "x", 0, -width, duration))
+			}
+		}
+	}
+
+	m.playAnimations(animations)
+}
+
+// updateVisibility works out which scenes on the stack are in view: those where every scene above them is an overlay.
+// Scenes in view are shown, and the others are hidden if hide is set.
+func (m *Manager) updateVisibility(hide bool) {
+	visible := true
+
+	for idx := len(m.stack) - 1; idx >= 0; idx-- {
+		s := m.stack[idx]
+
+		if visible {
+			s.Node.Active = true
+		} else if hide {
+			s.Node.Active = false
+		}
+
+		visible = visible && s.Overlay
+	}
+}
+
+// tween returns an animation that moves a value of a scene's node, or nil if there is no scene.
+func (m *Manager) tween(s *Scene, name string, from, to, duration float64) tween.Animation {
+	if s == nil {
+		return nil
+	}
+
+	value, _ := tween.NodeValue(s.Node, name)
+	value.Set(from)
+
+	easing, _ := tween.StringToEasing("sineInOut")
+	result := tween.New(s.Node, duration, easing)
+	result.Add(value, to)
+
+	return result
+}
+
+func (m *Manager) playAnimations(animations []tween.Animation) {
+	parts := make([]tween.Animation, 0, len(animations))
+
+	for _, animation := range animations {
+		if animation != nil {
+			parts = append(parts, animation)
+		}
+	}
+
+	if len(parts) == 0 {
+		m.finishTransition()
+		return
+	}
+
+	transition, _ := tween.NewParallel(parts...)
+	transition.SetOnComplete(m.finishTransition)
+
+	m.transition = transition
+
+	if err := m.tweens.Play(transition); err != nil {
+		m.finishTransition()
+	}
+}
+
+// finishTransition ends the transition that is playing, if there is one, leaving the scenes where they end up.
+func (m *Manager) finishTransition() {
+	if m.transition != nil {
+		m.transition.Cancel()
+		m.transition = nil
+	}
+
+	if m.endTransition != nil {
+		endTransition := m.endTransition
+		m.endTransition = nil
+
+		endTransition()
+	}
+}
+
+// unload removes the scene's node from the tree, and destroys the nodes in it, so that it can be entered again.
+func (m *Manager) unload(s *Scene) {
+	m.root.RemoveChild(s.Node)
+
+	for _, child := range s.Node.Children {
+		child.DestroyTree()
+	}
+
+	s.Node.RemoveAllChildren()
+	s.Node.Active = true
+	s.Node.Interactive = true
+}
+
+func (m *Manager) call(s *Scene, fn *lua.LFunction) {
+	if fn == nil || s.userData == nil {
+		return
+	}
+
+	m.runLua(fn, s.userData)
+}
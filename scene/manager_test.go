@@ -0,0 +1,224 @@
+package scene
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/OpenDiablo2/AbyssEngine/node"
+	"github.com/OpenDiablo2/AbyssEngine/tween"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// testManager is a scene stack whose scenes record their callbacks, e.g. "a:enter".
+type testManager struct {
+	*Manager
+	state  *lua.LState
+	tweens *tween.Manager
+	root   *node.Node
+	calls  []string
+}
+
+func newTestManager(t *testing.T) *testManager {
+	t.Helper()
+
+	l := lua.NewState()
+	t.Cleanup(l.Close)
+
+	result := &testManager{state: l, root: node.New()}
+	runLua := func(fn *lua.LFunction, args ...lua.LValue) {
+		if err := l.CallByParam(lua.P{Fn: fn, Protect: true}, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result.tweens = tween.NewManager(runLua)
+	result.Manager = NewManager(result.root, result.tweens, runLua, func() int { return 800 })
+
+	return result
+}
+
+// newScene creates a scene whose callbacks record their name.
+func (m *testManager) newScene(name string, overlay bool) *Scene {
+	result := New(name)
+	result.Overlay = overlay
+	result.ToLua(m.state)
+
+	record := func(callback string) *lua.LFunction {
+		return m.state.NewFunction(func(l *lua.LState) int {
+			m.calls = append(m.calls, fmt.Sprintf("%s:%s", l.CheckUserData(1).Value.(*Scene).Name, callback))
+			return 0
+		})
+	}
+
+	result.OnEnter, result.OnExit = record("enter"), record("exit")
+	result.OnPause, result.OnResume = record("pause"), record("resume")
+
+	return result
+}
+
+// visible returns the names of the scenes on the stack that are active, from the bottom up.
+func (m *testManager) visible() string {
+	names := make([]string, 0)
+
+	for _, s := range m.Stack() {
+		if s.Node.Active {
+			names = append(names, s.Name)
+		}
+	}
+
+	return strings.Join(names, ",")
+}
+
+func TestSceneStack(t *testing.T) {
+	type step struct {
+		op      string
+		scene   string
+		overlay bool
+		visible string
+		calls   []string
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "push hides every scene under one that is not an overlay",
+			steps: []step{
+				{op: "push", scene: "a", visible: "a", calls: []string{"a:enter"}},
+				{op: "push", scene: "b", overlay: true, visible: "a,b", calls: []string{"a:pause", "b:enter"}},
+				{op: "push", scene: "c", visible: "c", calls: []string{"b:pause", "c:enter"}},
+			},
+		},
+		{
+			name: "pop shows the scenes under overlays again",
+			steps: []step{
+				{op: "push", scene: "a", visible: "a"},
+				{op: "push", scene: "b", overlay: true, visible: "a,b"},
+				{op: "push", scene: "c", visible: "c"},
+				{op: "pop", visible: "a,b", calls: []string{"c:exit", "b:resume"}},
+				{op: "pop", visible: "a", calls: []string{"b:exit", "a:resume"}},
+				{op: "pop", visible: "", calls: []string{"a:exit"}},
+			},
+		},
+		{
+			name: "replacing the top with an overlay shows the scene below",
+			steps: []step{
+				{op: "push", scene: "a", visible: "a"},
+				{op: "push", scene: "b", visible: "b"},
+				{op: "replace", scene: "c", overlay: true, visible: "a,c", calls: []string{"b:exit", "c:enter"}},
+			},
+		},
+		{
+			name: "replacing an overlay on top hides the scene below",
+			steps: []step{
+				{op: "push", scene: "a", visible: "a"},
+				{op: "push", scene: "b", overlay: true, visible: "a,b"},
+				{op: "replace", scene: "c", visible: "c", calls: []string{"b:exit", "c:enter"}},
+			},
+		},
+		{
+			name: "replacing with an empty stack pushes",
+			steps: []step{
+				{op: "replace", scene: "a", visible: "a", calls: []string{"a:enter"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := newTestManager(t)
+
+			for idx, s := range test.steps {
+				m.calls = nil
+
+				var err error
+
+				switch s.op {
+				case "push":
+					err = m.Push(m.newScene(s.scene, s.overlay), TransitionNone, 0)
+				case "pop":
+					err = m.Pop(TransitionNone, 0)
+				case "replace":
+					err = m.Replace(m.newScene(s.scene, s.overlay), TransitionNone, 0)
+				}
+
+				if err != nil {
+					t.Fatalf("step %d: %v", idx, err)
+				}
+
+				if got := m.visible(); got != s.visible {
+					t.Errorf("step %d: visible scenes are %q, want %q", idx, got, s.visible)
+				}
+
+				if s.calls != nil && !reflect.DeepEqual(m.calls, s.calls) {
+					t.Errorf("step %d: callbacks are %q, want %q", idx, m.calls, s.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestTransitionHidesSceneWhenItEnds(t *testing.T) {
+	m := newTestManager(t)
+	a, b := m.newScene("a", false), m.newScene("b", false)
+
+	if err := m.Push(a, TransitionNone, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Push(b, TransitionSlide, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// the scene below slides out of view, so it is drawn until the transition ends
+	m.tweens.Update(0.5, m.root)
+
+	if got := m.visible(); got != "a,b" || b.Node.Interactive {
+		t.Errorf("during the transition, visible scenes are %q", got)
+	}
+
+	m.tweens.Update(1, m.root)
+
+	if got := m.visible(); got != "b" || !b.Node.Interactive || b.Node.X != 0 || a.Node.X != 0 {
+		t.Errorf("after the transition, visible scenes are %q", got)
+	}
+
+	// popping shows the scene below for the whole transition
+	if err := m.Pop(TransitionSlide, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m.visible(); got != "a" || a.Node.Interactive {
+		t.Errorf("when popping, visible scenes are %q", got)
+	}
+
+	m.tweens.Update(1, m.root)
+
+	if !a.Node.Interactive || m.root.FindChild(b.Node.Id) != nil {
+		t.Error("the popped scene was not unloaded")
+	}
+}
+
+func TestPushErrors(t *testing.T) {
+	m := newTestManager(t)
+	a := m.newScene("a", false)
+
+	if err := m.Push(a, TransitionNone, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Push(a, TransitionNone, 0); err == nil {
+		t.Error("a scene was pushed twice")
+	}
+
+	if err := m.Pop(TransitionNone, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Pop(TransitionNone, 0); err == nil {
+		t.Error("an empty stack was popped")
+	}
+}
@@ -0,0 +1,80 @@
+package scene
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/OpenDiablo2/AbyssEngine/node"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Transition is how the scene stack animates from one scene to the next.
+type Transition int
+
+const (
+	TransitionNone Transition = iota
+	// TransitionFade fades the scene on top in or out.
+	TransitionFade
+	// TransitionSlide slides the new scene in from the right, pushing the old one out to the left. Popping a scene
+	// slides the other way.
+	TransitionSlide
+)
+
+func (t Transition) ToString() string {
+	switch t {
+	case TransitionNone:
+		return "none"
+	case TransitionFade:
+		return "fade"
+	case TransitionSlide:
+		return "slide"
+	}
+
+	return "none"
+}
+
+func StringToTransition(s string) (Transition, error) {
+	switch strings.ToLower(s) {
+	case "none", "":
+		return TransitionNone, nil
+	case "fade":
+		return TransitionFade, nil
+	case "slide":
+		return TransitionSlide, nil
+	}
+
+	return TransitionNone, errors.New("unknown transition, expected none, fade or slide")
+}
+
+// Scene is a screen of the game, such as the main menu, with the subtree of nodes that make it up. Scenes usually
+// build their nodes when they enter, and their nodes are destroyed when they exit.
+type Scene struct {
+	Name string
+	Node *node.Node
+	// Overlay scenes, such as in-game panels, leave the scene below them visible while they are on top.
+	Overlay  bool
+	OnEnter  *lua.LFunction
+	OnExit   *lua.LFunction
+	OnPause  *lua.LFunction
+	OnResume *lua.LFunction
+
+	onStack  bool
+	userData *lua.LUserData
+}
+
+func New(name string) *Scene {
+	result := &Scene{
+		Name: name,
+		Node: node.New(),
+	}
+
+	// so that scripts can find the nodes of the scene from the root, e.g. root:find("mainMenu/buttons/ok")
+	result.Node.Name = name
+
+	return result
+}
+
+// IsOnStack reports whether the scene has been pushed, and not yet popped or replaced.
+func (s *Scene) IsOnStack() bool {
+	return s.onStack
+}
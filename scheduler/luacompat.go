@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	lua "github.com/yuin/gopher-lua"
+)
+
+var luaTypeExportName = "task"
+var LuaTypeExport = common.LuaTypeExport{
+	Name: luaTypeExportName,
+	//ConstructorFunc: newLuaEntity,
+	Methods: map[string]lua.LGFunction{
+		"id":        luaGetId,
+		"cancel":    luaCancel,
+		"done":      luaIsDone,
+		"cancelled": luaIsCancelled,
+	},
+}
+
+func (t *Thread) ToLua(l *lua.LState) *lua.LUserData {
+	result := l.NewUserData()
+	result.Value = t
+
+	l.SetMetatable(result, l.GetTypeMetatable(luaTypeExportName))
+
+	return result
+}
+
+func FromLua(ud *lua.LUserData) (*Thread, error) {
+	v, ok := ud.Value.(*Thread)
+
+	if !ok {
+		return nil, fmt.Errorf("failed to convert")
+	}
+
+	return v, nil
+}
+
+func luaGetId(l *lua.LState) int {
+	thread, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(lua.LNumber(thread.Id))
+
+	return 1
+}
+
+func luaCancel(l *lua.LState) int {
+	thread, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	thread.Cancel()
+
+	return 0
+}
+
+func luaIsDone(l *lua.LState) int {
+	thread, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(lua.LBool(thread.IsDone()))
+
+	return 1
+}
+
+func luaIsCancelled(l *lua.LState) int {
+	thread, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(lua.LBool(thread.IsCancelled()))
+
+	return 1
+}
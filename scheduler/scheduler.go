@@ -0,0 +1,190 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	yieldWait       = "wait"
+	yieldWaitFrames = "waitFrames"
+)
+
+// Scheduler runs Lua functions as cooperative threads (coroutines). Every call to Update advances the scheduler clock
+// and resumes, in spawn order, each thread whose wait has elapsed.
+type Scheduler struct {
+	state      *lua.LState
+	trampoline *lua.LFunction
+	threads    []*Thread
+	nextId     int
+	time       float64
+	frame      int
+}
+
+func New(state *lua.LState) *Scheduler {
+	result := &Scheduler{
+		state:   state,
+		threads: make([]*Thread, 0),
+	}
+
+	if chunk, err := state.Load(strings.NewReader(trampolineScript), trampolineName); err == nil {
+		state.Push(chunk)
+		state.Call(0, 1)
+		result.trampoline, _ = state.Get(-1).(*lua.LFunction)
+		state.Pop(1)
+	}
+
+	return result
+}
+
+// Spawn creates a new thread that runs fn with the given arguments. The thread first runs on the next Update (or
+// later in the current one, if Update is running).
+func (s *Scheduler) Spawn(fn *lua.LFunction, args ...lua.LValue) *Thread {
+	state, _ := s.state.NewThread()
+
+	s.nextId++
+
+	result := &Thread{
+		Id:        s.nextId,
+		state:     state,
+		fn:        fn,
+		args:      args,
+		wakeTime:  s.time,
+		wakeFrame: s.frame,
+	}
+
+	if s.trampoline != nil {
+		result.fn = s.trampoline
+		result.args = append([]lua.LValue{s.state.NewFunction(result.captureErrors), fn}, args...)
+	}
+
+	s.threads = append(s.threads, result)
+
+	return result
+}
+
+// Time returns the number of seconds the scheduler has advanced.
+func (s *Scheduler) Time() float64 {
+	return s.time
+}
+
+// Frame returns the number of times the scheduler has been updated.
+func (s *Scheduler) Frame() int {
+	return s.frame
+}
+
+// ThreadCount returns the number of threads that have not yet finished.
+func (s *Scheduler) ThreadCount() int {
+	return len(s.threads)
+}
+
+// CancelAll cancels every thread.
+func (s *Scheduler) CancelAll() {
+	for idx := range s.threads {
+		s.threads[idx].Cancel()
+	}
+}
+
+// Update advances the clock by elapsed seconds and resumes all threads that are due. If a thread raises an error it is
+// removed, and the first error encountered is returned after all other threads have been resumed. Errors raised by
+// scripts are returned as a *ScriptError.
+func (s *Scheduler) Update(elapsed float64) error {
+	var firstErr error
+
+	s.time += elapsed
+	s.frame++
+
+	// Threads spawned while iterating are appended, and run in this same update.
+	for idx := 0; idx < len(s.threads); idx++ {
+		thread := s.threads[idx]
+
+		if thread.done || thread.cancelled || !thread.isDue(s.time, s.frame) {
+			continue
+		}
+
+		if err := s.resume(thread); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	remaining := s.threads[:0]
+
+	for idx := range s.threads {
+		if s.threads[idx].done || s.threads[idx].cancelled {
+			continue
+		}
+
+		remaining = append(remaining, s.threads[idx])
+	}
+
+	for idx := len(remaining); idx < len(s.threads); idx++ {
+		s.threads[idx] = nil
+	}
+
+	s.threads = remaining
+
+	return firstErr
+}
+
+func (s *Scheduler) resume(thread *Thread) error {
+	var args []lua.LValue
+
+	if !thread.started {
+		thread.started = true
+		args = thread.args
+		thread.args = nil
+	}
+
+	resumeState, err, values := s.state.Resume(thread.state, thread.fn, args...)
+
+	switch resumeState {
+	case lua.ResumeError:
+		thread.done = true
+
+		if thread.err != nil {
+			return thread.err
+		}
+
+		return fmt.Errorf("script thread %d: %w", thread.Id, err)
+	case lua.ResumeOK:
+		thread.done = true
+	case lua.ResumeYield:
+		thread.wakeTime = s.time
+		thread.wakeFrame = s.frame + 1
+
+		if len(values) < 2 {
+			break
+		}
+
+		amount, ok := values[1].(lua.LNumber)
+
+		if !ok {
+			break
+		}
+
+		switch values[0] {
+		case lua.LString(yieldWait):
+			thread.wakeTime = s.time + (float64(amount) / 1000.0)
+		case lua.LString(yieldWaitFrames):
+			if int(amount) > 1 {
+				thread.wakeFrame = s.frame + int(amount)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Wait yields the calling thread for the specified number of milliseconds. It must be returned from the Go function
+// that implements it, e.g. `return scheduler.Wait(l, msec)`.
+func Wait(l *lua.LState, msec int) int {
+	return l.Yield(lua.LString(yieldWait), lua.LNumber(msec))
+}
+
+// WaitFrames yields the calling thread for the specified number of updates. It must be returned from the Go function
+// that implements it, e.g. `return scheduler.WaitFrames(l, frames)`.
+func WaitFrames(l *lua.LState, frames int) int {
+	return l.Yield(lua.LString(yieldWaitFrames), lua.LNumber(frames))
+}
@@ -0,0 +1,259 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// step is the time each update advances the clock by. It is a power of two, so that the clock adds up exactly.
+const step = 0.125
+
+// testScheduler runs scripts with wait, waitFrames, spawn and record functions. Records are logged with the frame they
+// were made in, e.g. "3:done".
+type testScheduler struct {
+	*Scheduler
+	records []string
+}
+
+func newTestScheduler(t *testing.T) *testScheduler {
+	t.Helper()
+
+	l := lua.NewState()
+	t.Cleanup(l.Close)
+
+	result := &testScheduler{Scheduler: New(l)}
+
+	mt := l.NewTypeMetatable(luaTypeExportName)
+	l.SetField(mt, "__index", l.SetFuncs(l.NewTable(), LuaTypeExport.Methods))
+
+	l.SetGlobal("wait", l.NewFunction(func(l *lua.LState) int { return Wait(l, l.CheckInt(1)) }))
+	l.SetGlobal("waitFrames", l.NewFunction(func(l *lua.LState) int { return WaitFrames(l, l.CheckInt(1)) }))
+	l.SetGlobal("spawn", l.NewFunction(func(l *lua.LState) int {
+		args := make([]lua.LValue, 0, l.GetTop()-1)
+
+		for i := 2; i <= l.GetTop(); i++ {
+			args = append(args, l.Get(i))
+		}
+
+		l.Push(result.Spawn(l.CheckFunction(1), args...).ToLua(l))
+
+		return 1
+	}))
+	l.SetGlobal("record", l.NewFunction(func(l *lua.LState) int {
+		result.records = append(result.records, fmt.Sprintf("%d:%s", result.Frame(), l.CheckString(1)))
+		return 0
+	}))
+
+	return result
+}
+
+// spawnScript spawns a thread that runs a script, loaded as /test.lua.
+func (s *testScheduler) spawnScript(t *testing.T, script string) *Thread {
+	t.Helper()
+
+	fn, err := s.state.Load(strings.NewReader(script), "/test.lua")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s.Spawn(fn)
+}
+
+// run updates the scheduler a number of times, and returns the first error.
+func (s *testScheduler) run(updates int) error {
+	var firstErr error
+
+	for idx := 0; idx < updates; idx++ {
+		if err := s.Update(step); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func TestScheduler(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		updates int
+		records []string
+		threads int
+	}{
+		{
+			name:    "wait resumes once the time has passed",
+			script:  `record("a") wait(250) record("b") wait(500) record("c") wait(1) record("d")`,
+			updates: 10,
+			records: []string{"1:a", "3:b", "7:c", "8:d"},
+		},
+		{
+			name:    "wait of zero resumes on the next update",
+			script:  `record("a") wait(0) record("b")`,
+			updates: 3,
+			records: []string{"1:a", "2:b"},
+		},
+		{
+			name:    "waitFrames resumes after that many updates",
+			script:  `record("a") waitFrames(1) record("b") waitFrames(3) record("c") waitFrames(0) record("d")`,
+			updates: 10,
+			records: []string{"1:a", "2:b", "5:c", "6:d"},
+		},
+		{
+			name:    "a thread that is still waiting is kept",
+			script:  `record("a") wait(10000) record("b")`,
+			updates: 5,
+			records: []string{"1:a"},
+			threads: 1,
+		},
+		{
+			name: "spawned threads run in the same update, after the thread that spawned them",
+			script: `
+				record("a")
+				spawn(function(name) record(name) waitFrames(1) record(name) end, "child")
+				record("b")
+				waitFrames(1)
+				record("c")`,
+			updates: 3,
+			records: []string{"1:a", "1:b", "1:child", "2:c", "2:child"},
+		},
+		{
+			name: "a cancelled thread is not resumed again",
+			script: `
+				local task = spawn(function() while true do record("tick") waitFrames(1) end end)
+				waitFrames(2)
+				task:cancel()
+				record(tostring(task:cancelled()))
+				record(tostring(task:done()))`,
+			updates: 5,
+			records: []string{"1:tick", "2:tick", "3:true", "3:false"},
+		},
+		{
+			name: "a thread that cancels itself stops at its next wait",
+			script: `
+				local task
+				task = spawn(function() record("a") waitFrames(1) task:cancel() record("b") waitFrames(1) record("c") end)`,
+			updates: 5,
+			records: []string{"1:a", "2:b"},
+		},
+		{
+			name:    "threads get increasing ids",
+			script:  `record(tostring(spawn(function() end):id())) record(tostring(spawn(function() end):id()))`,
+			updates: 1,
+			records: []string{"1:2", "1:3"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestScheduler(t)
+			s.spawnScript(t, test.script)
+
+			if err := s.run(test.updates); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(s.records, test.records) {
+				t.Errorf("records are %q, want %q", s.records, test.records)
+			}
+
+			if s.ThreadCount() != test.threads {
+				t.Errorf("%d threads left, want %d", s.ThreadCount(), test.threads)
+			}
+		})
+	}
+}
+
+func TestDelay(t *testing.T) {
+	s := newTestScheduler(t)
+	s.spawnScript(t, `record("a")`).Delay(3 * step)
+
+	if err := s.run(5); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"3:a"}; !reflect.DeepEqual(s.records, want) {
+		t.Errorf("records are %q, want %q", s.records, want)
+	}
+}
+
+func TestCancelAll(t *testing.T) {
+	s := newTestScheduler(t)
+	first := s.spawnScript(t, `while true do record("first") waitFrames(1) end`)
+	second := s.spawnScript(t, `while true do record("second") waitFrames(1) end`)
+
+	if err := s.run(1); err != nil {
+		t.Fatal(err)
+	}
+
+	s.CancelAll()
+
+	if err := s.run(2); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"1:first", "1:second"}; !reflect.DeepEqual(s.records, want) {
+		t.Errorf("records are %q, want %q", s.records, want)
+	}
+
+	if !first.IsCancelled() || !second.IsCancelled() || s.ThreadCount() != 0 {
+		t.Errorf("threads were not cancelled")
+	}
+}
+
+func TestScriptError(t *testing.T) {
+	s := newTestScheduler(t)
+	failing := s.spawnScript(t, `local function inner()
+	error("boom")
+end
+
+local function outer()
+	inner()
+end
+
+record("start")
+waitFrames(1)
+outer()
+record("unreachable")`)
+	s.spawnScript(t, `waitFrames(1) record("other") waitFrames(1) record("other")`)
+
+	err := s.run(3)
+
+	var scriptErr *ScriptError
+
+	if !errors.As(err, &scriptErr) {
+		t.Fatalf("error is %v, want a *ScriptError", err)
+	}
+
+	if scriptErr.ThreadId != failing.Id || !strings.Contains(scriptErr.Message, "boom") {
+		t.Errorf("error is %q in thread %d", scriptErr.Message, scriptErr.ThreadId)
+	}
+
+	if scriptErr.Location != "/test.lua:2" {
+		t.Errorf("location is %q, want /test.lua:2", scriptErr.Location)
+	}
+
+	traceback := strings.Join(scriptErr.Traceback, "\n")
+
+	for _, want := range []string{"/test.lua:2: in ", "/test.lua:6: in ", "/test.lua:11: in main chunk"} {
+		if !strings.Contains(traceback, want) {
+			t.Errorf("traceback does not contain %q:\n%s", want, traceback)
+		}
+	}
+
+	if strings.Contains(traceback, trampolineName) {
+		t.Errorf("traceback contains the trampoline:\n%s", traceback)
+	}
+
+	if want := []string{"1:start", "2:other", "3:other"}; !reflect.DeepEqual(s.records, want) {
+		t.Errorf("records are %q, want %q", s.records, want)
+	}
+
+	if !failing.IsDone() || s.ThreadCount() != 0 {
+		t.Errorf("the failing thread was not removed")
+	}
+}
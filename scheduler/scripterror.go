@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"fmt"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// maxTracebackLines is the number of stack frames kept in a script error traceback
+const maxTracebackLines = 20
+
+// ScriptError is an error raised by a script thread, along with where it was raised.
+type ScriptError struct {
+	ThreadId int
+	Message  string
+	// Location is the source file and line of the innermost script function, e.g. "/bootstrap.lua:12"
+	Location  string
+	Traceback []string
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("script thread %d: %s", e.ThreadId, e.Message)
+}
+
+// trampolineScript wraps each thread's function so that the Go setup function runs inside the thread before it does.
+// It is loaded under trampolineName, which keeps it out of tracebacks.
+const (
+	trampolineScript = `return function(setup, fn, ...) setup() return fn(...) end`
+	trampolineName   = "scheduler"
+)
+
+// captureErrors is the setup function run at the start of each thread. Resume discards the stack of a thread that
+// raises an error, so the thread's panic handler is replaced with one that records the traceback first.
+func (t *Thread) captureErrors(l *lua.LState) int {
+	l.Panic = func(l *lua.LState) {
+		t.err = newScriptError(l, t.Id)
+
+		panic(&lua.ApiError{Type: lua.ApiErrorRun, Object: l.Get(-1)})
+	}
+
+	return 0
+}
+
+func newScriptError(l *lua.LState, threadId int) *ScriptError {
+	result := &ScriptError{
+		ThreadId:  threadId,
+		Message:   l.Get(-1).String(),
+		Traceback: make([]string, 0),
+	}
+
+	var previous lua.Debug
+
+	for level := 0; ; level++ {
+		dbg, ok := l.GetStack(level)
+
+		if !ok {
+			break
+		}
+
+		if _, err := l.GetInfo("Sl", dbg, lua.LNil); err != nil || dbg.Source == trampolineName {
+			continue
+		}
+
+		// a frame that made a tail call is reported once for each call it replaced
+		if *dbg == previous {
+			continue
+		}
+
+		previous = *dbg
+
+		if dbg.CurrentLine < 0 {
+			result.Traceback = append(result.Traceback, fmt.Sprintf("[G]: in %s", describeFunction(dbg)))
+			continue
+		}
+
+		location := fmt.Sprintf("%s:%d", dbg.Source, dbg.CurrentLine)
+
+		if len(result.Location) == 0 {
+			result.Location = location
+		}
+
+		result.Traceback = append(result.Traceback, fmt.Sprintf("%s: in %s", location, describeFunction(dbg)))
+	}
+
+	if len(result.Traceback) > maxTracebackLines {
+		result.Traceback = append(result.Traceback[:maxTracebackLines], "...")
+	}
+
+	return result
+}
+
+func describeFunction(dbg *lua.Debug) string {
+	switch {
+	case dbg.What == "main", dbg.LineDefined == 0:
+		return "main chunk"
+	case len(dbg.Name) > 0:
+		return fmt.Sprintf("function '%s'", dbg.Name)
+	case dbg.CurrentLine < 0:
+		return "function ?"
+	}
+
+	return fmt.Sprintf("function <%s:%d>", dbg.Source, dbg.LineDefined)
+}
@@ -0,0 +1,39 @@
+package scheduler
+
+import lua "github.com/yuin/gopher-lua"
+
+// Thread is a single Lua function being run by the scheduler.
+type Thread struct {
+	Id        int
+	state     *lua.LState
+	fn        *lua.LFunction
+	args      []lua.LValue
+	wakeTime  float64
+	wakeFrame int
+	started   bool
+	done      bool
+	cancelled bool
+	err       *ScriptError
+}
+
+// Cancel stops the thread from being resumed again. A thread that cancels itself stops at its next wait.
+func (t *Thread) Cancel() {
+	t.cancelled = true
+}
+
+// Delay postpones the next resume of the thread by the specified number of seconds.
+func (t *Thread) Delay(seconds float64) {
+	t.wakeTime += seconds
+}
+
+func (t *Thread) IsDone() bool {
+	return t.done
+}
+
+func (t *Thread) IsCancelled() bool {
+	return t.cancelled
+}
+
+func (t *Thread) isDue(time float64, frame int) bool {
+	return time >= t.wakeTime && frame >= t.wakeFrame
+}
@@ -0,0 +1,409 @@
+package tween
+
+import (
+	"errors"
+
+	"github.com/OpenDiablo2/AbyssEngine/node"
+)
+
+// Animation changes nodes over time once it is played: a tween, a delay, or a group of animations.
+type Animation interface {
+	// SetOnComplete sets the function called when the animation finishes. It is not called if it is cancelled.
+	SetOnComplete(onComplete func())
+	// Cancel stops the animation where it is, along with the animations in it.
+	Cancel()
+	IsPlaying() bool
+	IsDone() bool
+	IsCancelled() bool
+
+	// begin starts the animation from the beginning, reading the values it animates from.
+	begin()
+	// advance moves the animation on by elapsed seconds. It returns the time left over once the animation finished,
+	// or a negative number while it is still running.
+	advance(elapsed float64) float64
+	// finish marks the animation as done, and calls its completion function.
+	finish()
+	// nodes appends the nodes the animation changes to result.
+	nodes(result []*node.Node) []*node.Node
+	// adopt marks the animation as part of a group, which fails if it already is.
+	adopt() error
+	isOwned() bool
+}
+
+// base holds the state shared by all animations.
+type base struct {
+	onComplete func()
+	playing    bool
+	done       bool
+	cancelled  bool
+	owned      bool
+}
+
+func (b *base) SetOnComplete(onComplete func()) {
+	b.onComplete = onComplete
+}
+
+func (b *base) Cancel() {
+	if !b.playing {
+		return
+	}
+
+	b.playing = false
+	b.cancelled = true
+}
+
+func (b *base) IsPlaying() bool {
+	return b.playing
+}
+
+func (b *base) IsDone() bool {
+	return b.done
+}
+
+func (b *base) IsCancelled() bool {
+	return b.cancelled
+}
+
+func (b *base) begin() {
+	b.playing = true
+	b.done = false
+	b.cancelled = false
+}
+
+func (b *base) finish() {
+	b.playing = false
+	b.done = true
+
+	if b.onComplete != nil {
+		b.onComplete()
+	}
+}
+
+func (b *base) adopt() error {
+	if b.owned {
+		return errors.New("animation is already part of a group")
+	}
+
+	b.owned = true
+
+	return nil
+}
+
+func (b *base) isOwned() bool {
+	return b.owned
+}
+
+// Value is a number on a node that a tween can animate.
+type Value struct {
+	Get func() float64
+	Set func(value float64)
+}
+
+// NodeValue returns one of the values every node has: x, y, scaleX, scaleY, rotation or opacity.
+func NodeValue(target *node.Node, name string) (Value, error) {
+	var field *float64
+
+	switch name {
+	case "x":
+		field = &target.X
+	case "y":
+		field = &target.Y
+	case "scaleX":
+		field = &target.ScaleX
+	case "scaleY":
+		field = &target.ScaleY
+	case "rotation":
+		field = &target.Rotation
+	case "opacity":
+		return Value{
+			Get: func() float64 { return target.Opacity },
+			// easings may overshoot, but opacity cannot
+			Set: func(value float64) { target.Opacity = clamp(value, 0, 1) },
+		}, nil
+	default:
+		return Value{}, errors.New("unknown node value " + name)
+	}
+
+	return Value{
+		Get: func() float64 { return *field },
+		Set: func(value float64) { *field = value },
+	}, nil
+}
+
+// Tween moves values on a node from wherever they are when it starts to their targets.
+type Tween struct {
+	base
+
+	target   *node.Node
+	values   []Value
+	from     []float64
+	to       []float64
+	duration float64
+	elapsed  float64
+	easing   Easing
+}
+
+// New creates a tween of the given duration, in seconds. Values to animate are added with Add.
+func New(target *node.Node, duration float64, easing Easing) *Tween {
+	result := &Tween{
+		target:   target,
+		values:   make([]Value, 0),
+		to:       make([]float64, 0),
+		duration: duration,
+		easing:   easing,
+	}
+
+	return result
+}
+
+// Add makes the tween move a value to the given target.
+func (t *Tween) Add(value Value, to float64) {
+	t.values = append(t.values, value)
+	t.to = append(t.to, to)
+}
+
+func (t *Tween) begin() {
+	t.base.begin()
+	t.elapsed = 0
+	t.from = make([]float64, len(t.values))
+
+	for idx := range t.values {
+		t.from[idx] = t.values[idx].Get()
+	}
+}
+
+func (t *Tween) advance(elapsed float64) float64 {
+	t.elapsed += elapsed
+	progress := 1.0
+
+	if t.duration > 0 && t.elapsed < t.duration {
+		progress = t.elapsed / t.duration
+	}
+
+	eased := t.easing(progress)
+
+	for idx := range t.values {
+		t.values[idx].Set(t.from[idx] + (t.to[idx]-t.from[idx])*eased)
+	}
+
+	if progress < 1 {
+		return -1
+	}
+
+	return t.elapsed - t.duration
+}
+
+func (t *Tween) nodes(result []*node.Node) []*node.Node {
+	return append(result, t.target)
+}
+
+// Delay is an animation that does nothing for a while, to leave a gap in a sequence.
+type Delay struct {
+	base
+
+	duration float64
+	elapsed  float64
+}
+
+func NewDelay(duration float64) *Delay {
+	result := &Delay{
+		duration: duration,
+	}
+
+	return result
+}
+
+func (d *Delay) begin() {
+	d.base.begin()
+	d.elapsed = 0
+}
+
+func (d *Delay) advance(elapsed float64) float64 {
+	d.elapsed += elapsed
+
+	if d.elapsed < d.duration {
+		return -1
+	}
+
+	return d.elapsed - d.duration
+}
+
+func (d *Delay) nodes(result []*node.Node) []*node.Node {
+	return result
+}
+
+// Sequence plays animations one after another.
+type Sequence struct {
+	base
+
+	animations []Animation
+	current    int
+}
+
+func NewSequence(animations ...Animation) (*Sequence, error) {
+	if err := adoptAll(animations); err != nil {
+		return nil, err
+	}
+
+	result := &Sequence{
+		animations: animations,
+	}
+
+	return result, nil
+}
+
+func (s *Sequence) Cancel() {
+	if s.playing && s.current < len(s.animations) {
+		s.animations[s.current].Cancel()
+	}
+
+	s.base.Cancel()
+}
+
+func (s *Sequence) begin() {
+	s.base.begin()
+	s.current = 0
+
+	if len(s.animations) > 0 {
+		s.animations[0].begin()
+	}
+}
+
+// advance carries the time left over by each animation on to the next, so that the sequence does not drift.
+func (s *Sequence) advance(elapsed float64) float64 {
+	for s.current < len(s.animations) {
+		// an animation cancelled by itself cancels the rest of the sequence
+		if s.animations[s.current].IsCancelled() {
+			s.Cancel()
+			return -1
+		}
+
+		leftover := s.animations[s.current].advance(elapsed)
+
+		if leftover < 0 {
+			return -1
+		}
+
+		s.animations[s.current].finish()
+		s.current++
+		elapsed = leftover
+
+		if s.current < len(s.animations) {
+			s.animations[s.current].begin()
+		}
+	}
+
+	return elapsed
+}
+
+func (s *Sequence) nodes(result []*node.Node) []*node.Node {
+	for idx := range s.animations {
+		result = s.animations[idx].nodes(result)
+	}
+
+	return result
+}
+
+// Parallel plays animations at the same time, and finishes when they all have.
+type Parallel struct {
+	base
+
+	animations []Animation
+}
+
+func NewParallel(animations ...Animation) (*Parallel, error) {
+	if err := adoptAll(animations); err != nil {
+		return nil, err
+	}
+
+	result := &Parallel{
+		animations: animations,
+	}
+
+	return result, nil
+}
+
+func (p *Parallel) Cancel() {
+	if p.playing {
+		for idx := range p.animations {
+			p.animations[idx].Cancel()
+		}
+	}
+
+	p.base.Cancel()
+}
+
+func (p *Parallel) begin() {
+	p.base.begin()
+
+	for idx := range p.animations {
+		p.animations[idx].begin()
+	}
+}
+
+// advance returns the time left over by the last animation to finish, which is the least left over.
+func (p *Parallel) advance(elapsed float64) float64 {
+	result := elapsed
+
+	for idx := range p.animations {
+		if !p.animations[idx].IsPlaying() {
+			continue
+		}
+
+		leftover := p.animations[idx].advance(elapsed)
+
+		if leftover < 0 {
+			result = -1
+			continue
+		}
+
+		p.animations[idx].finish()
+
+		if result >= 0 && leftover < result {
+			result = leftover
+		}
+	}
+
+	return result
+}
+
+func (p *Parallel) nodes(result []*node.Node) []*node.Node {
+	for idx := range p.animations {
+		result = p.animations[idx].nodes(result)
+	}
+
+	return result
+}
+
+// adoptAll marks the animations as part of a group. Nothing is marked if any of them cannot be.
+func adoptAll(animations []Animation) error {
+	for idx := range animations {
+		if animations[idx].IsPlaying() {
+			return errors.New("animations must not be playing when they are grouped")
+		}
+
+		if animations[idx].isOwned() {
+			return errors.New("animation is already part of a group")
+		}
+	}
+
+	for idx := range animations {
+		if err := animations[idx].adopt(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func clamp(value, min, max float64) float64 {
+	if value < min {
+		return min
+	}
+
+	if value > max {
+		return max
+	}
+
+	return value
+}
@@ -0,0 +1,140 @@
+package tween
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Easing maps the progress of a tween (from 0 to 1) to how far along its values are. The result may go past 0 or 1
+// (e.g. for the back and elastic easings), but is 0 at the start and 1 at the end.
+type Easing func(progress float64) float64
+
+const (
+	backOvershoot = 1.70158
+	elasticPeriod = 2 * math.Pi / 3
+)
+
+func Linear(progress float64) float64 {
+	return progress
+}
+
+// easeOut turns an ease in function into the matching ease out.
+func easeOut(easeIn Easing) Easing {
+	return func(progress float64) float64 {
+		return 1 - easeIn(1-progress)
+	}
+}
+
+// easeInOut turns an ease in function into one that eases in for the first half and out for the second.
+func easeInOut(easeIn Easing) Easing {
+	return func(progress float64) float64 {
+		if progress < 0.5 {
+			return easeIn(progress*2) / 2
+		}
+
+		return 1 - easeIn((1-progress)*2)/2
+	}
+}
+
+func quadIn(progress float64) float64 {
+	return progress * progress
+}
+
+func cubicIn(progress float64) float64 {
+	return progress * progress * progress
+}
+
+func sineIn(progress float64) float64 {
+	return 1 - math.Cos(progress*math.Pi/2)
+}
+
+func expoIn(progress float64) float64 {
+	if progress == 0 {
+		return 0
+	}
+
+	return math.Pow(2, 10*progress-10)
+}
+
+func backIn(progress float64) float64 {
+	return progress * progress * ((backOvershoot+1)*progress - backOvershoot)
+}
+
+func elasticIn(progress float64) float64 {
+	if progress == 0 || progress == 1 {
+		return progress
+	}
+
+	return -math.Pow(2, 10*progress-10) * math.Sin((progress*10-10.75)*elasticPeriod)
+}
+
+func bounceOut(progress float64) float64 {
+	const n, d = 7.5625, 2.75
+
+	switch {
+	case progress < 1/d:
+		return n * progress * progress
+	case progress < 2/d:
+		progress -= 1.5 / d
+		return n*progress*progress + 0.75
+	case progress < 2.5/d:
+		progress -= 2.25 / d
+		return n*progress*progress + 0.9375
+	}
+
+	progress -= 2.625 / d
+
+	return n*progress*progress + 0.984375
+}
+
+var easings = map[string]Easing{
+	"linear":       Linear,
+	"quadIn":       quadIn,
+	"quadOut":      easeOut(quadIn),
+	"quadInOut":    easeInOut(quadIn),
+	"cubicIn":      cubicIn,
+	"cubicOut":     easeOut(cubicIn),
+	"cubicInOut":   easeInOut(cubicIn),
+	"sineIn":       sineIn,
+	"sineOut":      easeOut(sineIn),
+	"sineInOut":    easeInOut(sineIn),
+	"expoIn":       expoIn,
+	"expoOut":      easeOut(expoIn),
+	"expoInOut":    easeInOut(expoIn),
+	"backIn":       backIn,
+	"backOut":      easeOut(backIn),
+	"backInOut":    easeInOut(backIn),
+	"elasticIn":    elasticIn,
+	"elasticOut":   easeOut(elasticIn),
+	"elasticInOut": easeInOut(elasticIn),
+	"bounceIn":     easeOut(bounceOut),
+	"bounceOut":    bounceOut,
+	"bounceInOut":  easeInOut(easeOut(bounceOut)),
+}
+
+// StringToEasing returns the easing with the given name, such as "linear", "quadOut" or "bounceInOut". Names are not
+// case sensitive.
+func StringToEasing(s string) (Easing, error) {
+	for name, easing := range easings {
+		if strings.EqualFold(name, s) {
+			return easing, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown easing %s, expected one of: %s", s, strings.Join(EasingNames(), ", "))
+}
+
+// EasingNames returns the names of all the easings, sorted.
+func EasingNames() []string {
+	result := make([]string, 0, len(easings))
+
+	for name := range easings {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
@@ -0,0 +1,148 @@
+package tween
+
+import (
+	"fmt"
+
+	"github.com/OpenDiablo2/AbyssEngine/common"
+	lua "github.com/yuin/gopher-lua"
+)
+
+var luaTypeExportName = "tween"
+var LuaTypeExport = common.LuaTypeExport{
+	Name: luaTypeExportName,
+	//ConstructorFunc: newLuaEntity,
+	Methods: map[string]lua.LGFunction{
+		"play":       luaPlay,
+		"cancel":     luaCancel,
+		"playing":    luaIsPlaying,
+		"done":       luaIsDone,
+		"cancelled":  luaIsCancelled,
+		"onComplete": luaOnComplete,
+	},
+}
+
+// luaAnimation is the Lua value of an animation, which keeps the manager that plays it.
+type luaAnimation struct {
+	animation Animation
+	manager   *Manager
+}
+
+// ToLua returns the Lua value of an animation that is played by the manager.
+func (m *Manager) ToLua(l *lua.LState, animation Animation) *lua.LUserData {
+	result := l.NewUserData()
+	result.Value = &luaAnimation{animation: animation, manager: m}
+
+	l.SetMetatable(result, l.GetTypeMetatable(luaTypeExportName))
+
+	return result
+}
+
+func FromLua(ud *lua.LUserData) (Animation, error) {
+	v, ok := ud.Value.(*luaAnimation)
+
+	if !ok {
+		return nil, fmt.Errorf("failed to convert")
+	}
+
+	return v.animation, nil
+}
+
+func luaPlay(l *lua.LState) int {
+	v, ok := l.ToUserData(1).Value.(*luaAnimation)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if err := v.manager.Play(v.animation); err != nil {
+		l.RaiseError(err.Error())
+		return 0
+	}
+
+	l.Push(l.Get(1))
+
+	return 1
+}
+
+func luaCancel(l *lua.LState) int {
+	animation, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	animation.Cancel()
+
+	return 0
+}
+
+func luaIsPlaying(l *lua.LState) int {
+	animation, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(lua.LBool(animation.IsPlaying()))
+
+	return 1
+}
+
+func luaIsDone(l *lua.LState) int {
+	animation, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(lua.LBool(animation.IsDone()))
+
+	return 1
+}
+
+func luaIsCancelled(l *lua.LState) int {
+	animation, err := FromLua(l.ToUserData(1))
+
+	if err != nil {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	l.Push(lua.LBool(animation.IsCancelled()))
+
+	return 1
+}
+
+// luaOnComplete sets the function called with the animation when it finishes, or clears it if nil. It returns the
+// animation, so that it can be chained with play.
+func luaOnComplete(l *lua.LState) int {
+	ud := l.ToUserData(1)
+	v, ok := ud.Value.(*luaAnimation)
+
+	if !ok {
+		l.RaiseError("failed to convert")
+		return 0
+	}
+
+	if l.Get(2) == lua.LNil {
+		v.animation.SetOnComplete(nil)
+		l.Push(ud)
+
+		return 1
+	}
+
+	fn := l.CheckFunction(2)
+	manager := v.manager
+
+	v.animation.SetOnComplete(func() {
+		manager.runLua(fn, ud)
+	})
+
+	l.Push(ud)
+
+	return 1
+}
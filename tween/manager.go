@@ -0,0 +1,114 @@
+package tween
+
+import (
+	"errors"
+
+	"github.com/OpenDiablo2/AbyssEngine/node"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// playing is an animation being played, along with the nodes it changes that have been seen in the tree.
+type playing struct {
+	animation Animation
+	attached  map[*node.Node]bool
+}
+
+// Manager plays animations, advancing them once per frame. An animation is cancelled when any node it changes is
+// destroyed, or removed from the tree after having been in it.
+type Manager struct {
+	playing []*playing
+	runLua  func(fn *lua.LFunction, args ...lua.LValue)
+}
+
+// NewManager creates a manager that runs the Lua completion functions of animations with runLua.
+func NewManager(runLua func(fn *lua.LFunction, args ...lua.LValue)) *Manager {
+	result := &Manager{
+		playing: make([]*playing, 0),
+		runLua:  runLua,
+	}
+
+	return result
+}
+
+// Play starts an animation from the beginning. Animations that are already playing carry on, and animations that are
+// part of a group are played by the group.
+func (m *Manager) Play(animation Animation) error {
+	if animation.isOwned() {
+		return errors.New("animations in a group are played by the group")
+	}
+
+	if animation.IsPlaying() {
+		return nil
+	}
+
+	animation.begin()
+
+	m.playing = append(m.playing, &playing{animation: animation, attached: make(map[*node.Node]bool)})
+
+	return nil
+}
+
+// Update advances the animations by elapsed seconds. Completion functions are called as animations finish.
+func (m *Manager) Update(elapsed float64, root *node.Node) {
+	current := m.playing
+	m.playing = make([]*playing, 0, len(current))
+
+	for _, entry := range current {
+		if !entry.animation.IsPlaying() {
+			continue
+		}
+
+		if entry.lostNode(root) {
+			entry.animation.Cancel()
+			continue
+		}
+
+		if entry.animation.advance(elapsed) >= 0 {
+			entry.animation.finish()
+			continue
+		}
+
+		m.playing = append(m.playing, entry)
+	}
+}
+
+// CancelAll cancels every animation that is playing.
+func (m *Manager) CancelAll() {
+	for _, entry := range m.playing {
+		entry.animation.Cancel()
+	}
+
+	m.playing = make([]*playing, 0)
+}
+
+// Count returns the number of animations that are playing.
+func (m *Manager) Count() int {
+	return len(m.playing)
+}
+
+// lostNode reports whether a node the animation changes was destroyed, or has left the tree.
+func (p *playing) lostNode(root *node.Node) bool {
+	for _, target := range p.animation.nodes(nil) {
+		if target.ShouldRemove {
+			return true
+		}
+
+		if isInTree(target, root) {
+			p.attached[target] = true
+		} else if p.attached[target] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isInTree(target, root *node.Node) bool {
+	for current := target; current != nil; current = current.Parent {
+		if current == root {
+			return true
+		}
+	}
+
+	return false
+}
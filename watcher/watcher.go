@@ -0,0 +1,151 @@
+package watcher
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher polls a directory tree and reports the files that were added, changed or removed since the last poll.
+// Hidden files and directories (names starting with a dot) are ignored.
+type Watcher struct {
+	root     string
+	interval time.Duration
+	onChange func(paths []string)
+	files    map[string]fileState
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New creates a watcher for the given root directory. The onChange function is called from the watcher's own
+// goroutine with the changed paths, relative to the root and using forward slashes.
+func New(root string, interval time.Duration, onChange func(paths []string)) *Watcher {
+	result := &Watcher{
+		root:     root,
+		interval: interval,
+		onChange: onChange,
+	}
+
+	return result
+}
+
+// Start takes a snapshot of the directory tree and starts polling it.
+func (w *Watcher) Start() {
+	if w.stop != nil {
+		return
+	}
+
+	w.files = w.scan()
+	w.stop = make(chan struct{})
+	w.wg.Add(1)
+
+	go w.run()
+}
+
+// Stop stops polling, and waits for a poll that is in progress to finish.
+func (w *Watcher) Stop() {
+	if w.stop == nil {
+		return
+	}
+
+	close(w.stop)
+	w.wg.Wait()
+	w.stop = nil
+}
+
+func (w *Watcher) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+func (w *Watcher) poll() {
+	files := w.scan()
+	changed := make([]string, 0)
+
+	for path, state := range files {
+		if previous, ok := w.files[path]; !ok || previous != state {
+			changed = append(changed, path)
+		}
+	}
+
+	for path := range w.files {
+		if _, ok := files[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+
+	w.files = files
+
+	if len(changed) == 0 {
+		return
+	}
+
+	sort.Strings(changed)
+	w.onChange(changed)
+}
+
+func (w *Watcher) scan() map[string]fileState {
+	result := make(map[string]fileState)
+
+	err := filepath.WalkDir(w.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// files can disappear while the tree is being walked
+			return nil
+		}
+
+		if path != w.root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+
+		if err != nil {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(w.root, path)
+
+		if err != nil {
+			return nil
+		}
+
+		result[filepath.ToSlash(relPath)] = fileState{modTime: info.ModTime(), size: info.Size()}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to scan %s for changes", w.root)
+	}
+
+	return result
+}